@@ -1,27 +1,73 @@
 package api
 
 import (
-	"database/sql"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/helpers"
 	"github.com/mrlightwood/golang-products-api/model"
 	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Api struct {
 	Http     *echo.Echo
 	conf     *config.Config
 	cs       service.CategoryService
+	bs       service.BrandService
 	ps       service.ProductService
+	prs      service.PromotionService
+	tcs      service.TaxClassService
+	rs       service.ReviewService
+	ss       service.SupplierService
+	rps      service.ReportService
+	bas      service.BatchService
+	ims      service.ImportService
+	aks      service.ApiKeyService
+	ts       service.TokenService
+	is       service.IdempotencyService
 	apiInfo  ApiInfo
 	validate *validator.Validate
+	// OpenAPI document of the registered routes
+	spec []byte
+	// Serves the metrics, nil without
+	metrics http.Handler
+	// Paths of the registered routes
+	routes map[string]bool
+}
+
+// Services used by the API handlers
+type Services struct {
+	Categories service.CategoryService
+	Brands     service.BrandService
+	Products   service.ProductService
+	Promotions service.PromotionService
+	TaxClasses service.TaxClassService
+	Reviews    service.ReviewService
+	Suppliers  service.SupplierService
+	Reports    service.ReportService
+	Batches    service.BatchService
+	Imports    service.ImportService
+	// Authenticate the clients with API keys and tokens, the API is open without both
+	ApiKeys service.ApiKeyService
+	Tokens  service.TokenService
+	// Replays the responses of the create requests with an idempotency key to their retries
+	Idempotency service.IdempotencyService
+	// Registry of the metrics served at /metrics along with the ones of the requests, no metrics without
+	Metrics *prometheus.Registry
 }
 
 type ApiInfo struct {
@@ -30,36 +76,148 @@ type ApiInfo struct {
 	Routes  []string
 }
 
-func NewApi(conf *config.Config, cs service.CategoryService, ps service.ProductService) *Api {
+func NewApi(conf *config.Config, services Services) *Api {
 	api := &Api{}
 	api.validate = validator.New()
+	registerValidations(api.validate)
 	api.conf = conf
-	api.cs = cs
-	api.ps = ps
+	api.cs = services.Categories
+	api.bs = services.Brands
+	api.ps = services.Products
+	api.prs = services.Promotions
+	api.tcs = services.TaxClasses
+	api.rs = services.Reviews
+	api.ss = services.Suppliers
+	api.rps = services.Reports
+	api.bas = services.Batches
+	api.ims = services.Imports
+	api.aks = services.ApiKeys
+	api.ts = services.Tokens
+	api.is = services.Idempotency
 	api.Http = echo.New()
 	api.Http.Logger.SetLevel(log.Lvl(conf.LogLevel))
 	api.apiInfo.Address = ":" + strconv.Itoa(api.conf.Api.HttpPort)
 	api.Http.HideBanner = true
+	// The address is logged along with the routes
+	api.Http.HidePort = true
+	api.Http.HTTPErrorHandler = api.handleError
+	api.Http.IPExtractor = ipExtractor(conf)
+	api.Http.Pre(abort)
 	api.Http.Pre(middleware.RemoveTrailingSlash())
+	api.Http.Binder = &binder{}
+	// Measured first, rejected requests count as well
+	if services.Metrics != nil {
+		api.Http.Use(newHttpMetrics(services.Metrics, api.route).measure)
+		api.apiInfo.MW = append(api.apiInfo.MW, "Metrics")
+	}
+	api.Http.Use(api.trace)
+	api.apiInfo.MW = append(api.apiInfo.MW, "Tracing")
+	api.Http.Use(api.identify)
+	api.apiInfo.MW = append(api.apiInfo.MW, "RequestID")
 	if conf.Api.Logging {
-		api.Http.Use(middleware.Logger())
+		api.Http.Use(api.accessLog)
 		api.apiInfo.MW = append(api.apiInfo.MW, "Logger")
 	}
+	api.Http.Use(api.negotiate)
+	api.apiInfo.MW = append(api.apiInfo.MW, "Negotiation")
+	limiter := newRateLimiter(conf)
+	// Guessing credentials is limited before they are looked up
+	if conf.Api.RateLimit.Enabled && api.authenticating() {
+		api.Http.Use(limiter.guard)
+		api.apiInfo.MW = append(api.apiInfo.MW, "AuthenticationLimit")
+	}
+	if api.authenticating() {
+		api.Http.Use(api.authenticate)
+		api.apiInfo.MW = append(api.apiInfo.MW, "Authentication")
+	} else if conf.Api.EditorToken != "" {
+		api.Http.Use(api.editorToken)
+		api.apiInfo.MW = append(api.apiInfo.MW, "EditorToken")
+	}
+	// Clients are told apart once authenticated
+	if conf.Api.RateLimit.Enabled {
+		api.Http.Use(limiter.limit)
+		api.apiInfo.MW = append(api.apiInfo.MW, "RateLimit")
+	}
+	// Roles of the routes, checked when clients are authenticated: viewers read,
+	// editors write as well, admins manage the API keys. Any client reads the
+	// metrics, scrapers don't need more than a read key.
+	// Submitted reviews await moderation, viewers send them.
+	// Moderation, suppliers and costs are for editors only, the editor token
+	// stands for the role when clients aren't authenticated.
+	viewer, editor, admin := api.permit(model.RoleViewer), api.permit(model.RoleEditor), api.permit(model.RoleAdmin)
+	editorOnly := api.require(model.RoleEditor)
 	api.Http.GET("/", api.index)
-	api.Http.GET("/api/categories", api.getCategories)
-	api.Http.GET("/api/categories/:id", api.getCategory)
-	api.Http.POST("/api/categories", api.createCategory)
-	api.Http.PUT("/api/categories/:id", api.updateCategory)
-	api.Http.DELETE("/api/categories/:id", api.deleteCategory)
-
-	api.Http.GET("/api/products", api.getProducts)
-	api.Http.GET("/api/products/:id", api.getProduct)
-	api.Http.POST("/api/products", api.createProduct)
-	api.Http.PUT("/api/products/:id", api.updateProduct)
-	api.Http.DELETE("/api/products/:id", api.deleteProduct)
+	api.Http.GET("/openapi.json", api.getOpenAPI)
+	if services.Metrics != nil {
+		api.metrics = metricsHandler(services.Metrics)
+		api.Http.GET("/metrics", api.getMetrics, viewer)
+	}
+	api.Http.GET("/api/categories", api.getCategories, viewer)
+	api.Http.GET("/api/categories/:id", api.getCategory, viewer)
+	api.Http.GET("/api/categories/by-slug/:slug", api.getCategoryBySlug, viewer)
+	api.Http.POST("/api/categories", api.createCategory, editor, api.idempotent)
+	api.Http.PUT("/api/categories/:id", api.updateCategory, editor)
+	api.Http.DELETE("/api/categories/:id", api.deleteCategory, editor)
+
+	api.Http.GET("/api/brands", api.getBrands, viewer)
+	api.Http.GET("/api/brands/:id", api.getBrand, viewer)
+	api.Http.POST("/api/brands", api.createBrand, editor, api.idempotent)
+	api.Http.PUT("/api/brands/:id", api.updateBrand, editor)
+	api.Http.DELETE("/api/brands/:id", api.deleteBrand, editor)
+
+	api.Http.GET("/api/products", api.getProducts, viewer)
+	api.Http.GET("/api/products/:id", api.getProduct, viewer)
+	api.Http.GET("/api/products/by-slug/:slug", api.getProductBySlug, viewer)
+	api.Http.GET("/api/products/by-barcode/:code", api.getProductByBarcode, viewer)
+	api.Http.POST("/api/products", api.createProduct, editor, api.idempotent)
+	api.Http.PUT("/api/products/:id", api.updateProduct, editor)
+	api.Http.DELETE("/api/products/:id", api.deleteProduct, editor)
+	api.Http.GET("/api/products/:id/related", api.getRelatedProducts, viewer)
+	api.Http.POST("/api/products/:id/related", api.addRelatedProducts, editor)
+	api.Http.PUT("/api/products/:id/related", api.replaceRelatedProducts, editor)
+	api.Http.DELETE("/api/products/:id/related", api.removeRelatedProducts, editor)
+	api.Http.GET("/api/products/:id/reviews", api.getReviews, viewer)
+	api.Http.POST("/api/products/:id/reviews", api.createReview, viewer, api.idempotent)
+	api.Http.PUT("/api/reviews/:id/moderation", api.moderateReview, editorOnly)
+
+	api.Http.GET("/api/products/:id/suppliers", api.getProductSuppliers, editorOnly)
+	api.Http.PUT("/api/products/:id/suppliers", api.setProductSuppliers, editorOnly)
+	api.Http.GET("/api/suppliers", api.getSuppliers, editorOnly)
+	api.Http.GET("/api/suppliers/:id", api.getSupplier, editorOnly)
+	api.Http.POST("/api/suppliers", api.createSupplier, editorOnly, api.idempotent)
+	api.Http.PUT("/api/suppliers/:id", api.updateSupplier, editorOnly)
+	api.Http.DELETE("/api/suppliers/:id", api.deleteSupplier, editorOnly)
+	api.Http.GET("/api/reports/margins", api.getMarginReport, editorOnly)
+
+	api.Http.GET("/api/promotions", api.getPromotions, viewer)
+	api.Http.GET("/api/promotions/:id", api.getPromotion, viewer)
+	api.Http.POST("/api/promotions", api.createPromotion, editor, api.idempotent)
+	api.Http.PUT("/api/promotions/:id", api.updatePromotion, editor)
+	api.Http.DELETE("/api/promotions/:id", api.deletePromotion, editor)
+
+	api.Http.GET("/api/tax-classes", api.getTaxClasses, viewer)
+	api.Http.GET("/api/tax-classes/:id", api.getTaxClass, viewer)
+	api.Http.POST("/api/tax-classes", api.createTaxClass, editor, api.idempotent)
+	api.Http.PUT("/api/tax-classes/:id", api.updateTaxClass, editor)
+	api.Http.DELETE("/api/tax-classes/:id", api.deleteTaxClass, editor)
+
+	if api.aks != nil {
+		api.Http.GET("/api/keys", api.getApiKeys, admin)
+		api.Http.GET("/api/keys/:id", api.getApiKey, admin)
+		api.Http.POST("/api/keys", api.createApiKey, admin)
+		api.Http.POST("/api/keys/:id/rotate", api.rotateApiKey, admin)
+		api.Http.DELETE("/api/keys/:id", api.revokeApiKey, admin)
+	}
+
+	api.Http.POST("/api/batch", api.executeBatch, editor, api.idempotent)
+	api.Http.POST("/api/import/products", api.importProducts, editor, api.idempotent)
+	api.Http.GET("/api/export/products", api.exportProducts, viewer)
+	api.routes = map[string]bool{}
 	for _, r := range api.Http.Routes() {
 		api.apiInfo.Routes = append(api.apiInfo.Routes, fmt.Sprintf("%s %s", r.Path, r.Method))
+		api.routes[r.Path] = true
 	}
+	api.spec, _ = json.Marshal(api.openAPI())
 	return api
 }
 
@@ -71,8 +229,26 @@ func (api *Api) GetApiInfo() ApiInfo {
 	return api.apiInfo
 }
 
+// route returns the route template of the request, "unmatched" for the paths
+// of no route, the path of the request takes its place then
+func (api *Api) route(c echo.Context) string {
+	if !api.routes[c.Path()] {
+		return "unmatched"
+	}
+	return c.Path()
+}
+
+// explorer browses and tries the operations of the OpenAPI document
+//
+//go:embed explorer.html
+var explorer []byte
+
 func (api *Api) index(c echo.Context) error {
-	return c.File("static/html/index.html")
+	return c.HTMLBlob(http.StatusOK, explorer)
+}
+
+func (api *Api) getOpenAPI(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, api.spec)
 }
 
 func (api *Api) getCategory(c echo.Context) error {
@@ -80,18 +256,36 @@ func (api *Api) getCategory(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
 	}
-	cat, err := api.cs.GetCategory(id)
+	cat, err := api.cs.GetCategory(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, cat)
+}
+
+func (api *Api) getCategoryBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	cat, err := api.cs.GetCategoryBySlug(c.Request().Context(), slug)
 	if err != nil {
 		return err
 	}
-	if cat == nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Category `id` = ", id, " not found")
+	if cat.Slug != slug {
+		return slugRedirect(c, "/api/categories/by-slug/", cat.Slug)
+	}
+	return render(c, http.StatusOK, cat)
+}
+
+// slugRedirect sends clients of a former slug to the current one
+func slugRedirect(c echo.Context, prefix string, slug string) error {
+	location := prefix + url.PathEscape(slug)
+	if query := c.QueryString(); query != "" {
+		location += "?" + query
 	}
-	return c.JSON(http.StatusOK, cat)
+	return c.Redirect(http.StatusMovedPermanently, location)
 }
 
 func (api *Api) getCategories(c echo.Context) error {
-	cats, err := api.cs.GetCategories()
+	cats, err := api.cs.GetCategories(c.Request().Context())
 	if err != nil {
 		return err
 	}
@@ -99,22 +293,22 @@ func (api *Api) getCategories(c echo.Context) error {
 		cats = []*model.Category{}
 	}
 
-	return c.JSON(http.StatusOK, cats)
+	return render(c, http.StatusOK, cats)
 }
 
 func (api *Api) createCategory(c echo.Context) error {
 	req := &model.Category{}
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return bindError(err)
 	}
 	if err := api.validate.Struct(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return err
 	}
-	res, err := api.cs.CreateCategory(req)
+	res, err := api.cs.CreateCategory(c.Request().Context(), req)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusCreated, map[string]*int{"id": res})
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
 }
 
 func (api *Api) updateCategory(c echo.Context) error {
@@ -124,19 +318,14 @@ func (api *Api) updateCategory(c echo.Context) error {
 	}
 	req := &model.Category{}
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return bindError(err)
 	}
 	if err := api.validate.Struct(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return err
 	}
 	req.Id = id
-	if err = api.cs.UpdateCategory(req); err != nil {
-		if err != sql.ErrNoRows {
-			return err
-		} else {
-			return echo.NewHTTPError(http.StatusNotFound, "Category `id` = ", id, " not found")
-		}
-
+	if err = api.cs.UpdateCategory(c.Request().Context(), req); err != nil {
+		return err
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -146,63 +335,311 @@ func (api *Api) deleteCategory(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
 	}
-	if err = api.cs.DeleteCategory(id); err != nil {
-		if err != sql.ErrNoRows {
-			return err
-		} else {
-			return echo.NewHTTPError(http.StatusNotFound, "Category `id` = ", id, " not found")
-		}
+	if err = api.cs.DeleteCategory(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getBrand(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	brand, err := api.bs.GetBrand(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, brand)
+}
+
+func (api *Api) getBrands(c echo.Context) error {
+	brands, err := api.bs.GetBrands(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	if brands == nil {
+		brands = []*model.Brand{}
+	}
+	return render(c, http.StatusOK, brands)
+}
+
+func (api *Api) createBrand(c echo.Context) error {
+	req := &model.Brand{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	res, err := api.bs.CreateBrand(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
+}
+
+func (api *Api) updateBrand(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.Brand{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
 	}
+	req.Id = id
+	if err = api.bs.UpdateBrand(c.Request().Context(), req); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
 
+func (api *Api) deleteBrand(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	if err = api.bs.DeleteBrand(c.Request().Context(), id); err != nil {
+		return err
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
 func (api *Api) getProduct(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Product `id` = ", id, " not found")
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	view, err := api.priceView(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	prod, err := api.ps.GetProduct(c.Request().Context(), id, view)
+	if err != nil {
+		return err
+	}
+	if err = api.visible(c, prod, "id", id); err != nil {
+		return err
+	}
+	api.present(c, units, prod)
+	return render(c, http.StatusOK, prod)
+}
+
+func (api *Api) getProductBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	view, err := api.priceView(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	prod, err := api.ps.GetProductBySlug(c.Request().Context(), slug, view)
+	if err != nil {
+		return err
+	}
+	if err = api.visible(c, prod, "slug", slug); err != nil {
+		return err
 	}
-	prod, err := api.ps.GetProduct(id)
+	if prod.Slug != slug {
+		return slugRedirect(c, "/api/products/by-slug/", prod.Slug)
+	}
+	api.present(c, units, prod)
+	return render(c, http.StatusOK, prod)
+}
+
+func (api *Api) getProductByBarcode(c echo.Context) error {
+	code := c.Param("code")
+	if err := api.validate.Var(code, "gtin"); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `code`")
+	}
+	view, err := api.priceView(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	prod, err := api.ps.GetProductByBarcode(c.Request().Context(), code, view)
 	if err != nil {
 		return err
 	}
-	if prod == nil {
-		return c.String(http.StatusNotFound, "")
+	if err = api.visible(c, prod, "barcode", code); err != nil {
+		return err
 	}
-	return c.JSON(http.StatusOK, prod)
+	api.present(c, units, prod)
+	return render(c, http.StatusOK, prod)
 }
 
 func (api *Api) getProducts(c echo.Context) error {
-	var products []*model.Product
-	var err error
-	category, err := strconv.Atoi(c.QueryParam("category"))
+	filter, err := api.productFilter(c)
+	if err != nil {
+		return err
+	}
+	view, err := api.priceView(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
 	if err != nil {
-		products, err = api.ps.GetProducts(nil)
-	} else {
-		products, err = api.ps.GetProducts(&category)
+		return err
+	}
+	facets := c.QueryParam("facets")
+	if err := api.validate.Var(facets, "omitempty,oneof=brands"); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `facets`")
 	}
+	products, err := api.ps.GetProducts(c.Request().Context(), filter, view)
 	if err != nil {
 		return err
 	}
 	if products == nil {
 		products = []*model.Product{}
 	}
-	return c.JSON(http.StatusOK, products)
+	api.present(c, units, products...)
+	// Facets wrap the products, plain listings stay a bare array
+	if facets == "" {
+		return render(c, http.StatusOK, products)
+	}
+	listing := &model.ProductListing{Products: products}
+	if listing.Facets.Brands, err = api.ps.GetBrandFacets(c.Request().Context(), filter); err != nil {
+		return err
+	}
+	if listing.Facets.Brands == nil {
+		listing.Facets.Brands = []*model.BrandFacet{}
+	}
+	return render(c, http.StatusOK, listing)
+}
+
+// productFilter reads the listing query params. Only published products
+// are listed, unless an editor asks to include drafts.
+func (api *Api) productFilter(c echo.Context) (*model.ProductFilter, error) {
+	filter := &model.ProductFilter{Statuses: []string{model.ProductStatusPublished}}
+	if category, err := strconv.Atoi(c.QueryParam("category")); err == nil {
+		filter.Category = &category
+	}
+	if brand, err := strconv.Atoi(c.QueryParam("brand")); err == nil {
+		filter.Brand = &brand
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, bound := range []struct {
+		param string
+		max   **float64
+		from  func(float64, string) float64
+	}{
+		{"weight_max", &filter.WeightMax, model.WeightFromUnits},
+		{"length_max", &filter.LengthMax, model.LengthFromUnits},
+		{"width_max", &filter.WidthMax, model.LengthFromUnits},
+		{"height_max", &filter.HeightMax, model.LengthFromUnits},
+	} {
+		if value := c.QueryParam(bound.param); value != "" {
+			max, err := strconv.ParseFloat(value, 64)
+			if err != nil || !(max > 0) {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "Bad request param `"+bound.param+"`")
+			}
+			max = bound.from(max, units)
+			*bound.max = &max
+		}
+	}
+	if sort := c.QueryParam("sort"); sort != "" {
+		key := strings.TrimPrefix(sort, "-")
+		if err := api.validate.Var(key, "oneof="+strings.Join(model.ProductSortKeys, " ")); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Bad request param `sort`")
+		}
+		filter.Sort = sort
+	}
+	if c.QueryParam("include") == "drafts" {
+		if !api.isEditor(c) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, "Including drafts requires editor access")
+		}
+		filter.Statuses = append(filter.Statuses, model.ProductStatusDraft)
+	}
+	return filter, nil
+}
+
+// units reads the `units` query param, weights and dimensions of requests
+// and responses are given in these units
+func (api *Api) units(c echo.Context) (string, error) {
+	units := c.QueryParam("units")
+	if units == "" {
+		return model.UnitsMetric, nil
+	}
+	if err := api.validate.Var(units, "oneof=metric imperial"); err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "Bad request param `units`")
+	}
+	return units, nil
+}
+
+// present prepares products for the response: weights and dimensions
+// are converted to the requested units and costs hidden from non-editors
+func (api *Api) present(c echo.Context, units string, products ...*model.Product) {
+	editor := api.isEditor(c)
+	for _, product := range products {
+		product.ToUnits(units)
+		if !editor {
+			product.CostPrice = nil
+		}
+	}
+}
+
+// visibleStatuses returns the statuses of the products the caller may see,
+// editors see them all
+func (api *Api) visibleStatuses(c echo.Context) []string {
+	if api.isEditor(c) {
+		return nil
+	}
+	return []string{model.ProductStatusPublished}
+}
+
+// visible returns the NotFoundError of a product hidden from the caller,
+// looked up by `field`
+func (api *Api) visible(c echo.Context, product *model.Product, field string, value interface{}) error {
+	if product.Status != model.ProductStatusPublished && !api.isEditor(c) {
+		return &service.NotFoundError{Entity: "product", Field: field, Value: value}
+	}
+	return nil
+}
+
+// priceView reads the `region` and `prices` query params
+func (api *Api) priceView(c echo.Context) (*model.PriceView, error) {
+	view := &model.PriceView{Region: strings.ToUpper(c.QueryParam("region")), Prices: c.QueryParam("prices")}
+	if err := api.validate.Struct(view); err != nil {
+		return nil, err
+	}
+	return view, nil
 }
 
 func (api *Api) createProduct(c echo.Context) error {
 	req := &model.Product{}
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return bindError(err)
 	}
 	if err := api.validate.Struct(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
 	}
-	res, err := api.ps.CreateProduct(req)
+	req.FromUnits(units)
+	res, err := api.ps.CreateProduct(c.Request().Context(), req)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusCreated, map[string]*int{"id": res})
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
 }
 
 func (api *Api) updateProduct(c echo.Context) error {
@@ -212,18 +649,19 @@ func (api *Api) updateProduct(c echo.Context) error {
 	}
 	req := &model.Product{}
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return bindError(err)
 	}
 	if err := api.validate.Struct(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param: "+err.Error())
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
 	}
+	req.FromUnits(units)
 	req.Id = id
-	if err = api.ps.UpdateProduct(req); err != nil {
-		if err != sql.ErrNoRows {
-			return err
-		} else {
-			return echo.NewHTTPError(http.StatusNotFound, "Category `id` = ", id, " not found")
-		}
+	if err = api.ps.UpdateProduct(c.Request().Context(), req); err != nil {
+		return err
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -233,13 +671,660 @@ func (api *Api) deleteProduct(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
 	}
-	if err = api.ps.DeleteProduct(id); err != nil {
-		if err != sql.ErrNoRows {
-			return err
-		} else {
-			return echo.NewHTTPError(http.StatusNotFound, "Product `id` = ", id, " not found")
-		}
+	if err = api.ps.DeleteProduct(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getRelatedProducts(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	relationType := c.QueryParam("type")
+	if err := api.validate.Var(relationType, "omitempty,oneof=accessory-of alternative-to replaced-by up-sell"); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `type`")
+	}
+	filter, err := api.productFilter(c)
+	if err != nil {
+		return err
+	}
+	view, err := api.priceView(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	related, err := api.ps.GetRelatedProducts(c.Request().Context(), id, relationType, filter, view, api.visibleStatuses(c))
+	if err != nil {
+		return err
 	}
+	if related == nil {
+		related = []*model.RelatedProduct{}
+	}
+	for _, relation := range related {
+		api.present(c, units, relation.Product)
+	}
+	return render(c, http.StatusOK, related)
+}
+
+func (api *Api) addRelatedProducts(c echo.Context) error {
+	return api.changeRelatedProducts(c, api.ps.AddProductRelations)
+}
+
+func (api *Api) replaceRelatedProducts(c echo.Context) error {
+	return api.changeRelatedProducts(c, api.ps.ReplaceProductRelations)
+}
+
+func (api *Api) removeRelatedProducts(c echo.Context) error {
+	return api.changeRelatedProducts(c, api.ps.RemoveProductRelations)
+}
 
+// changeRelatedProducts applies a list of relations sent as JSON in body
+func (api *Api) changeRelatedProducts(c echo.Context, change func(ctx context.Context, id int, relations []model.ProductRelation) error) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	var req []model.ProductRelation
+	if err := c.Bind(&req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Var(req, "dive"); err != nil {
+		return err
+	}
+	if err = change(c.Request().Context(), id, req); err != nil {
+		return err
+	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (api *Api) getReviews(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	// Reviews awaiting or failing moderation are for editors only
+	status := c.QueryParam("status")
+	if status == "" {
+		status = model.ReviewStatusApproved
+	}
+	if err := api.validate.Var(status, "oneof=pending approved rejected"); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `status`")
+	}
+	if status != model.ReviewStatusApproved && !api.isEditor(c) {
+		return echo.NewHTTPError(http.StatusForbidden, "Listing unapproved reviews requires editor access")
+	}
+	reviews, err := api.rs.GetReviews(c.Request().Context(), id, status, api.visibleStatuses(c))
+	if err != nil {
+		return err
+	}
+	if reviews == nil {
+		reviews = []*model.Review{}
+	}
+	return render(c, http.StatusOK, reviews)
+}
+
+func (api *Api) createReview(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.Review{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	req.Product = id
+	res, err := api.rs.CreateReview(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
+}
+
+func (api *Api) moderateReview(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.ReviewModeration{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	if err = api.rs.ModerateReview(c.Request().Context(), id, req.Status); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getProductSuppliers(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	suppliers, err := api.ss.GetProductSuppliers(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if suppliers == nil {
+		suppliers = []model.ProductSupplier{}
+	}
+	return render(c, http.StatusOK, suppliers)
+}
+
+func (api *Api) setProductSuppliers(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	var req []model.ProductSupplier
+	if err := c.Bind(&req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Var(req, "unique=Supplier,dive"); err != nil {
+		return err
+	}
+	if err = api.ss.SetProductSuppliers(c.Request().Context(), id, req); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	supplier, err := api.ss.GetSupplier(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, supplier)
+}
+
+func (api *Api) getSuppliers(c echo.Context) error {
+	suppliers, err := api.ss.GetSuppliers(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	if suppliers == nil {
+		suppliers = []*model.Supplier{}
+	}
+	return render(c, http.StatusOK, suppliers)
+}
+
+func (api *Api) createSupplier(c echo.Context) error {
+	req := &model.Supplier{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	res, err := api.ss.CreateSupplier(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
+}
+
+func (api *Api) updateSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.Supplier{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	req.Id = id
+	if err = api.ss.UpdateSupplier(c.Request().Context(), req); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) deleteSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	if err = api.ss.DeleteSupplier(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getMarginReport(c echo.Context) error {
+	filter, err := api.productFilter(c)
+	if err != nil {
+		return err
+	}
+	report, err := api.rps.GetMarginReport(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, report)
+}
+
+func (api *Api) getPromotion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	promotion, err := api.prs.GetPromotion(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, promotion)
+}
+
+func (api *Api) getPromotions(c echo.Context) error {
+	promotions, err := api.prs.GetPromotions(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	if promotions == nil {
+		promotions = []*model.Promotion{}
+	}
+	return render(c, http.StatusOK, promotions)
+}
+
+func (api *Api) createPromotion(c echo.Context) error {
+	req := &model.Promotion{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	res, err := api.prs.CreatePromotion(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
+}
+
+func (api *Api) updatePromotion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.Promotion{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	req.Id = id
+	if err = api.prs.UpdatePromotion(c.Request().Context(), req); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) deletePromotion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	if err = api.prs.DeletePromotion(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getTaxClass(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	taxClass, err := api.tcs.GetTaxClass(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, taxClass)
+}
+
+func (api *Api) getTaxClasses(c echo.Context) error {
+	taxClasses, err := api.tcs.GetTaxClasses(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	if taxClasses == nil {
+		taxClasses = []*model.TaxClass{}
+	}
+	return render(c, http.StatusOK, taxClasses)
+}
+
+func (api *Api) createTaxClass(c echo.Context) error {
+	req := &model.TaxClass{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	res, err := api.tcs.CreateTaxClass(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, map[string]*int{"id": res})
+}
+
+func (api *Api) updateTaxClass(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	req := &model.TaxClass{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	req.Id = id
+	if err = api.tcs.UpdateTaxClass(c.Request().Context(), req); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) deleteTaxClass(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	if err = api.tcs.DeleteTaxClass(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) getApiKeys(c echo.Context) error {
+	keys, err := api.aks.GetApiKeys(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		keys = []*model.ApiKey{}
+	}
+	return render(c, http.StatusOK, keys)
+}
+
+func (api *Api) getApiKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	key, err := api.aks.GetApiKey(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, key)
+}
+
+// createApiKey answers the new key, it can't be read again afterwards
+func (api *Api) createApiKey(c echo.Context) error {
+	req := &model.ApiKey{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	key, err := api.aks.CreateApiKey(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusCreated, key)
+}
+
+func (api *Api) rotateApiKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	key, err := api.aks.RotateApiKey(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, key)
+}
+
+func (api *Api) revokeApiKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `id`")
+	}
+	if err = api.aks.RevokeApiKey(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (api *Api) executeBatch(c echo.Context) error {
+	req := &model.Batch{}
+	if err := c.Bind(req); err != nil {
+		return bindError(err)
+	}
+	if err := api.validate.Struct(req); err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	res, err := api.bas.ExecuteBatch(c.Request().Context(), req, api.checker(units))
+	if err != nil {
+		return err
+	}
+	for i := range res.Results {
+		result := &res.Results[i]
+		result.Status = batchStatus(req.Operations[i].Op, result.Err)
+		if result.Err != nil {
+			result.Error = result.Err.Error()
+			if result.Status == http.StatusInternalServerError {
+				result.Error = http.StatusText(result.Status)
+			}
+		}
+	}
+	return render(c, http.StatusOK, res)
+}
+
+// checker validates the products and categories of batches and imports like
+// their create and update requests, converting product units
+func (api *Api) checker(units string) func(interface{}) error {
+	return func(value interface{}) error {
+		if err := api.validate.Struct(value); err != nil {
+			return err
+		}
+		if product, ok := value.(*model.Product); ok {
+			product.FromUnits(units)
+		}
+		return nil
+	}
+}
+
+// batchStatus is the status an operation of a batch would have had on its own
+func batchStatus(op string, err error) int {
+	if err != nil {
+		return errorStatus(err)
+	}
+	if op == model.BatchOpCreate {
+		return http.StatusCreated
+	}
+	return http.StatusNoContent
+}
+
+func (api *Api) importProducts(c echo.Context) error {
+	dryRun, err := strconv.ParseBool(c.QueryParam("dry_run"))
+	if err != nil && c.QueryParam("dry_run") != "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `dry_run`")
+	}
+	mapping := map[string]string{}
+	for _, m := range c.QueryParams()["map"] {
+		parts := strings.SplitN(m, ":", 2)
+		if len(parts) != 2 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `map`")
+		}
+		mapping[parts[0]] = parts[1]
+	}
+	// The CSV is the body, or the "file" field of a form upload
+	body := c.Request().Body
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `file`")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+	report, err := api.ims.ImportProducts(c.Request().Context(), body, mapping, dryRun, api.checker(model.UnitsMetric))
+	if err != nil {
+		return err
+	}
+	return render(c, http.StatusOK, report)
+}
+
+func (api *Api) exportProducts(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = model.ExportFormatCSV
+	}
+	if err := api.validate.Var(format, "oneof=csv ndjson xlsx"); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad request param `format`")
+	}
+	filter, err := api.productFilter(c)
+	if err != nil {
+		return err
+	}
+	units, err := api.units(c)
+	if err != nil {
+		return err
+	}
+	// The response starts with the first row, errors of the query are still answered
+	// properly before it and abort the connection after it
+	e := &exporter{res: c.Response(), format: format}
+	err = api.ps.ExportProducts(c.Request().Context(), filter, func(product *model.ExportedProduct) error {
+		if !e.started {
+			if err := e.start(); err != nil {
+				return err
+			}
+		}
+		product.ToUnits(units)
+		return e.write(product)
+	})
+	if err != nil {
+		return err
+	}
+	if !e.started {
+		if err = e.start(); err != nil {
+			return err
+		}
+	}
+	return e.finish()
+}
+
+// exporter streams the rows of a product export in one format
+type exporter struct {
+	res     *echo.Response
+	format  string
+	started bool
+	csv     *csv.Writer
+	ndjson  *json.Encoder
+	xlsx    *helpers.XLSXWriter
+}
+
+func (e *exporter) start() error {
+	e.started = true
+	header := e.res.Header()
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="products.%s"`, e.format))
+	switch e.format {
+	case model.ExportFormatCSV:
+		header.Set(echo.HeaderContentType, "text/csv; charset=UTF-8")
+	case model.ExportFormatNDJSON:
+		header.Set(echo.HeaderContentType, "application/x-ndjson")
+	case model.ExportFormatXLSX:
+		header.Set(echo.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	}
+	e.res.WriteHeader(http.StatusOK)
+	var err error
+	switch e.format {
+	case model.ExportFormatCSV:
+		e.csv = csv.NewWriter(e.res)
+		return e.csv.Write(model.ExportColumns)
+	case model.ExportFormatNDJSON:
+		e.ndjson = json.NewEncoder(e.res)
+	case model.ExportFormatXLSX:
+		if e.xlsx, err = helpers.NewXLSXWriter(e.res, "Products"); err != nil {
+			return err
+		}
+		columns := make([]interface{}, len(model.ExportColumns))
+		for i, column := range model.ExportColumns {
+			columns[i] = column
+		}
+		return e.xlsx.Write(columns)
+	}
+	return nil
+}
+
+func (e *exporter) write(product *model.ExportedProduct) error {
+	switch e.format {
+	case model.ExportFormatCSV:
+		values := product.Values()
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = exportText(value)
+		}
+		return e.csv.Write(record)
+	case model.ExportFormatNDJSON:
+		return e.ndjson.Encode(product)
+	}
+	return e.xlsx.Write(product.Values())
+}
+
+func (e *exporter) finish() error {
+	switch e.format {
+	case model.ExportFormatCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case model.ExportFormatXLSX:
+		return e.xlsx.Close()
+	}
+	return nil
+}
+
+// exportText formats the values of CSV exports the way the import reads them
+func exportText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+)
+
+const (
+	headerEditorToken = "X-Editor-Token"
+	headerApiKey      = "X-API-Key"
+	// contextPrincipal holds the client a request was authenticated as
+	contextPrincipal = "principal"
+)
+
+// publicPaths are the routes answered without credentials
+var publicPaths = map[string]bool{"/": true, "/openapi.json": true}
+
+// authenticating reports whether clients have to present credentials
+func (api *Api) authenticating() bool {
+	return api.aks != nil || api.ts != nil
+}
+
+// isEditor reports whether the caller has editor access
+func (api *Api) isEditor(c echo.Context) bool {
+	p := principal(c)
+	return p != nil && p.Has(model.RoleEditor)
+}
+
+// editorToken is a middleware of the APIs without authentication, the
+// configured editor token stands for a client of the editor role. The token
+// is retired once clients authenticate.
+func (api *Api) editorToken(next echo.HandlerFunc) echo.HandlerFunc {
+	token := []byte(api.conf.Api.EditorToken)
+	return func(c echo.Context) error {
+		if subtle.ConstantTimeCompare([]byte(c.Request().Header.Get(headerEditorToken)), token) == 1 {
+			c.Set(contextPrincipal, &model.Principal{Subject: "editor-token", Role: model.RoleEditor})
+		}
+		return next(c)
+	}
+}
+
+// principal returns the client of the request, nil when it isn't authenticated
+func principal(c echo.Context) *model.Principal {
+	p, _ := c.Get(contextPrincipal).(*model.Principal)
+	return p
+}
+
+// credentials reads the API key sent in the `X-API-Key` header, or else the
+// bearer token which is an API key or a JWT
+func credentials(req *http.Request) (key string, bearer string) {
+	if key = req.Header.Get(headerApiKey); key != "" {
+		return key, ""
+	}
+	auth := req.Header.Get(echo.HeaderAuthorization)
+	if scheme := "Bearer "; len(auth) > len(scheme) && strings.EqualFold(auth[:len(scheme)], scheme) {
+		return "", strings.TrimSpace(auth[len(scheme):])
+	}
+	return "", ""
+}
+
+// isJWT tells tokens from API keys by their three dot separated parts
+func isJWT(bearer string) bool {
+	return strings.Count(bearer, ".") == 2
+}
+
+// authenticate is a middleware requiring an active API key or a valid token
+// on every route but the public ones, the routes check the role of the client
+func (api *Api) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if publicPaths[c.Path()] {
+			return next(c)
+		}
+		key, bearer := credentials(c.Request())
+		if bearer != "" && api.ts != nil && (isJWT(bearer) || api.aks == nil) {
+			p, err := api.ts.Verify(bearer)
+			if err != nil {
+				return err
+			}
+			c.Set(contextPrincipal, p)
+			return next(c)
+		}
+		if bearer != "" {
+			key = bearer
+		}
+		if key == "" || api.aks == nil {
+			return &service.AuthenticationError{Reason: "credentials required"}
+		}
+		apiKey, err := api.aks.Authenticate(c.Request().Context(), key)
+		if err != nil {
+			return err
+		}
+		c.Set(contextPrincipal, &model.Principal{Subject: apiKey.Name, Role: apiKey.Role(), ApiKey: apiKey})
+		return next(c)
+	}
+}
+
+// permit returns the route middleware rejecting the clients without `role`,
+// it lets everyone through when clients aren't authenticated
+func (api *Api) permit(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !api.authenticating() {
+			return next
+		}
+		return api.require(role)(next)
+	}
+}
+
+// require returns the route middleware rejecting the clients without `role`
+// whether clients are authenticated or hold the editor token
+func (api *Api) require(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if p := principal(c); p == nil || !p.Has(role) {
+				return echo.NewHTTPError(http.StatusForbidden, "Requires the "+role+" role")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/logging"
+	"github.com/mrlightwood/golang-products-api/service"
+)
+
+const mimeProblem = "application/problem+json"
+
+// problem is an RFC 7807 error response
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Invalid values of the request
+	Errors []service.FieldError `json:"errors,omitempty"`
+	// Field holding a value already in use and the resource using it
+	Field string `json:"field,omitempty"`
+	Id    int    `json:"id,omitempty"`
+}
+
+// errorStatus maps the errors of the services to HTTP statuses,
+// unexpected errors are internal ones
+func errorStatus(err error) int {
+	var httpError *echo.HTTPError
+	var validationErrors validator.ValidationErrors
+	var validationError *service.ValidationError
+	var invalidOperation *service.InvalidOperationError
+	var notFound *service.NotFoundError
+	var conflict *service.ConflictError
+	var preconditionFailed *service.PreconditionFailedError
+	var authentication *service.AuthenticationError
+	switch {
+	case errors.As(err, &httpError):
+		return httpError.Code
+	case errors.As(err, &validationErrors), errors.As(err, &validationError), errors.As(err, &invalidOperation):
+		return http.StatusBadRequest
+	case errors.As(err, &authentication):
+		return http.StatusUnauthorized
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &conflict):
+		return http.StatusConflict
+	case errors.As(err, &preconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrNotExecuted):
+		return http.StatusFailedDependency
+	case errors.Is(err, service.ErrRequestInProgress):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+const abortedKey = "aborted"
+
+// abort is the first middleware, it aborts the connection of the responses
+// failing once started, after the other middlewares have measured and logged them
+func abort(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if aborted, _ := c.Get(abortedKey).(bool); aborted {
+			panic(http.ErrAbortHandler)
+		}
+		return err
+	}
+}
+
+// handleError answers every failed request with a problem, the details of
+// unexpected errors are logged and kept from clients
+func (api *Api) handleError(err error, c echo.Context) {
+	if c.Response().Committed {
+		// Part of the body is sent already, the connection is aborted for the
+		// client to tell the response is incomplete
+		logging.Entry(c.Request().Context()).WithError(err).Error("Response aborted")
+		c.Set(abortedKey, true)
+		return
+	}
+	p := &problem{Type: "about:blank", Status: errorStatus(err), Instance: c.Request().URL.Path}
+	p.Title = http.StatusText(p.Status)
+	var httpError *echo.HTTPError
+	var validationErrors validator.ValidationErrors
+	var validationError *service.ValidationError
+	var conflict *service.ConflictError
+	switch {
+	case errors.As(err, &httpError):
+		p.Detail = fmt.Sprint(httpError.Message)
+		if httpError.Internal != nil {
+			logging.Entry(c.Request().Context()).WithError(httpError.Internal).Debug("Request failed")
+		}
+	case errors.As(err, &validationErrors):
+		validationError = &service.ValidationError{Fields: fieldErrors(validationErrors)}
+		p.Detail, p.Errors = validationError.Error(), validationError.Fields
+	case errors.As(err, &validationError):
+		p.Detail, p.Errors = validationError.Error(), validationError.Fields
+	case errors.As(err, &conflict):
+		p.Detail, p.Field, p.Id = conflict.Error(), conflict.Field, conflict.Id
+	case p.Status == http.StatusInternalServerError:
+		logging.Entry(c.Request().Context()).WithError(err).Error("Request failed")
+	default:
+		p.Detail = err.Error()
+	}
+	if p.Title == "" {
+		p.Title = p.Detail
+	}
+	if p.Status == http.StatusUnauthorized {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(p.Status)
+	} else {
+		var body []byte
+		if body, err = json.Marshal(p); err == nil {
+			err = c.Blob(p.Status, mimeProblem, body)
+		}
+	}
+	if err != nil {
+		logging.Entry(c.Request().Context()).WithError(err).Error("Error response not sent")
+	}
+}
+
+// fieldErrors names the invalid fields of a request like the request does
+func fieldErrors(errs validator.ValidationErrors) []service.FieldError {
+	fields := make([]service.FieldError, len(errs))
+	for i, e := range errs {
+		// The namespace starts with the struct, or with the index of a list
+		field := e.Namespace()
+		if i := strings.Index(field, "."); i >= 0 && !strings.HasPrefix(field, "[") {
+			field = field[i+1:]
+		}
+		fields[i] = service.FieldError{Field: field, Rule: e.Tag(), Message: ruleMessage(e)}
+	}
+	return fields
+}
+
+// ruleMessage tells what a rule requires from a value
+func ruleMessage(e validator.FieldError) string {
+	param := e.Param()
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "required_if", "required_unless":
+		return "is required along with the other values"
+	case "required_without_all":
+		return "is required when none of " + strings.Join(strings.Fields(param), ", ") + " is given"
+	case "excluded_unless":
+		return "is only allowed for " + param
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "min", "gte":
+		if unit := sizeUnit(e.Kind()); unit != "" {
+			return "must have at least " + param + " " + unit
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if unit := sizeUnit(e.Kind()); unit != "" {
+			return "must have at most " + param + " " + unit
+		}
+		return "must be at most " + param
+	case "len":
+		return "must have " + param + " " + sizeUnit(e.Kind())
+	case "gt":
+		return "must be greater than " + param
+	case "gtfield":
+		return "must be after " + param
+	case "unique":
+		return "must not repeat " + param
+	case "email":
+		return "must be an email address"
+	case "slug":
+		return "must be lowercase words separated by hyphens"
+	case "gtin":
+		return "must be a GTIN-8, 12, 13 or 14 with a valid check digit"
+	case "alpha", "uppercase":
+		return "must be " + e.Tag()
+	}
+	return "breaks the " + e.Tag() + " rule"
+}
+
+// sizeUnit is what the length of a value counts, "" for numbers
+func sizeUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return ""
+}
+
+// bindError reports a request body that can't be read into the request model,
+// the statuses of the binder other than 400 are kept, like 415 for unsupported
+// media types
+func bindError(err error) error {
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		return echo.NewHTTPError(httpError.Code, fmt.Sprint(httpError.Message))
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+}
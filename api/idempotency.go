@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/logging"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplayed marks the responses of former requests
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	// maxIdempotencyKey is the longest idempotency key accepted
+	maxIdempotencyKey = 255
+)
+
+// recorder copies the body of a response as it is written
+type recorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// fingerprint identifies a request by its method, URL, media type and body,
+// the params of the content type like the multipart boundary are left out
+func fingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{req.Method, req.URL.RequestURI(), mediaType(req.Header.Get(echo.HeaderContentType))} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotent is a route middleware replaying the response of the first
+// request sent with an `Idempotency-Key` to its retries. Failures of the
+// server aren't kept, the retries run again.
+func (api *Api) idempotent(next echo.HandlerFunc) echo.HandlerFunc {
+	if api.is == nil {
+		return next
+	}
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(headerIdempotencyKey)
+		if key == "" {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKey {
+			return echo.NewHTTPError(http.StatusBadRequest, "Idempotency key longer than "+strconv.Itoa(maxIdempotencyKey)+" characters")
+		}
+		req := c.Request()
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return bindError(err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		// The keys of the clients are kept apart, by IP when they aren't authenticated
+		key = client(c) + " " + key
+		stored, err := api.is.Begin(c.Request().Context(), key, fingerprint(req, body))
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			c.Response().Header().Set(headerIdempotentReplayed, "true")
+			if len(stored.Body) == 0 {
+				return c.NoContent(stored.Status)
+			}
+			return c.Blob(stored.Status, stored.ContentType, stored.Body)
+		}
+		rec := &recorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = rec
+		if err = next(c); err != nil {
+			c.Error(err)
+		}
+		res := c.Response()
+		if res.Status >= http.StatusInternalServerError {
+			err = api.is.Release(c.Request().Context(), key)
+		} else {
+			err = api.is.Complete(c.Request().Context(), key, &model.IdempotentResponse{Status: res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType), Body: rec.body.Bytes()})
+		}
+		if err != nil {
+			logging.Entry(c.Request().Context()).WithError(err).Error("Idempotency key not updated")
+		}
+		return nil
+	}
+}
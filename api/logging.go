@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/logging"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRequestId is the longest request id accepted from clients
+const maxRequestId = 128
+
+// validRequestId accepts the ids of visible ASCII characters, others could
+// break the log lines they are written in
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestId {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// identify is a middleware naming each request by the `X-Request-ID` sent by
+// the client, or a generated id. The id is returned in the response and comes
+// with every line logged for the request, along with the id of its trace. The
+// services and the store log through the entry of the request context.
+func (api *Api) identify(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !validRequestId(id) {
+			id = newRequestId()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		entry := log.WithField("request_id", id)
+		if span := trace.SpanContextFromContext(req.Context()); span.IsValid() {
+			entry = entry.WithField("trace_id", span.TraceID().String())
+		}
+		c.SetRequest(req.WithContext(logging.WithEntry(req.Context(), entry)))
+		return next(c)
+	}
+}
+
+// accessLog is a middleware writing a line per request once it's answered
+func (api *Api) accessLog(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		req, res := c.Request(), c.Response()
+		entry := logging.Entry(req.Context()).WithFields(log.Fields{
+			"method":     req.Method,
+			"uri":        req.RequestURI,
+			"route":      c.Path(),
+			"status":     res.Status,
+			"duration":   time.Since(start).String(),
+			"bytes_in":   req.ContentLength,
+			"bytes_out":  res.Size,
+			"remote_ip":  c.RealIP(),
+			"user_agent": req.UserAgent(),
+		})
+		if p := principal(c); p != nil {
+			entry = entry.WithField("principal", p.Id())
+		}
+		entry.Info("Request handled")
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+// httpMetrics count and time the requests by route template, so that the
+// ids in the URLs don't make a series each
+type httpMetrics struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	inFlight  prometheus.Gauge
+	// Route template of a request
+	route func(c echo.Context) string
+}
+
+func newHttpMetrics(reg prometheus.Registerer, route func(c echo.Context) string) *httpMetrics {
+	labels := []string{"method", "route", "status"}
+	hm := &httpMetrics{
+		route: route,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Requests answered.",
+		}, labels),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of the requests until answered.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Requests being answered.",
+		}),
+	}
+	reg.MustRegister(hm.requests, hm.durations, hm.inFlight)
+	return hm
+}
+
+// measure is a middleware counting and timing the requests, errors included
+func (hm *httpMetrics) measure(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		hm.inFlight.Inc()
+		defer hm.inFlight.Dec()
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		labels := prometheus.Labels{"method": c.Request().Method, "route": hm.route(c), "status": strconv.Itoa(c.Response().Status)}
+		hm.requests.With(labels).Inc()
+		hm.durations.With(labels).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
+
+// metricsHandler serves the metrics of `gatherer` in the Prometheus text format,
+// the metrics failing to be collected are left out
+func metricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		ErrorLog:      log.StandardLogger(),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+func (api *Api) getMetrics(c echo.Context) error {
+	api.metrics.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
@@ -0,0 +1,563 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Media types of requests and responses, JSON comes first when anything goes
+const (
+	mimeJSON    = echo.MIMEApplicationJSON
+	mimeXML     = echo.MIMEApplicationXML
+	mimeCSV     = "text/csv"
+	mimeMsgpack = echo.MIMEApplicationMsgpack
+)
+
+var mediaAliases = map[string][]string{
+	"*/*":                     {mimeJSON, mimeXML, mimeMsgpack, mimeCSV},
+	"application/*":           {mimeJSON, mimeXML, mimeMsgpack},
+	"text/*":                  {mimeCSV, mimeXML},
+	mimeJSON:                  {mimeJSON},
+	mimeXML:                   {mimeXML},
+	echo.MIMETextXML:          {mimeXML},
+	mimeCSV:                   {mimeCSV},
+	mimeMsgpack:               {mimeMsgpack},
+	"application/x-msgpack":   {mimeMsgpack},
+	"application/vnd.msgpack": {mimeMsgpack},
+}
+
+// acceptedKey holds the response media types of the request, preferred first
+const acceptedKey = "accepted"
+
+// accepted lists the supported media types matching an Accept header in
+// order of preference, every type is accepted without header
+func accepted(header string) []string {
+	if strings.TrimSpace(header) == "" {
+		return []string{mimeJSON}
+	}
+	type mediaRange struct {
+		media string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{media: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, param := range params[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				if q, err := strconv.ParseFloat(value[2:], 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	var media []string
+	seen := map[string]bool{}
+	for _, r := range ranges {
+		for _, m := range mediaAliases[r.media] {
+			if !seen[m] {
+				seen[m] = true
+				media = append(media, m)
+			}
+		}
+	}
+	return media
+}
+
+// unnegotiated are the routes answering in their own media type
+var unnegotiated = map[string]bool{"/": true, "/openapi.json": true, "/metrics": true, "/api/export/products": true}
+
+// negotiate answers 406 up front when none of the accepted media types is
+// supported, so that no write happens for a response nobody can read.
+// Writes never answer with a collection, CSV isn't offered for them.
+func (api *Api) negotiate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if unnegotiated[c.Path()] {
+			return next(c)
+		}
+		supported := []string{mimeJSON, mimeXML, mimeCSV, mimeMsgpack}
+		media := accepted(c.Request().Header.Get(echo.HeaderAccept))
+		if method := c.Request().Method; method != http.MethodGet && method != http.MethodHead {
+			supported = without(supported, mimeCSV)
+			media = without(media, mimeCSV)
+		}
+		if len(media) == 0 {
+			return echo.NewHTTPError(http.StatusNotAcceptable, "Supported media types: "+strings.Join(supported, ", "))
+		}
+		c.Set(acceptedKey, media)
+		return next(c)
+	}
+}
+
+// without returns the media types except `excluded`
+func without(media []string, excluded string) []string {
+	var kept []string
+	for _, m := range media {
+		if m != excluded {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// render answers with the first accepted media type able to represent the
+// value, CSV being limited to collections
+func render(c echo.Context, code int, value interface{}) error {
+	media, _ := c.Get(acceptedKey).([]string)
+	if media == nil {
+		media = []string{mimeJSON}
+	}
+	for _, m := range media {
+		switch m {
+		case mimeJSON:
+			return c.JSON(code, value)
+		case mimeXML:
+			var buf bytes.Buffer
+			if err := writeXML(&buf, value); err != nil {
+				return err
+			}
+			return c.Blob(code, echo.MIMEApplicationXMLCharsetUTF8, buf.Bytes())
+		case mimeMsgpack:
+			var buf bytes.Buffer
+			encoder := msgpack.NewEncoder(&buf)
+			encoder.SetCustomStructTag("json")
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+			return c.Blob(code, mimeMsgpack, buf.Bytes())
+		case mimeCSV:
+			if !isCollection(value) {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := writeCSV(&buf, value); err != nil {
+				return err
+			}
+			return c.Blob(code, mimeCSV+"; charset=UTF-8", buf.Bytes())
+		}
+	}
+	return echo.NewHTTPError(http.StatusNotAcceptable, "Only collections are available as "+mimeCSV)
+}
+
+// writeXML writes the JSON form of the value as XML: a "response" root, an
+// element per struct field, "entry" elements for map entries and "item"
+// elements for array entries
+func writeXML(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	encoder := xml.NewEncoder(w)
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if err = jsonToXML(encoder, decoder, xml.StartElement{Name: xml.Name{Local: "response"}}, reflect.TypeOf(value)); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// jsonToXML writes the next JSON value, of Go type t, as the element `start`.
+// Map keys needn't be XML names, they go in the "key" attribute of "entry"
+// elements. Objects of unknown type, raw JSON, do the same for the keys that
+// aren't XML names.
+func jsonToXML(encoder *xml.Encoder, decoder *json.Decoder, start xml.StartElement, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if err = encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := token.(string)
+			child := xml.StartElement{Name: xml.Name{Local: key}}
+			var childType reflect.Type
+			switch {
+			case t != nil && t.Kind() == reflect.Struct:
+				childType = jsonFieldType(t, key)
+			case t != nil && t.Kind() == reflect.Map:
+				child, childType = xmlEntry(key), t.Elem()
+			case !xmlName(key):
+				child = xmlEntry(key)
+			}
+			if err = jsonToXML(encoder, decoder, child, childType); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for decoder.More() {
+			if err = jsonToXML(encoder, decoder, xml.StartElement{Name: xml.Name{Local: "item"}}, elem); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	case nil:
+	default:
+		err = encoder.EncodeToken(xml.CharData(fmt.Sprint(token)))
+	}
+	if err != nil {
+		return err
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+func xmlEntry(key string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}}}
+}
+
+// xmlName reports whether the key can name an element as is
+func xmlName(key string) bool {
+	for i, r := range key {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r) && r != '-' && r != '.') {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// jsonFieldType is the type of the struct field of JSON name `name`, fields
+// of embedded structs included
+func jsonFieldType(t reflect.Type, name string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if fieldType := jsonFieldType(embedded, name); fieldType != nil {
+					return fieldType
+				}
+				continue
+			}
+		}
+		if fieldName, ok := jsonName(field); ok && fieldName == name {
+			return field.Type
+		}
+	}
+	return nil
+}
+
+// isCollection reports whether the value is a slice CSV can hold
+func isCollection(value interface{}) bool {
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.Slice
+}
+
+// writeCSV writes a row per entry with a column per JSON field, nested
+// values as JSON. Entries that aren't objects go in a "value" column.
+func writeCSV(w io.Writer, value interface{}) error {
+	elem := reflect.TypeOf(value).Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	columns := []string{"value"}
+	if elem.Kind() == reflect.Struct {
+		columns = jsonFields(elem)
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	entries := reflect.ValueOf(value)
+	for i := 0; i < entries.Len(); i++ {
+		data, err := json.Marshal(entries.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		fields := map[string]json.RawMessage{"value": data}
+		if elem.Kind() == reflect.Struct {
+			if err = json.Unmarshal(data, &fields); err != nil {
+				return err
+			}
+		}
+		record := make([]string, len(columns))
+		for j, column := range columns {
+			record[j] = csvCell(fields[column])
+		}
+		if err = writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvCell(raw json.RawMessage) string {
+	var s string
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return ""
+	case json.Unmarshal(raw, &s) == nil:
+		return s
+	}
+	return string(raw)
+}
+
+// jsonFields names the JSON fields of a struct type in order
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := jsonName(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return tag, true
+}
+
+// mediaType is the media type of a Content-Type header, without parameters
+// like the charset or the multipart boundary
+func mediaType(header string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(header, ";")[0]))
+}
+
+// binder reads request bodies in the media types responses are available
+// in, XML and CSV with the JSON field names
+type binder struct {
+	echo.DefaultBinder
+}
+
+func (b *binder) Bind(i interface{}, c echo.Context) error {
+	req := c.Request()
+	switch mediaType(req.Header.Get(echo.HeaderContentType)) {
+	case mimeMsgpack, "application/x-msgpack", "application/vnd.msgpack":
+		decoder := msgpack.NewDecoder(req.Body)
+		decoder.SetCustomStructTag("json")
+		return decoder.Decode(i)
+	case mimeXML, echo.MIMETextXML:
+		root, err := readXML(req.Body)
+		if err != nil {
+			return err
+		}
+		return assign(reflect.ValueOf(i), root)
+	case mimeCSV:
+		root, err := readCSV(req.Body)
+		if err != nil {
+			return err
+		}
+		return assign(reflect.ValueOf(i), root)
+	}
+	return b.DefaultBinder.Bind(i, c)
+}
+
+// node is an XML element or, for CSV, the table, a row or a cell: text
+// or child nodes named after fields
+type node struct {
+	name string
+	// Key of a map entry, the name unless a "key" attribute tells otherwise
+	key      string
+	text     string
+	children []*node
+}
+
+func readXML(r io.Reader) (*node, error) {
+	decoder := xml.NewDecoder(r)
+	var stack []*node
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("XML body without root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			n := &node{name: token.Name.Local, key: token.Name.Local}
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "key" {
+					n.key = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(token)
+			}
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			if stack = stack[:len(stack)-1]; len(stack) == 0 {
+				return n, nil
+			}
+		}
+	}
+}
+
+// readCSV turns the rows into nodes and the header into the names of their cells
+func readCSV(r io.Reader) (*node, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	root := &node{}
+	if len(records) == 0 {
+		return root, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		row := &node{}
+		for i, cell := range record {
+			row.children = append(row.children, &node{name: header[i], key: header[i], text: cell})
+		}
+		root.children = append(root.children, row)
+	}
+	return root, nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// assign sets the value pointed to by v from the node, fields by JSON name.
+// Text standing for a nested value holds its JSON, as CSV cells do.
+func assign(v reflect.Value, n *node) error {
+	if v.Kind() == reflect.Ptr {
+		if len(n.children) == 0 && strings.TrimSpace(n.text) == "" && v.Elem().Kind() != reflect.Struct &&
+			v.Elem().Kind() != reflect.Slice {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assign(v.Elem(), n)
+	}
+	text := strings.TrimSpace(n.text)
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		if text == "" {
+			return nil
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+	if len(n.children) == 0 && (strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{")) && v.Kind() != reflect.String {
+		return json.Unmarshal([]byte(text), v.Addr().Interface())
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := map[string]int{}
+		for i := 0; i < v.NumField(); i++ {
+			if name, ok := jsonName(v.Type().Field(i)); ok {
+				fields[name] = i
+			}
+		}
+		for _, child := range n.children {
+			if i, ok := fields[child.name]; ok {
+				if err := assign(v.Field(i).Addr(), child); err != nil {
+					return fmt.Errorf("%s: %v", child.name, err)
+				}
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(n.text))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), 0, len(n.children))
+		for _, child := range n.children {
+			elem := reflect.New(v.Type().Elem())
+			if err := assign(elem, child); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem.Elem())
+		}
+		v.Set(slice)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key %s", v.Type().Key())
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, child := range n.children {
+			elem := reflect.New(v.Type().Elem())
+			if err := assign(elem, child); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(child.key).Convert(v.Type().Key()), elem.Elem())
+		}
+		v.Set(m)
+	case reflect.String:
+		v.SetString(n.text)
+	case reflect.Bool:
+		if text == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if text == "" {
+			return nil
+		}
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		if text == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(n.text))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
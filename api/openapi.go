@@ -0,0 +1,579 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// obj is a JSON object of the OpenAPI document
+type obj = map[string]interface{}
+
+// operation documents a route, the path params are read from the route itself
+type operation struct {
+	tag         string
+	summary     string
+	description string
+	params      []param
+	// Model of the request body, nil without body
+	body interface{}
+	// Status and model of the success response, nil without content
+	status   int
+	response interface{}
+	// Media types replacing the negotiated ones, for routes reading or
+	// writing their own formats
+	bodyContent     obj
+	responseContent obj
+	// Error statuses besides the unexpected ones
+	errors []int
+	// Requires the editor token
+	editor bool
+	// Requires the admin role
+	admin bool
+	// Replays its response to the retries sent with the same idempotency key
+	idempotent bool
+}
+
+// param is a query param of an operation
+type param struct {
+	name        string
+	description string
+	schema      obj
+}
+
+// oneOf is a response taking one of several models
+type oneOf []interface{}
+
+// created is the response of the routes creating a resource
+type created struct {
+	Id int `json:"id"`
+}
+
+func enum(values ...string) obj {
+	return obj{"type": "string", "enum": values}
+}
+
+var (
+	unitsParam = param{"units", "Units of weights and dimensions in the request and response, kilograms and meters or pounds and inches",
+		enum(model.UnitsMetric, model.UnitsImperial)}
+	regionParam = param{"region", "ISO 3166-1 alpha-2 region of the tax breakdown of prices, " +
+		"answers 412 when the tax class of a product has no rate in the region", obj{"type": "string"}}
+	pricesParam = param{"prices", "Whether the prices of the tax breakdown are net or include taxes, gross requires a region",
+		enum(model.PricesNet, model.PricesGross)}
+	// listingParams are the filters of product listings
+	listingParams = []param{
+		{"category", "Products of a category", obj{"type": "integer"}},
+		{"brand", "Products of a brand", obj{"type": "integer"}},
+		{"weight_max", "Maximum weight, products without weight are left out", obj{"type": "number"}},
+		{"length_max", "Maximum length, products without length are left out", obj{"type": "number"}},
+		{"width_max", "Maximum width, products without width are left out", obj{"type": "number"}},
+		{"height_max", "Maximum height, products without height are left out", obj{"type": "number"}},
+		{"sort", "Sort key, descending when prefixed with \"-\"", enum(sortKeys()...)},
+		{"include", "Lists drafts as well, for editors only", enum("drafts")},
+		unitsParam,
+	}
+)
+
+func sortKeys() []string {
+	var keys []string
+	for _, key := range model.ProductSortKeys {
+		keys = append(keys, key, "-"+key)
+	}
+	return keys
+}
+
+const productDescription = "Bundles are products of type bundle made of components, priced at a fixed price or " +
+	"at the sum of their components minus a discount. Slugs are generated from the name when empty, the tax class " +
+	"is inherited from the category when empty. A taken slug, SKU or barcode answers 409 with the product holding it."
+
+// rateLimitHeaders tell clients the state of their budget
+var rateLimitHeaders = obj{
+	headerRateLimitLimit:     obj{"description": "Requests of a full budget", "schema": obj{"type": "integer"}},
+	headerRateLimitRemaining: obj{"description": "Requests left in the budget", "schema": obj{"type": "integer"}},
+	headerRateLimitReset:     obj{"description": "Seconds until the budget is full again", "schema": obj{"type": "integer"}},
+}
+
+// operations documents the routes by method and path
+var operations = map[string]operation{
+	"GET /": {tag: "Documentation", summary: "API explorer",
+		responseContent: obj{echo.MIMETextHTMLCharsetUTF8: obj{"schema": obj{"type": "string"}}}},
+	"GET /openapi.json": {tag: "Documentation", summary: "OpenAPI document of the API",
+		responseContent: obj{mimeJSON: obj{"schema": obj{"type": "object"}}}},
+	"GET /metrics": {tag: "Monitoring", summary: "Metrics of the requests, the store and the catalog",
+		description: "In the Prometheus text format.", responseContent: obj{echo.MIMETextPlain: obj{"schema": obj{"type": "string"}}}},
+
+	"GET /api/categories": {tag: "Categories", summary: "List categories", response: []model.Category{}},
+	"GET /api/categories/:id": {tag: "Categories", summary: "Get a category", response: model.Category{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"GET /api/categories/by-slug/:slug": {tag: "Categories", summary: "Get a category by slug",
+		description: "Former slugs answer with a 301 to the current one.", response: model.Category{},
+		errors: []int{http.StatusNotFound}},
+	"POST /api/categories": {tag: "Categories", summary: "Create a category",
+		description: "Names are unique regardless of case, the slug is generated from the name when empty.",
+		body:        model.Category{}, status: http.StatusCreated, response: created{},
+		errors: []int{http.StatusBadRequest, http.StatusConflict}, idempotent: true},
+	"PUT /api/categories/:id": {tag: "Categories", summary: "Update a category", body: model.Category{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+	"DELETE /api/categories/:id": {tag: "Categories", summary: "Delete a category",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	"GET /api/brands": {tag: "Brands", summary: "List brands", response: []model.Brand{}},
+	"GET /api/brands/:id": {tag: "Brands", summary: "Get a brand", response: model.Brand{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"POST /api/brands": {tag: "Brands", summary: "Create a brand", description: "Names are unique regardless of case.", body: model.Brand{},
+		status: http.StatusCreated, response: created{}, errors: []int{http.StatusBadRequest, http.StatusConflict}, idempotent: true},
+	"PUT /api/brands/:id": {tag: "Brands", summary: "Update a brand", body: model.Brand{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+	"DELETE /api/brands/:id": {tag: "Brands", summary: "Delete a brand", description: "Its products become unbranded.",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	"GET /api/products": {tag: "Products", summary: "List published products",
+		description: "Answers the bare list, or the list along with the brand facets when asked for.",
+		params: append([]param{regionParam, pricesParam,
+			{"facets", "Counts the listed products per brand", enum("brands")}}, listingParams...),
+		response: oneOf{[]model.Product{}, model.ProductListing{}},
+		errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusPreconditionFailed}},
+	"GET /api/products/:id": {tag: "Products", summary: "Get a product", params: []param{regionParam, pricesParam, unitsParam},
+		response: model.Product{}, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed}},
+	"GET /api/products/by-slug/:slug": {tag: "Products", summary: "Get a product by slug",
+		description: "Former slugs answer with a 301 to the current one.", params: []param{regionParam, pricesParam, unitsParam},
+		response: model.Product{}, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed}},
+	"GET /api/products/by-barcode/:code": {tag: "Products", summary: "Get a product by barcode",
+		description: "Takes GTIN-8, 12, 13 or 14 codes, the UPC-A and EAN-13 forms of a code match the same product.",
+		params:      []param{regionParam, pricesParam, unitsParam},
+		response:    model.Product{}, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed}},
+	"POST /api/products": {tag: "Products", summary: "Create a product", description: productDescription,
+		params: []param{unitsParam}, body: model.Product{}, status: http.StatusCreated, response: created{},
+		errors: []int{http.StatusBadRequest, http.StatusConflict}, idempotent: true},
+	"PUT /api/products/:id": {tag: "Products", summary: "Update a product", description: productDescription,
+		params: []param{unitsParam}, body: model.Product{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+	"DELETE /api/products/:id": {tag: "Products", summary: "Delete a product",
+		description: "Products still used by a bundle answer 412.",
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed}},
+	"GET /api/products/:id/related": {tag: "Products", summary: "List related products",
+		params: []param{{"type", "Relations of a type", enum(model.RelationAccessoryOf, model.RelationAlternativeTo,
+			model.RelationReplacedBy, model.RelationUpSell)}, unitsParam},
+		response: []model.RelatedProduct{}, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"POST /api/products/:id/related": {tag: "Products", summary: "Link products", body: []model.ProductRelation{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"PUT /api/products/:id/related": {tag: "Products", summary: "Replace the links of a product", body: []model.ProductRelation{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"DELETE /api/products/:id/related": {tag: "Products", summary: "Unlink products", body: []model.ProductRelation{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"GET /api/products/:id/reviews": {tag: "Reviews", summary: "List the approved reviews of a product",
+		params:   []param{{"status", "Reviews of another status, for editors only", enum(model.ReviewStatusPending, model.ReviewStatusRejected)}},
+		response: []model.Review{}, errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+	"POST /api/products/:id/reviews": {tag: "Reviews", summary: "Submit a review for moderation", body: model.Review{},
+		status: http.StatusCreated, response: created{}, errors: []int{http.StatusBadRequest, http.StatusNotFound}, idempotent: true},
+	"PUT /api/reviews/:id/moderation": {tag: "Reviews", summary: "Approve or reject a review", body: model.ReviewModeration{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+
+	"GET /api/products/:id/suppliers": {tag: "Suppliers", summary: "List the suppliers of a product", response: []model.ProductSupplier{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+	"PUT /api/products/:id/suppliers": {tag: "Suppliers", summary: "Replace the suppliers of a product",
+		description: "One supplier at most is preferred, it gives the cost price of the product.", body: []model.ProductSupplier{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+	"GET /api/suppliers": {tag: "Suppliers", summary: "List suppliers", response: []model.Supplier{}, editor: true},
+	"GET /api/suppliers/:id": {tag: "Suppliers", summary: "Get a supplier", response: model.Supplier{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+	"POST /api/suppliers": {tag: "Suppliers", summary: "Create a supplier", body: model.Supplier{},
+		status: http.StatusCreated, response: created{}, errors: []int{http.StatusBadRequest}, editor: true, idempotent: true},
+	"PUT /api/suppliers/:id": {tag: "Suppliers", summary: "Update a supplier", body: model.Supplier{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+	"DELETE /api/suppliers/:id": {tag: "Suppliers", summary: "Delete a supplier along with its product links",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, editor: true},
+	"GET /api/reports/margins": {tag: "Suppliers", summary: "Margins per product and per category",
+		description: "From list prices, derived from the components for bundles, and cost prices. Products without supplier are left out, " +
+			"drafts unless included.",
+		params: listingParams, response: model.MarginReport{}, errors: []int{http.StatusBadRequest}, editor: true},
+
+	"GET /api/promotions": {tag: "Promotions", summary: "List promotions", response: []model.Promotion{}},
+	"GET /api/promotions/:id": {tag: "Promotions", summary: "Get a promotion", response: model.Promotion{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"POST /api/promotions": {tag: "Promotions", summary: "Create a promotion",
+		description: "Targets products, categories or tags, at least one of them.", body: model.Promotion{},
+		status: http.StatusCreated, response: created{}, errors: []int{http.StatusBadRequest}, idempotent: true},
+	"PUT /api/promotions/:id": {tag: "Promotions", summary: "Update a promotion", body: model.Promotion{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"DELETE /api/promotions/:id": {tag: "Promotions", summary: "Delete a promotion",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	"GET /api/tax-classes": {tag: "Tax classes", summary: "List tax classes", response: []model.TaxClass{}},
+	"GET /api/tax-classes/:id": {tag: "Tax classes", summary: "Get a tax class", response: model.TaxClass{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	"POST /api/tax-classes": {tag: "Tax classes", summary: "Create a tax class",
+		description: "Names are unique regardless of case, rates are percentages keyed by ISO 3166-1 alpha-2 region.", body: model.TaxClass{},
+		status: http.StatusCreated, response: created{}, errors: []int{http.StatusBadRequest, http.StatusConflict}, idempotent: true},
+	"PUT /api/tax-classes/:id": {tag: "Tax classes", summary: "Update a tax class", body: model.TaxClass{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+	"DELETE /api/tax-classes/:id": {tag: "Tax classes", summary: "Delete a tax class",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	"GET /api/keys": {tag: "API keys", summary: "List API keys", response: []model.ApiKey{}, admin: true},
+	"GET /api/keys/:id": {tag: "API keys", summary: "Get an API key", response: model.ApiKey{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, admin: true},
+	"POST /api/keys": {tag: "API keys", summary: "Create an API key",
+		description: "The key is only part of this response, only its hash is stored.", body: model.ApiKey{},
+		status: http.StatusCreated, response: model.IssuedApiKey{}, errors: []int{http.StatusBadRequest}, admin: true},
+	"POST /api/keys/:id/rotate": {tag: "API keys", summary: "Replace the key of an API key",
+		description: "The former key stops working at once, the new one is only part of this response.",
+		response:    model.IssuedApiKey{}, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed},
+		admin: true},
+	"DELETE /api/keys/:id": {tag: "API keys", summary: "Revoke an API key",
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}, admin: true},
+
+	"POST /api/batch": {tag: "Bulk", summary: "Run operations in one transaction",
+		description: "Data is the body of the matching create or update request. A create with a ref lets later operations " +
+			"use its id as {\"$ref\": name}. Each result has the status the operation would have had on its own. Atomic " +
+			"batches write nothing when an operation fails, the following ones are reported as 424.",
+		params: []param{unitsParam}, body: model.Batch{}, response: model.BatchResponse{},
+		errors: []int{http.StatusBadRequest}, idempotent: true},
+	"POST /api/import/products": {tag: "Bulk", summary: "Create or update products from CSV",
+		description: "The header names the columns of the export. Rows with an id update that product, else rows with a " +
+			"known SKU update its product, the others create one. Failing rows are reported and skipped.",
+		params: []param{{"dry_run", "Reports without writing anything", obj{"type": "boolean"}},
+			{"map", "Renames a column to a product field, as \"Column:field\"", obj{"type": "string"}}},
+		bodyContent: obj{
+			mimeCSV:                obj{"schema": obj{"type": "string"}},
+			echo.MIMEMultipartForm: obj{"schema": obj{"type": "object", "properties": obj{"file": obj{"type": "string", "format": "binary"}}}},
+		},
+		response: model.ImportReport{}, errors: []int{http.StatusBadRequest}, idempotent: true},
+	"GET /api/export/products": {tag: "Bulk", summary: "Download the catalog",
+		description: "Streams the stored fields of the products and their category name, row by row. " +
+			"Bundles priced from their components export the price listings show before promotions. " +
+			"The connection is closed before the end of the download when the catalog fails to be read meanwhile.",
+		params: append([]param{{"format", "Format of the download, CSV by default", enum(model.ExportFormatCSV, model.ExportFormatNDJSON, model.ExportFormatXLSX)}}, listingParams...),
+		responseContent: obj{
+			mimeCSV:                obj{"schema": obj{"type": "string"}},
+			"application/x-ndjson": obj{"schema": obj{"type": "string"}},
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": obj{"schema": obj{"type": "string", "format": "binary"}},
+		},
+		errors: []int{http.StatusBadRequest, http.StatusForbidden}},
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas collects the schemas of the structs referenced by the document
+type schemas obj
+
+// of returns the schema of a Go type, structs are referenced by name
+func (s schemas) of(t reflect.Type) obj {
+	switch t {
+	case timeType:
+		return obj{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return obj{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.of(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			return obj{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := s[name]; !ok {
+			// Reserved first, for the structs referencing themselves
+			s[name] = obj{}
+			s[name] = s.object(t)
+		}
+		return obj{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return obj{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return obj{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Bool:
+		return obj{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return obj{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return obj{"type": "number"}
+	case reflect.Interface:
+		return obj{}
+	}
+	return obj{"type": "string"}
+}
+
+// object returns the schema of the fields of a struct, named after their json tag
+func (s schemas) object(t reflect.Type) obj {
+	properties := obj{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := s.of(field.Type)
+		if constrain(schema, field.Type, strings.Split(field.Tag.Get("validate"), ",")) {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+	schema := obj{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// constrain adds the validation rules of a field to its schema, reporting
+// whether the field is required. Rules the document can't express, like the
+// ones spanning fields, are left to the descriptions.
+func constrain(schema obj, t reflect.Type, rules []string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := schema["allOf"]; ok {
+		schema = obj{}
+	}
+	required := false
+	for i := 0; i < len(rules); i++ {
+		rule, value := rules[i], ""
+		if j := strings.Index(rule, "="); j >= 0 {
+			rule, value = rule[:j], rule[j+1:]
+		}
+		switch rule {
+		case "required":
+			required = true
+		case "dive":
+			// The following rules apply to the items, past the ones of map keys
+			rest := rules[i+1:]
+			if len(rest) > 0 && rest[0] == "keys" {
+				for len(rest) > 0 && rest[0] != "endkeys" {
+					rest = rest[1:]
+				}
+				if len(rest) > 0 {
+					rest = rest[1:]
+				}
+			}
+			if items, ok := schema["items"].(obj); ok {
+				constrain(items, t.Elem(), rest)
+			} else if values, ok := schema["additionalProperties"].(obj); ok {
+				constrain(values, t.Elem(), rest)
+			}
+			return required
+		case "min", "gte":
+			schema[limit(t, "min")] = json.Number(value)
+		case "max", "lte":
+			schema[limit(t, "max")] = json.Number(value)
+		case "len":
+			schema[limit(t, "min")] = json.Number(value)
+			schema[limit(t, "max")] = json.Number(value)
+		case "gt":
+			schema["minimum"], schema["exclusiveMinimum"] = json.Number(value), true
+		case "lt":
+			schema["maximum"], schema["exclusiveMaximum"] = json.Number(value), true
+		case "oneof":
+			schema["enum"] = strings.Fields(value)
+		case "email":
+			schema["format"] = "email"
+		case "slug":
+			schema["pattern"] = slugPattern.String()
+		case "gtin":
+			schema["pattern"] = `^([0-9]{8}|[0-9]{12,14})$`
+		}
+	}
+	return required
+}
+
+// limit names the bound of a value of type `t`, "min" or "max" as validated
+func limit(t reflect.Type, bound string) string {
+	switch t.Kind() {
+	case reflect.String:
+		return bound + "Length"
+	case reflect.Slice, reflect.Array:
+		return bound + "Items"
+	case reflect.Map:
+		return bound + "Properties"
+	}
+	return map[string]string{"min": "minimum", "max": "maximum"}[bound]
+}
+
+// content lists the media types a model is read or written in
+func (s schemas) content(value interface{}) obj {
+	var schema obj
+	if alternatives, ok := value.(oneOf); ok {
+		var schemas []interface{}
+		for _, alternative := range alternatives {
+			schemas = append(schemas, s.of(reflect.TypeOf(alternative)))
+		}
+		schema = obj{"oneOf": schemas}
+	} else {
+		schema = s.of(reflect.TypeOf(value))
+	}
+	content := obj{}
+	for _, media := range []string{mimeJSON, mimeXML, mimeMsgpack} {
+		content[media] = obj{"schema": schema}
+	}
+	if kind := reflect.TypeOf(value).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		content[mimeCSV] = obj{"schema": schema}
+	}
+	return content
+}
+
+// document describes the operation of a route in the OpenAPI document,
+// `authenticated` tells whether the API requires credentials, `limited`
+// whether it limits the rate of requests
+func (op operation) document(s schemas, route *echo.Route, authenticated bool, limited bool) obj {
+	name := route.Name[strings.LastIndex(route.Name, ".")+1:]
+	doc := obj{"operationId": strings.TrimSuffix(name, "-fm"), "summary": op.summary}
+	if op.tag != "" {
+		doc["tags"] = []string{op.tag}
+	}
+	if op.description != "" {
+		doc["description"] = op.description
+	}
+	params := []interface{}{}
+	for _, segment := range strings.Split(route.Path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			schema := obj{"type": "string"}
+			if segment == ":id" {
+				schema = obj{"type": "integer"}
+			}
+			params = append(params, obj{"name": segment[1:], "in": "path", "required": true, "schema": schema})
+		}
+	}
+	for _, p := range op.params {
+		params = append(params, obj{"name": p.name, "in": "query", "description": p.description, "schema": p.schema})
+	}
+	if op.idempotent {
+		params = append(params, obj{"name": headerIdempotencyKey, "in": "header", "schema": obj{"type": "string", "maxLength": maxIdempotencyKey},
+			"description": "Unique key of the request, its retries with the same key get the first response back " +
+				"instead of running again. A retry while the first request runs answers 409, another request with the key 422."})
+	}
+	if len(params) > 0 {
+		doc["parameters"] = params
+	}
+	if op.bodyContent != nil {
+		doc["requestBody"] = obj{"required": true, "content": op.bodyContent}
+	} else if op.body != nil {
+		doc["requestBody"] = obj{"required": true, "content": s.content(op.body)}
+	}
+	status, success := op.status, obj{}
+	switch {
+	case op.responseContent != nil:
+		success["content"] = op.responseContent
+	case op.response != nil:
+		success["content"] = s.content(op.response)
+	case status == 0:
+		status = http.StatusNoContent
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success["description"] = http.StatusText(status)
+	responses := obj{strconv.Itoa(status): success}
+	var errors []int
+	if publicPaths[route.Path] {
+		if authenticated {
+			doc["security"] = []obj{}
+		}
+	} else if authenticated {
+		// Clients lacking the role of the route are forbidden
+		errors = append(errors, http.StatusUnauthorized)
+		if op.editor || op.admin || route.Method != http.MethodGet {
+			errors = append(errors, http.StatusForbidden)
+		}
+	} else if op.editor {
+		errors = append(errors, http.StatusForbidden)
+	}
+	if op.editor && !authenticated {
+		doc["security"] = []obj{{"editorToken": []string{}}}
+	}
+	errors = append(errors, op.errors...)
+	if op.body != nil {
+		errors = append(errors, http.StatusUnsupportedMediaType)
+	}
+	if op.idempotent {
+		errors = append(errors, http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	if limited {
+		errors = append(errors, http.StatusTooManyRequests)
+		success["headers"] = rateLimitHeaders
+	}
+	failure := obj{mimeProblem: obj{"schema": s.of(reflect.TypeOf(problem{}))}}
+	for _, code := range errors {
+		response := obj{"description": http.StatusText(code), "content": failure}
+		if code == http.StatusTooManyRequests {
+			response["headers"] = obj{echo.HeaderRetryAfter: obj{"description": "Seconds until the next request is allowed",
+				"schema": obj{"type": "integer"}}}
+		}
+		// Failed authentications spend the budget of the IP
+		if code == http.StatusUnauthorized && limited {
+			response["headers"] = rateLimitHeaders
+		}
+		responses[strconv.Itoa(code)] = response
+	}
+	responses["default"] = obj{"description": "Unexpected error", "content": failure}
+	doc["responses"] = responses
+	return doc
+}
+
+// openAPI generates the OpenAPI 3 document of the registered routes,
+// the routes missing from `operations` get the minimal description
+func (api *Api) openAPI() obj {
+	s := schemas{}
+	paths := obj{}
+	routes := api.Http.Routes()
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	for _, route := range routes {
+		path := route.Path
+		for _, segment := range strings.Split(route.Path, "/") {
+			if strings.HasPrefix(segment, ":") {
+				path = strings.Replace(path, segment, "{"+segment[1:]+"}", 1)
+			}
+		}
+		item, ok := paths[path].(obj)
+		if !ok {
+			item = obj{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operations[route.Method+" "+route.Path].document(s, route, api.authenticating(), api.conf.Api.RateLimit.Enabled)
+	}
+	// The editor token is retired once clients authenticate
+	security := obj{"editorToken": obj{"type": "apiKey", "in": "header", "name": headerEditorToken}}
+	if api.authenticating() {
+		security = obj{
+			"apiKey":    obj{"type": "apiKey", "in": "header", "name": headerApiKey},
+			"bearerKey": obj{"type": "http", "scheme": "bearer", "description": "An API key or a JWT"},
+		}
+	}
+	doc := obj{
+		"openapi": "3.0.3",
+		"info": obj{
+			"title":   "Products API",
+			"version": "1.0",
+			"description": "A REST API for a catalog of products. Responses follow the Accept header, request bodies the Content-Type. " +
+				"Errors are RFC 7807 problems, listing the invalid fields of requests. " +
+				"Clients authenticate with an API key or a JWT of the identity provider. Viewers read, editors write as well " +
+				"and admins manage API keys; the read, write and admin scopes of keys grant these roles.",
+		},
+		"paths":      paths,
+		"components": obj{"schemas": s, "securitySchemes": security},
+	}
+	if api.authenticating() {
+		doc["security"] = []obj{{"apiKey": []string{}}, {"bearerKey": []string{}}}
+	}
+	return doc
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/service"
+)
+
+// Headers of the rate limits, from the IETF RateLimit header fields draft
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+)
+
+// sweepInterval is how often the buckets back to full are forgotten
+const sweepInterval = time.Minute
+
+// bucket holds the tokens left to a client
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// buckets are the token buckets of the clients of a budget, refilled at
+// `rate` tokens per second up to `burst` tokens
+type buckets struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	clients map[string]*bucket
+	swept   time.Time
+}
+
+// newBuckets returns nil for a budget without limit, when `rate` isn't positive
+func newBuckets(rate float64, burst int) *buckets {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &buckets{rate: rate, burst: float64(burst), clients: map[string]*bucket{}}
+}
+
+// take spends a token of the bucket of `client` when there is one. It returns
+// the tokens left, and the time until the next token or the full bucket.
+func (b *buckets) take(client string, now time.Time) (taken bool, remaining int, retry time.Duration, reset time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Sub(b.swept) >= sweepInterval {
+		b.sweep(now)
+	}
+	state := b.refill(client, now)
+	if state.tokens >= 1 {
+		state.tokens--
+		taken = true
+	} else {
+		retry = b.wait(1 - state.tokens)
+	}
+	return taken, int(state.tokens), retry, b.wait(b.burst - state.tokens)
+}
+
+// left returns the tokens left to `client` without spending any, and the time
+// until the next token or the full bucket
+func (b *buckets) left(client string, now time.Time) (remaining int, retry time.Duration, reset time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.refill(client, now)
+	if state.tokens < 1 {
+		retry = b.wait(1 - state.tokens)
+	}
+	return int(state.tokens), retry, b.wait(b.burst - state.tokens)
+}
+
+// refill returns the bucket of `client` with the tokens gained until `now`
+func (b *buckets) refill(client string, now time.Time) *bucket {
+	state, ok := b.clients[client]
+	if !ok {
+		state = &bucket{tokens: b.burst, updated: now}
+		b.clients[client] = state
+	}
+	state.tokens = math.Min(b.burst, state.tokens+now.Sub(state.updated).Seconds()*b.rate)
+	state.updated = now
+	return state
+}
+
+// wait is the time it takes to refill `tokens`
+func (b *buckets) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
+
+// sweep forgets the buckets back to full, they are the same as new ones
+func (b *buckets) sweep(now time.Time) {
+	for client, state := range b.clients {
+		if state.tokens+now.Sub(state.updated).Seconds()*b.rate >= b.burst {
+			delete(b.clients, client)
+		}
+	}
+	b.swept = now
+}
+
+// rateLimiter limits the requests of each client, reading and writing
+// routes spend separate budgets. The failed authentications of each IP
+// spend a budget of their own.
+type rateLimiter struct {
+	read     *buckets
+	write    *buckets
+	failures *buckets
+}
+
+func newRateLimiter(conf *config.Config) *rateLimiter {
+	limits := conf.Api.RateLimit
+	return &rateLimiter{read: newBuckets(limits.ReadRate, limits.ReadBurst),
+		write: newBuckets(limits.WriteRate, limits.WriteBurst), failures: newBuckets(limits.FailureRate, limits.FailureBurst)}
+}
+
+// ipExtractor reads the client IP from the `X-Forwarded-For` of the trusted
+// proxies only, clients could pick any IP otherwise
+func ipExtractor(conf *config.Config) echo.IPExtractor {
+	if len(conf.Api.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	trust := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxies := range conf.Api.TrustedProxies {
+		// Checked as the config is loaded
+		if _, ipRange, err := net.ParseCIDR(proxies); err == nil {
+			trust = append(trust, echo.TrustIPRange(ipRange))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(trust...)
+}
+
+// client identifies the caller by API key or token subject once
+// authenticated, by IP address otherwise
+func client(c echo.Context) string {
+	if p := principal(c); p != nil {
+		return p.Id()
+	}
+	return "ip:" + c.RealIP()
+}
+
+// seconds rounds durations up to whole seconds, as the headers count them
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// setHeaders tells the state of `budget` in the response
+func setHeaders(c echo.Context, budget *buckets, remaining int, reset time.Duration) {
+	header := c.Response().Header()
+	header.Set(headerRateLimitLimit, strconv.Itoa(int(budget.burst)))
+	header.Set(headerRateLimitRemaining, strconv.Itoa(remaining))
+	header.Set(headerRateLimitReset, seconds(reset))
+}
+
+// exceeded is the error of the clients out of tokens
+func exceeded(c echo.Context, retry time.Duration) error {
+	c.Response().Header().Set(echo.HeaderRetryAfter, seconds(retry))
+	return echo.NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded, retry in "+seconds(retry)+" s")
+}
+
+// guard is a middleware running before the authentication, answering 429 to
+// the IPs out of failed authentications without checking their credentials.
+// Each failure spends a token, its response tells the state of the budget.
+func (rl *rateLimiter) guard(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if rl.failures == nil {
+			return next(c)
+		}
+		ip := "ip:" + c.RealIP()
+		if remaining, retry, reset := rl.failures.left(ip, time.Now()); retry > 0 {
+			setHeaders(c, rl.failures, remaining, reset)
+			return exceeded(c, retry)
+		}
+		err := next(c)
+		var failure *service.AuthenticationError
+		if errors.As(err, &failure) {
+			_, remaining, _, reset := rl.failures.take(ip, time.Now())
+			setHeaders(c, rl.failures, remaining, reset)
+		}
+		return err
+	}
+}
+
+// limit is a middleware answering 429 to the clients out of tokens, every
+// response tells the state of the budget of the client
+func (rl *rateLimiter) limit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		budget := rl.write
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			budget = rl.read
+		}
+		if budget == nil {
+			return next(c)
+		}
+		taken, remaining, retry, reset := budget.take(client(c), time.Now())
+		setHeaders(c, budget, remaining, reset)
+		if !taken {
+			return exceeded(c, retry)
+		}
+		return next(c)
+	}
+}
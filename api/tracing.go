@@ -0,0 +1,40 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mrlightwood/golang-products-api/api")
+
+// traceContext reads the W3C `traceparent` and `tracestate` headers
+var traceContext = propagation.TraceContext{}
+
+// trace is a middleware running each request in a span, child of the span of
+// the `traceparent` header when the caller sent one. The services and the
+// store start their spans in the span of the request context.
+func (api *Api) trace(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := traceContext.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		route := api.route(c)
+		name := req.Method + " " + route
+		if route == "unmatched" {
+			name, route = "HTTP "+req.Method, ""
+		}
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(api.conf.Tracing.ServiceName, route, req)...))
+		defer span.End()
+		c.SetRequest(req.WithContext(ctx))
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+		return nil
+	}
+}
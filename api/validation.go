@@ -0,0 +1,75 @@
+package api
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// slugPattern matches lowercase words separated by single hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// registerValidations adds the rules that can't be expressed with struct tags
+func registerValidations(validate *validator.Validate) {
+	// Errors name the fields like requests do
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "-" {
+			return name
+		}
+		return ""
+	})
+	validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
+	validate.RegisterValidation("gtin", func(fl validator.FieldLevel) bool {
+		return model.ValidGTIN(fl.Field().String())
+	})
+	validate.RegisterStructValidation(validateProduct, model.Product{})
+	validate.RegisterStructValidation(validatePromotion, model.Promotion{})
+}
+
+// validateProduct requires `unpublish_at` to follow `publish_at` when both are set,
+// and components with bundle pricing to be given for bundles only
+func validateProduct(sl validator.StructLevel) {
+	product := sl.Current().Interface().(model.Product)
+	if product.PublishAt != nil && product.UnpublishAt != nil && !product.UnpublishAt.After(*product.PublishAt) {
+		sl.ReportError(product.UnpublishAt, "unpublish_at", "UnpublishAt", "gtfield", "publish_at")
+	}
+	if product.IsBundle() {
+		if len(product.Components) == 0 {
+			sl.ReportError(product.Components, "components", "Components", "required", "")
+		}
+		seen := map[int]bool{}
+		for _, component := range product.Components {
+			if seen[component.Product] {
+				sl.ReportError(product.Components, "components", "Components", "unique", "")
+				break
+			}
+			seen[component.Product] = true
+		}
+	} else {
+		if len(product.Components) > 0 {
+			sl.ReportError(product.Components, "components", "Components", "excluded_unless", "type bundle")
+		}
+		if product.BundlePricing != "" {
+			sl.ReportError(product.BundlePricing, "bundle_pricing", "BundlePricing", "excluded_unless", "type bundle")
+		}
+	}
+}
+
+// validatePromotion checks the rules spanning several promotion fields
+func validatePromotion(sl validator.StructLevel) {
+	promotion := sl.Current().Interface().(model.Promotion)
+	if promotion.Type == model.PromotionTypePercentage && promotion.Value > 100 {
+		sl.ReportError(promotion.Value, "value", "Value", "lte", "100")
+	}
+	if len(promotion.Products) == 0 && len(promotion.Categories) == 0 && len(promotion.Tags) == 0 {
+		sl.ReportError(promotion.Products, "products", "Products", "required_without_all", "categories tags")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		sl.ReportError(promotion.EndsAt, "ends_at", "EndsAt", "gtfield", "starts_at")
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 
 	"github.com/jinzhu/configor"
@@ -13,10 +15,71 @@ type Config struct {
 	Api        struct {
 		HttpPort int  `default:"8080"`
 		Logging  bool `default:"false"`
+		// Token granting editor access, sent in the `X-Editor-Token` header, to the
+		// APIs without authentication only
+		EditorToken string
+		// Admin API key created on first startup, `pk_` and at least 32 random
+		// characters, a random one is logged when empty
+		BootstrapKey string
+		// Seconds the responses of requests with an idempotency key are replayed to their retries
+		IdempotencyExpiry int `default:"86400"`
+		// Seconds a request holds its idempotency key, its retries get 409 Conflict
+		// meanwhile and run once the request is taken for dead. Longer than requests last.
+		IdempotencyLease int `default:"300"`
+		// Ranges (CIDR) of the reverse proxies whose `X-Forwarded-For` tells the
+		// client IP, the address of the connection is the client IP when empty
+		TrustedProxies []string
+		// Token buckets of each client, keyed by API key or token subject, or else by IP
+		RateLimit struct {
+			Enabled bool `default:"false"`
+			// Requests per second and burst of the reading routes, not limited when the rate is 0
+			ReadRate  float64 `default:"10"`
+			ReadBurst int     `default:"20"`
+			// Requests per second and burst of the other routes, not limited when the rate is 0
+			WriteRate  float64 `default:"2"`
+			WriteBurst int     `default:"5"`
+			// Failed authentications per second and burst of each IP, checked
+			// before the credentials, not limited when the rate is 0
+			FailureRate  float64 `default:"0.1"`
+			FailureBurst int     `default:"10"`
+		}
+	}
+	// Tokens of the identity provider, accepted as bearer tokens when a secret or a JWKS is set
+	Jwt struct {
+		// Shared secrets of HS256, HS384 and HS512 tokens, several ones while rotating
+		Secrets []string
+		// Local JWKS file with the RSA and ECDSA public keys of RS, PS and ES tokens
+		JwksFile string
+		// Expected `iss` and `aud` claims, not checked when empty
+		Issuer   string
+		Audience string
+		// Claim listing the roles of the subject, as a list or a space separated string
+		RolesClaim string `default:"roles"`
+		// Role of the API (viewer, editor or admin) by value of the roles claim.
+		// Without entries the values name roles of the API, with entries the
+		// values without one grant nothing.
+		Roles map[string]string
 	}
 	Store struct {
 		Dbpath string `required:"true"`
 	}
+	Scheduler struct {
+		// Seconds between publication schedule runs, positive
+		Interval int `default:"60"`
+	}
+	// Spans of the requests, the service calls and the SQL statements
+	Tracing struct {
+		// Where the spans go: "stdout", "otlp", or nowhere when empty
+		Exporter string
+		// host:port of the OTLP/HTTP collector, the OTEL_EXPORTER_OTLP_* variables apply when empty
+		Endpoint string
+		// Send the spans to the collector over HTTP rather than HTTPS
+		Insecure    bool
+		ServiceName string `default:"products-api"`
+		// Share of the traces started by the API which are recorded, the traces of
+		// requests carrying a `traceparent` follow the decision of the caller
+		SampleRatio float64 `default:"1"`
+	}
 }
 
 func NewConfig(configFile string) (*Config, error) {
@@ -24,6 +87,14 @@ func NewConfig(configFile string) (*Config, error) {
 	if err := configor.Load(config, configFile); err != nil {
 		return nil, err
 	}
+	if config.Scheduler.Interval <= 0 {
+		return nil, fmt.Errorf("scheduler.interval: %d seconds isn't positive", config.Scheduler.Interval)
+	}
+	for _, proxies := range config.Api.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxies); err != nil {
+			return nil, fmt.Errorf("api.trustedproxies: %w", err)
+		}
+	}
 	// Create database file if not exists
 	config.Store.Dbpath = helpers.RootDir() + config.Store.Dbpath
 	if _, err := os.Stat(config.Store.Dbpath); os.IsNotExist(err) {
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+const apiKeyColumns = "id, name, prefix, scopes, expires_at, last_used_at, created_at, revoked_at, hash"
+
+func scanApiKey(row scanner) (*model.ApiKey, error) {
+	key := &model.ApiKey{}
+	var scopes string
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, &scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt,
+		&key.RevokedAt, &key.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// getApiKey returns the key matching the condition on `column`, nil when there is none
+func (sc *StoreContext) getApiKey(ctx context.Context, tx *sql.Tx, column string, value interface{}) (*model.ApiKey, error) {
+	key, err := scanApiKey(sc.conn(ctx, tx).QueryRow("SELECT "+apiKeyColumns+" FROM api_key WHERE "+column+" = $1;", value))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	return key, nil
+}
+
+func (sc *StoreContext) GetApiKey(ctx context.Context, tx *sql.Tx, id int) (*model.ApiKey, error) {
+	return sc.getApiKey(ctx, tx, "id", id)
+}
+
+func (sc *StoreContext) GetApiKeyByHash(ctx context.Context, tx *sql.Tx, hash string) (*model.ApiKey, error) {
+	return sc.getApiKey(ctx, tx, "hash", hash)
+}
+
+func (sc *StoreContext) GetApiKeys(ctx context.Context, tx *sql.Tx) ([]*model.ApiKey, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT " + apiKeyColumns + " FROM api_key ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []*model.ApiKey
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (sc *StoreContext) CountApiKeys(ctx context.Context, tx *sql.Tx) (int, error) {
+	var count int
+	err := sc.conn(ctx, tx).QueryRow("SELECT COUNT(*) FROM api_key;").Scan(&count)
+	return count, err
+}
+
+func (sc *StoreContext) CreateApiKey(ctx context.Context, tx *sql.Tx, key *model.ApiKey) (*int, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	query := `INSERT INTO api_key(name, prefix, scopes, expires_at, created_at, hash)
+		VALUES($1, $2, $3, $4, $5, $6) RETURNING id;`
+	var id int
+	err = sc.conn(ctx, tx).QueryRow(query, key.Name, key.Prefix, string(scopes), utc(key.ExpiresAt), key.CreatedAt.UTC(),
+		key.Hash).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// execApiKey runs an update of key `id`, sql.ErrNoRows when there is no such key
+func (sc *StoreContext) execApiKey(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) error {
+	res, err := sc.conn(ctx, tx).Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (sc *StoreContext) SetApiKeyHash(ctx context.Context, tx *sql.Tx, id int, prefix string, hash string) error {
+	return sc.execApiKey(ctx, tx, "UPDATE api_key SET prefix = $1, hash = $2 WHERE id = $3;", prefix, hash, id)
+}
+
+func (sc *StoreContext) RevokeApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	return sc.execApiKey(ctx, tx, "UPDATE api_key SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2;", at.UTC(), id)
+}
+
+func (sc *StoreContext) TouchApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	return sc.execApiKey(ctx, tx, "UPDATE api_key SET last_used_at = $1 WHERE id = $2;", at.UTC(), id)
+}
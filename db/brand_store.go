@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetBrand(ctx context.Context, tx *sql.Tx, id int) (*model.Brand, error) {
+	brand := &model.Brand{}
+	err := sc.conn(ctx, tx).QueryRow("SELECT id, name FROM brand WHERE id = $1;", id).Scan(&brand.Id, &brand.Name)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	return brand, nil
+}
+
+func (sc *StoreContext) GetBrands(ctx context.Context, tx *sql.Tx) ([]*model.Brand, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT id, name FROM brand;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var brands []*model.Brand
+	for rows.Next() {
+		brand := &model.Brand{}
+		if err := rows.Scan(&brand.Id, &brand.Name); err != nil {
+			return nil, err
+		}
+		brands = append(brands, brand)
+	}
+	return brands, rows.Err()
+}
+
+func (sc *StoreContext) CreateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) (*int, error) {
+	var id int
+	if err := sc.conn(ctx, tx).QueryRow("INSERT INTO brand(name) VALUES($1) RETURNING id;", brand.Name).Scan(&id); err != nil {
+		return nil, sc.conflict(ctx, tx, err, "brand", 0, brandKeys(brand))
+	}
+	return &id, nil
+}
+
+func (sc *StoreContext) UpdateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) error {
+	res, err := sc.conn(ctx, tx).Exec("UPDATE brand SET name = $1 WHERE id = $2;", brand.Name, brand.Id)
+	if err != nil {
+		return sc.conflict(ctx, tx, err, "brand", brand.Id, brandKeys(brand))
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (sc *StoreContext) DeleteBrand(ctx context.Context, tx *sql.Tx, id int) error {
+	conn := sc.conn(ctx, tx)
+	res, err := conn.Exec("DELETE FROM brand WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = conn.Exec("UPDATE product SET brand = NULL WHERE brand = $1;", id)
+	return err
+}
+
+func (sc *StoreContext) GetBrandFacets(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	// Facets list the alternatives to the selected brand, so it is left out
+	if filter != nil {
+		unbranded := *filter
+		unbranded.Brand = nil
+		filter = &unbranded
+	}
+	where, args := productConditions(filter)
+	query := "SELECT brand.id, brand.name, COUNT(*) FROM product JOIN brand ON brand.id = product.brand" + where +
+		" GROUP BY brand.id ORDER BY brand.name, brand.id;"
+	rows, err := sc.conn(ctx, tx).Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var facets []*model.BrandFacet
+	for rows.Next() {
+		facet := &model.BrandFacet{}
+		if err := rows.Scan(&facet.Brand, &facet.Name, &facet.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, facet)
+	}
+	return facets, rows.Err()
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// ConflictError is returned when a write would duplicate a value that must be unique
+type ConflictError struct {
+	// Table of the rows, "product", "category", "brand" or "tax_class"
+	Entity string
+	// Field holding the duplicated value
+	Field string
+	// Id of the row already holding the value
+	Id int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s already in use by %s %d", e.Field, e.Entity, e.Id)
+}
+
+// uniqueKey is a value no two rows of a table may share
+type uniqueKey struct {
+	field string
+	// Condition on the rows holding the value, passed as $1
+	condition string
+	value     interface{}
+}
+
+func productKeys(product *model.Product) []uniqueKey {
+	var keys []uniqueKey
+	if product.SKU != "" {
+		keys = append(keys, uniqueKey{"sku", "sku = $1", product.SKU})
+	}
+	if product.Barcode != "" {
+		keys = append(keys, uniqueKey{"barcode", barcodeKey + " = $1", model.GTIN14(product.Barcode)})
+	}
+	if product.Slug != "" {
+		keys = append(keys, uniqueKey{"slug", "slug = $1", product.Slug})
+	}
+	return keys
+}
+
+func brandKeys(brand *model.Brand) []uniqueKey {
+	return []uniqueKey{{"name", "name = $1 COLLATE NOCASE", brand.Name}}
+}
+
+func taxClassKeys(taxClass *model.TaxClass) []uniqueKey {
+	return []uniqueKey{{"name", "name = $1 COLLATE NOCASE", taxClass.Name}}
+}
+
+func categoryKeys(category *model.Category) []uniqueKey {
+	keys := []uniqueKey{{"name", "name = $1 COLLATE NOCASE", category.Name}}
+	if category.Slug != "" {
+		keys = append(keys, uniqueKey{"slug", "slug = $1", category.Slug})
+	}
+	return keys
+}
+
+// conflict translates the violation of a unique index into a ConflictError
+// naming the row that already holds one of the keys of row `id`
+func (sc *StoreContext) conflict(ctx context.Context, tx *sql.Tx, err error, entity string, id int, keys []uniqueKey) error {
+	if e, ok := err.(sqlite3.Error); !ok || e.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return err
+	}
+	for _, key := range keys {
+		query := fmt.Sprintf(`SELECT id FROM "%s" WHERE %s AND id != $2 LIMIT 1;`, entity, key.condition)
+		var owner int
+		switch e := sc.conn(ctx, tx).QueryRow(query, key.value, id).Scan(&owner); e {
+		case nil:
+			return &ConflictError{Entity: entity, Field: key.field, Id: owner}
+		case sql.ErrNoRows:
+		default:
+			return e
+		}
+	}
+	return err
+}
+
+// checkNames fails on the rows of `table` created while names were not unique
+// yet, before the unique index would. Which one keeps a name is for the editors
+// to decide, the duplicates are reported rather than renamed.
+func checkNames(db *sql.DB, table string, plural string) error {
+	rows, err := db.Query(fmt.Sprintf(`SELECT min(name), group_concat(id, ', ') FROM "%s"
+		GROUP BY name COLLATE NOCASE HAVING count(*) > 1 ORDER BY min(id);`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var duplicates []string
+	for rows.Next() {
+		var name, ids string
+		if err = rows.Scan(&name, &ids); err != nil {
+			return err
+		}
+		duplicates = append(duplicates, fmt.Sprintf("%q (ids %s)", name, ids))
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("%s share names, rename them before the names are made unique: %s", plural, strings.Join(duplicates, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// exportColumns are the stored fields of products, the average rating and
+// review count are selected for sorting only
+const exportColumns = `id, COALESCE(sku, ''), name, COALESCE(slug, ''), COALESCE(description, ''), COALESCE(category, 0),
+	COALESCE((SELECT name FROM category WHERE category.id = product.category), ''), brand, COALESCE(barcode, ''),
+	price, status, (SELECT json_group_array(tag) FROM product_tag WHERE product_id = product.id), tax_class, stock,
+	weight, length, width, height, publish_at, unpublish_at, bundle_pricing,
+	(SELECT AVG(rating) FROM review WHERE product_id = product.id AND status = 'approved') AS average_rating,
+	(SELECT COUNT(*) FROM review WHERE product_id = product.id AND status = 'approved') AS review_count`
+
+func (sc *StoreContext) ExportProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	where, args := productConditions(filter)
+	query := "SELECT " + exportColumns + " FROM product" + where
+	if filter != nil && filter.Sort != "" {
+		query += " ORDER BY " + productOrder(filter.Sort)
+	}
+	rows, err := sc.conn(ctx, tx).Query(query+";", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		product := &model.ExportedProduct{}
+		var tags string
+		var averageRating sql.NullFloat64
+		var reviewCount int
+		err := rows.Scan(&product.Id, &product.SKU, &product.Name, &product.Slug, &product.Description, &product.Category,
+			&product.CategoryName, &product.Brand, &product.Barcode, &product.Price, &product.Status, &tags, &product.TaxClass,
+			&product.Stock, &product.Weight, &product.Length, &product.Width, &product.Height, &product.PublishAt,
+			&product.UnpublishAt, &product.BundlePricing, &averageRating, &reviewCount)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal([]byte(tags), &product.Tags); err != nil {
+			return err
+		}
+		if err = fn(product); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) (*model.IdempotencyKey, error) {
+	query := `SELECT key, fingerprint, created_at, status, content_type, body FROM idempotency_key WHERE key = $1;`
+	record := &model.IdempotencyKey{}
+	var status sql.NullInt64
+	var contentType sql.NullString
+	var body []byte
+	err := sc.conn(ctx, tx).QueryRow(query, key).Scan(&record.Key, &record.Fingerprint, &record.CreatedAt, &status, &contentType, &body)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	if status.Valid {
+		record.Response = &model.IdempotentResponse{Status: int(status.Int64), ContentType: contentType.String, Body: body}
+	}
+	return record, nil
+}
+
+func (sc *StoreContext) CreateIdempotencyKey(ctx context.Context, tx *sql.Tx, key *model.IdempotencyKey) (bool, error) {
+	res, err := sc.conn(ctx, tx).Exec(`INSERT INTO idempotency_key(key, fingerprint, created_at, locked_at) VALUES($1, $2, $3, $3)
+		ON CONFLICT(key) DO NOTHING;`, key.Key, key.Fingerprint, key.CreatedAt.UTC())
+	if err != nil {
+		return false, err
+	}
+	a, err := res.RowsAffected()
+	return a > 0, err
+}
+
+// RelockIdempotencyKey takes over an unfinished key whose lock was taken
+// before `before`, or never recorded, reporting whether it did
+func (sc *StoreContext) RelockIdempotencyKey(ctx context.Context, tx *sql.Tx, key string, before time.Time, at time.Time) (bool, error) {
+	res, err := sc.conn(ctx, tx).Exec(`UPDATE idempotency_key SET locked_at = $1
+		WHERE key = $2 AND status IS NULL AND (locked_at IS NULL OR locked_at < $3);`, at.UTC(), key, before.UTC())
+	if err != nil {
+		return false, err
+	}
+	a, err := res.RowsAffected()
+	return a > 0, err
+}
+
+func (sc *StoreContext) SetIdempotentResponse(ctx context.Context, tx *sql.Tx, key string, response *model.IdempotentResponse) error {
+	res, err := sc.conn(ctx, tx).Exec("UPDATE idempotency_key SET status = $1, content_type = $2, body = $3 WHERE key = $4;",
+		response.Status, response.ContentType, response.Body, key)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (sc *StoreContext) DeleteIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) error {
+	_, err := sc.conn(ctx, tx).Exec("DELETE FROM idempotency_key WHERE key = $1;", key)
+	return err
+}
+
+func (sc *StoreContext) DeleteIdempotencyKeys(ctx context.Context, tx *sql.Tx, before time.Time) (int64, error) {
+	res, err := sc.conn(ctx, tx).Exec("DELETE FROM idempotency_key WHERE created_at < $1;", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,495 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// measuredStore times the methods of a store and counts its transactions.
+// It has no embedded store, so that new methods of the interface are measured too.
+type measuredStore struct {
+	store        Store
+	durations    *prometheus.HistogramVec
+	transactions *prometheus.CounterVec
+}
+
+// Measure returns `store` measured by the collectors it registers in `reg`,
+// along with the ones of its connection pool and of the size of the catalog
+func Measure(store Store, reg prometheus.Registerer) (Store, error) {
+	ms := &measuredStore{
+		store: store,
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "store_method_duration_seconds",
+			Help:    "Duration of the calls of the store methods.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "store_transactions_total",
+			Help: "Transactions committed and rolled back.",
+		}, []string{"outcome"}),
+	}
+	ms.transactions.WithLabelValues("commit")
+	ms.transactions.WithLabelValues("rollback")
+	// The catalog is counted by the store itself, scrapes aren't store calls of the API
+	for _, c := range []prometheus.Collector{ms.durations, ms.transactions, newPoolCollector(store), newCatalogCollector(store)} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+func (ms *measuredStore) observe(method string, start time.Time) {
+	ms.durations.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (ms *measuredStore) Begin(ctx context.Context) (*sql.Tx, error) {
+	defer ms.observe("Begin", time.Now())
+	return ms.store.Begin(ctx)
+}
+
+func (ms *measuredStore) Close() error {
+	return ms.store.Close()
+}
+
+func (ms *measuredStore) Stats() sql.DBStats {
+	return ms.store.Stats()
+}
+
+func (ms *measuredStore) Commit(ctx context.Context, tx *sql.Tx) error {
+	defer ms.observe("Commit", time.Now())
+	err := ms.store.Commit(ctx, tx)
+	if err == nil {
+		ms.transactions.WithLabelValues("commit").Inc()
+	}
+	return err
+}
+
+func (ms *measuredStore) Rollback(ctx context.Context, tx *sql.Tx) error {
+	defer ms.observe("Rollback", time.Now())
+	err := ms.store.Rollback(ctx, tx)
+	if err == nil {
+		ms.transactions.WithLabelValues("rollback").Inc()
+	}
+	return err
+}
+
+func (ms *measuredStore) Savepoint(ctx context.Context, tx *sql.Tx, name string) error {
+	defer ms.observe("Savepoint", time.Now())
+	return ms.store.Savepoint(ctx, tx, name)
+}
+
+func (ms *measuredStore) Release(ctx context.Context, tx *sql.Tx, name string) error {
+	defer ms.observe("Release", time.Now())
+	return ms.store.Release(ctx, tx, name)
+}
+
+func (ms *measuredStore) RollbackTo(ctx context.Context, tx *sql.Tx, name string) error {
+	defer ms.observe("RollbackTo", time.Now())
+	return ms.store.RollbackTo(ctx, tx, name)
+}
+
+func (ms *measuredStore) GetProduct(ctx context.Context, tx *sql.Tx, id int) (*model.Product, error) {
+	defer ms.observe("GetProduct", time.Now())
+	return ms.store.GetProduct(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetProductByBarcode(ctx context.Context, tx *sql.Tx, code string) (*model.Product, error) {
+	defer ms.observe("GetProductByBarcode", time.Now())
+	return ms.store.GetProductByBarcode(ctx, tx, code)
+}
+
+func (ms *measuredStore) GetProductBySKU(ctx context.Context, tx *sql.Tx, sku string) (*model.Product, error) {
+	defer ms.observe("GetProductBySKU", time.Now())
+	return ms.store.GetProductBySKU(ctx, tx, sku)
+}
+
+func (ms *measuredStore) GetProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.Product, error) {
+	defer ms.observe("GetProducts", time.Now())
+	return ms.store.GetProducts(ctx, tx, filter)
+}
+
+func (ms *measuredStore) ExportProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	defer ms.observe("ExportProducts", time.Now())
+	return ms.store.ExportProducts(ctx, tx, filter, fn)
+}
+
+func (ms *measuredStore) CreateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) (*int, error) {
+	defer ms.observe("CreateProduct", time.Now())
+	return ms.store.CreateProduct(ctx, tx, product)
+}
+
+func (ms *measuredStore) UpdateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) error {
+	defer ms.observe("UpdateProduct", time.Now())
+	return ms.store.UpdateProduct(ctx, tx, product)
+}
+
+func (ms *measuredStore) DeleteProduct(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeleteProduct", time.Now())
+	return ms.store.DeleteProduct(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetProductRelations(ctx context.Context, tx *sql.Tx, id int, relationType string) ([]model.ProductRelation, error) {
+	defer ms.observe("GetProductRelations", time.Now())
+	return ms.store.GetProductRelations(ctx, tx, id, relationType)
+}
+
+func (ms *measuredStore) AddProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	defer ms.observe("AddProductRelations", time.Now())
+	return ms.store.AddProductRelations(ctx, tx, id, relations)
+}
+
+func (ms *measuredStore) RemoveProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	defer ms.observe("RemoveProductRelations", time.Now())
+	return ms.store.RemoveProductRelations(ctx, tx, id, relations)
+}
+
+func (ms *measuredStore) ClearProductRelations(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("ClearProductRelations", time.Now())
+	return ms.store.ClearProductRelations(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetReviews(ctx context.Context, tx *sql.Tx, product int, status string) ([]*model.Review, error) {
+	defer ms.observe("GetReviews", time.Now())
+	return ms.store.GetReviews(ctx, tx, product, status)
+}
+
+func (ms *measuredStore) CreateReview(ctx context.Context, tx *sql.Tx, review *model.Review) (*int, error) {
+	defer ms.observe("CreateReview", time.Now())
+	return ms.store.CreateReview(ctx, tx, review)
+}
+
+func (ms *measuredStore) SetReviewStatus(ctx context.Context, tx *sql.Tx, id int, status string) error {
+	defer ms.observe("SetReviewStatus", time.Now())
+	return ms.store.SetReviewStatus(ctx, tx, id, status)
+}
+
+func (ms *measuredStore) ResolveSlug(ctx context.Context, tx *sql.Tx, entity string, slug string) (*int, error) {
+	defer ms.observe("ResolveSlug", time.Now())
+	return ms.store.ResolveSlug(ctx, tx, entity, slug)
+}
+
+func (ms *measuredStore) GetBundlesContaining(ctx context.Context, tx *sql.Tx, id int) ([]int, error) {
+	defer ms.observe("GetBundlesContaining", time.Now())
+	return ms.store.GetBundlesContaining(ctx, tx, id)
+}
+
+func (ms *measuredStore) ApplyPublicationSchedule(ctx context.Context, tx *sql.Tx, now time.Time) (published int64, archived int64, err error) {
+	defer ms.observe("ApplyPublicationSchedule", time.Now())
+	return ms.store.ApplyPublicationSchedule(ctx, tx, now)
+}
+
+func (ms *measuredStore) GetPromotion(ctx context.Context, tx *sql.Tx, id int) (*model.Promotion, error) {
+	defer ms.observe("GetPromotion", time.Now())
+	return ms.store.GetPromotion(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetPromotions(ctx context.Context, tx *sql.Tx) ([]*model.Promotion, error) {
+	defer ms.observe("GetPromotions", time.Now())
+	return ms.store.GetPromotions(ctx, tx)
+}
+
+func (ms *measuredStore) GetActivePromotions(ctx context.Context, tx *sql.Tx, now time.Time) ([]*model.Promotion, error) {
+	defer ms.observe("GetActivePromotions", time.Now())
+	return ms.store.GetActivePromotions(ctx, tx, now)
+}
+
+func (ms *measuredStore) CreatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) (*int, error) {
+	defer ms.observe("CreatePromotion", time.Now())
+	return ms.store.CreatePromotion(ctx, tx, promotion)
+}
+
+func (ms *measuredStore) UpdatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) error {
+	defer ms.observe("UpdatePromotion", time.Now())
+	return ms.store.UpdatePromotion(ctx, tx, promotion)
+}
+
+func (ms *measuredStore) DeletePromotion(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeletePromotion", time.Now())
+	return ms.store.DeletePromotion(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetTaxClass(ctx context.Context, tx *sql.Tx, id int) (*model.TaxClass, error) {
+	defer ms.observe("GetTaxClass", time.Now())
+	return ms.store.GetTaxClass(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetTaxClasses(ctx context.Context, tx *sql.Tx) ([]*model.TaxClass, error) {
+	defer ms.observe("GetTaxClasses", time.Now())
+	return ms.store.GetTaxClasses(ctx, tx)
+}
+
+func (ms *measuredStore) CreateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) (*int, error) {
+	defer ms.observe("CreateTaxClass", time.Now())
+	return ms.store.CreateTaxClass(ctx, tx, taxClass)
+}
+
+func (ms *measuredStore) UpdateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) error {
+	defer ms.observe("UpdateTaxClass", time.Now())
+	return ms.store.UpdateTaxClass(ctx, tx, taxClass)
+}
+
+func (ms *measuredStore) DeleteTaxClass(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeleteTaxClass", time.Now())
+	return ms.store.DeleteTaxClass(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetTaxRates(ctx context.Context, tx *sql.Tx, region string) (map[int]float64, error) {
+	defer ms.observe("GetTaxRates", time.Now())
+	return ms.store.GetTaxRates(ctx, tx, region)
+}
+
+func (ms *measuredStore) GetSupplier(ctx context.Context, tx *sql.Tx, id int) (*model.Supplier, error) {
+	defer ms.observe("GetSupplier", time.Now())
+	return ms.store.GetSupplier(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetSuppliers(ctx context.Context, tx *sql.Tx) ([]*model.Supplier, error) {
+	defer ms.observe("GetSuppliers", time.Now())
+	return ms.store.GetSuppliers(ctx, tx)
+}
+
+func (ms *measuredStore) CreateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) (*int, error) {
+	defer ms.observe("CreateSupplier", time.Now())
+	return ms.store.CreateSupplier(ctx, tx, supplier)
+}
+
+func (ms *measuredStore) UpdateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) error {
+	defer ms.observe("UpdateSupplier", time.Now())
+	return ms.store.UpdateSupplier(ctx, tx, supplier)
+}
+
+func (ms *measuredStore) DeleteSupplier(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeleteSupplier", time.Now())
+	return ms.store.DeleteSupplier(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetProductSuppliers(ctx context.Context, tx *sql.Tx, id int) ([]model.ProductSupplier, error) {
+	defer ms.observe("GetProductSuppliers", time.Now())
+	return ms.store.GetProductSuppliers(ctx, tx, id)
+}
+
+func (ms *measuredStore) SetProductSuppliers(ctx context.Context, tx *sql.Tx, id int, suppliers []model.ProductSupplier) error {
+	defer ms.observe("SetProductSuppliers", time.Now())
+	return ms.store.SetProductSuppliers(ctx, tx, id, suppliers)
+}
+
+func (ms *measuredStore) GetBrand(ctx context.Context, tx *sql.Tx, id int) (*model.Brand, error) {
+	defer ms.observe("GetBrand", time.Now())
+	return ms.store.GetBrand(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetBrands(ctx context.Context, tx *sql.Tx) ([]*model.Brand, error) {
+	defer ms.observe("GetBrands", time.Now())
+	return ms.store.GetBrands(ctx, tx)
+}
+
+func (ms *measuredStore) CreateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) (*int, error) {
+	defer ms.observe("CreateBrand", time.Now())
+	return ms.store.CreateBrand(ctx, tx, brand)
+}
+
+func (ms *measuredStore) UpdateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) error {
+	defer ms.observe("UpdateBrand", time.Now())
+	return ms.store.UpdateBrand(ctx, tx, brand)
+}
+
+func (ms *measuredStore) DeleteBrand(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeleteBrand", time.Now())
+	return ms.store.DeleteBrand(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetBrandFacets(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	defer ms.observe("GetBrandFacets", time.Now())
+	return ms.store.GetBrandFacets(ctx, tx, filter)
+}
+
+func (ms *measuredStore) GetApiKey(ctx context.Context, tx *sql.Tx, id int) (*model.ApiKey, error) {
+	defer ms.observe("GetApiKey", time.Now())
+	return ms.store.GetApiKey(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetApiKeyByHash(ctx context.Context, tx *sql.Tx, hash string) (*model.ApiKey, error) {
+	defer ms.observe("GetApiKeyByHash", time.Now())
+	return ms.store.GetApiKeyByHash(ctx, tx, hash)
+}
+
+func (ms *measuredStore) GetApiKeys(ctx context.Context, tx *sql.Tx) ([]*model.ApiKey, error) {
+	defer ms.observe("GetApiKeys", time.Now())
+	return ms.store.GetApiKeys(ctx, tx)
+}
+
+func (ms *measuredStore) CountApiKeys(ctx context.Context, tx *sql.Tx) (int, error) {
+	defer ms.observe("CountApiKeys", time.Now())
+	return ms.store.CountApiKeys(ctx, tx)
+}
+
+func (ms *measuredStore) CreateApiKey(ctx context.Context, tx *sql.Tx, key *model.ApiKey) (*int, error) {
+	defer ms.observe("CreateApiKey", time.Now())
+	return ms.store.CreateApiKey(ctx, tx, key)
+}
+
+func (ms *measuredStore) SetApiKeyHash(ctx context.Context, tx *sql.Tx, id int, prefix string, hash string) error {
+	defer ms.observe("SetApiKeyHash", time.Now())
+	return ms.store.SetApiKeyHash(ctx, tx, id, prefix, hash)
+}
+
+func (ms *measuredStore) RevokeApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	defer ms.observe("RevokeApiKey", time.Now())
+	return ms.store.RevokeApiKey(ctx, tx, id, at)
+}
+
+func (ms *measuredStore) TouchApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	defer ms.observe("TouchApiKey", time.Now())
+	return ms.store.TouchApiKey(ctx, tx, id, at)
+}
+
+func (ms *measuredStore) GetIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) (*model.IdempotencyKey, error) {
+	defer ms.observe("GetIdempotencyKey", time.Now())
+	return ms.store.GetIdempotencyKey(ctx, tx, key)
+}
+
+func (ms *measuredStore) CreateIdempotencyKey(ctx context.Context, tx *sql.Tx, key *model.IdempotencyKey) (bool, error) {
+	defer ms.observe("CreateIdempotencyKey", time.Now())
+	return ms.store.CreateIdempotencyKey(ctx, tx, key)
+}
+
+func (ms *measuredStore) RelockIdempotencyKey(ctx context.Context, tx *sql.Tx, key string, before time.Time, at time.Time) (bool, error) {
+	defer ms.observe("RelockIdempotencyKey", time.Now())
+	return ms.store.RelockIdempotencyKey(ctx, tx, key, before, at)
+}
+
+func (ms *measuredStore) SetIdempotentResponse(ctx context.Context, tx *sql.Tx, key string, response *model.IdempotentResponse) error {
+	defer ms.observe("SetIdempotentResponse", time.Now())
+	return ms.store.SetIdempotentResponse(ctx, tx, key, response)
+}
+
+func (ms *measuredStore) DeleteIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) error {
+	defer ms.observe("DeleteIdempotencyKey", time.Now())
+	return ms.store.DeleteIdempotencyKey(ctx, tx, key)
+}
+
+func (ms *measuredStore) DeleteIdempotencyKeys(ctx context.Context, tx *sql.Tx, before time.Time) (int64, error) {
+	defer ms.observe("DeleteIdempotencyKeys", time.Now())
+	return ms.store.DeleteIdempotencyKeys(ctx, tx, before)
+}
+
+func (ms *measuredStore) GetCategory(ctx context.Context, tx *sql.Tx, id int) (*model.Category, error) {
+	defer ms.observe("GetCategory", time.Now())
+	return ms.store.GetCategory(ctx, tx, id)
+}
+
+func (ms *measuredStore) GetCategories(ctx context.Context, tx *sql.Tx) ([]*model.Category, error) {
+	defer ms.observe("GetCategories", time.Now())
+	return ms.store.GetCategories(ctx, tx)
+}
+
+func (ms *measuredStore) CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) (*int, error) {
+	defer ms.observe("CreateCategory", time.Now())
+	return ms.store.CreateCategory(ctx, tx, category)
+}
+
+func (ms *measuredStore) UpdateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error {
+	defer ms.observe("UpdateCategory", time.Now())
+	return ms.store.UpdateCategory(ctx, tx, category)
+}
+
+func (ms *measuredStore) DeleteCategory(ctx context.Context, tx *sql.Tx, id int) error {
+	defer ms.observe("DeleteCategory", time.Now())
+	return ms.store.DeleteCategory(ctx, tx, id)
+}
+
+func (ms *measuredStore) CountCategories(ctx context.Context, tx *sql.Tx) (int, error) {
+	defer ms.observe("CountCategories", time.Now())
+	return ms.store.CountCategories(ctx, tx)
+}
+
+func (ms *measuredStore) CountProducts(ctx context.Context, tx *sql.Tx) (map[string]int, error) {
+	defer ms.observe("CountProducts", time.Now())
+	return ms.store.CountProducts(ctx, tx)
+}
+
+// poolCollector reads the statistics of the connection pool of a store at each scrape
+type poolCollector struct {
+	store       Store
+	maxOpen     *prometheus.Desc
+	open        *prometheus.Desc
+	inUse       *prometheus.Desc
+	idle        *prometheus.Desc
+	waits       *prometheus.Desc
+	waitSeconds *prometheus.Desc
+}
+
+func newPoolCollector(store Store) *poolCollector {
+	return &poolCollector{
+		store:       store,
+		maxOpen:     prometheus.NewDesc("store_max_open_connections", "Maximum number of open connections, 0 when unlimited.", nil, nil),
+		open:        prometheus.NewDesc("store_open_connections", "Open connections, in use and idle.", nil, nil),
+		inUse:       prometheus.NewDesc("store_in_use_connections", "Connections in use.", nil, nil),
+		idle:        prometheus.NewDesc("store_idle_connections", "Idle connections.", nil, nil),
+		waits:       prometheus.NewDesc("store_wait_count_total", "Connections waited for.", nil, nil),
+		waitSeconds: prometheus.NewDesc("store_wait_duration_seconds_total", "Time blocked waiting for connections.", nil, nil),
+	}
+}
+
+func (pc *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{pc.maxOpen, pc.open, pc.inUse, pc.idle, pc.waits, pc.waitSeconds} {
+		ch <- desc
+	}
+}
+
+func (pc *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := pc.store.Stats()
+	ch <- prometheus.MustNewConstMetric(pc.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(pc.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(pc.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(pc.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(pc.waits, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(pc.waitSeconds, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// catalogCollector counts the products and categories of a store at each scrape
+type catalogCollector struct {
+	store      Store
+	products   *prometheus.Desc
+	categories *prometheus.Desc
+}
+
+func newCatalogCollector(store Store) *catalogCollector {
+	return &catalogCollector{
+		store:      store,
+		products:   prometheus.NewDesc("catalog_products", "Products of the catalog by status.", []string{"status"}, nil),
+		categories: prometheus.NewDesc("catalog_categories", "Categories of the catalog.", nil, nil),
+	}
+}
+
+func (cc *catalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.products
+	ch <- cc.categories
+}
+
+func (cc *catalogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	if products, err := cc.store.CountProducts(ctx, nil); err != nil {
+		ch <- prometheus.NewInvalidMetric(cc.products, err)
+	} else {
+		// Every status is reported, the missing ones as 0
+		for _, status := range []string{model.ProductStatusDraft, model.ProductStatusPublished, model.ProductStatusArchived} {
+			if _, ok := products[status]; !ok {
+				products[status] = 0
+			}
+		}
+		for status, count := range products {
+			ch <- prometheus.MustNewConstMetric(cc.products, prometheus.GaugeValue, float64(count), status)
+		}
+	}
+	if categories, err := cc.store.CountCategories(ctx, nil); err != nil {
+		ch <- prometheus.NewInvalidMetric(cc.categories, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(cc.categories, prometheus.GaugeValue, float64(categories))
+	}
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+const promotionColumns = "id, name, type, value, starts_at, ends_at, priority, stackable"
+
+func scanPromotion(row scanner) (*model.Promotion, error) {
+	promotion := &model.Promotion{}
+	err := row.Scan(&promotion.Id, &promotion.Name, &promotion.Type, &promotion.Value,
+		&promotion.StartsAt, &promotion.EndsAt, &promotion.Priority, &promotion.Stackable)
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (sc *StoreContext) GetPromotion(ctx context.Context, tx *sql.Tx, id int) (*model.Promotion, error) {
+	query := "SELECT " + promotionColumns + " FROM promotion WHERE id = $1;"
+	promotion, err := scanPromotion(sc.conn(ctx, tx).QueryRow(query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	if err = sc.loadPromotionTargets(ctx, tx, []*model.Promotion{promotion}); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (sc *StoreContext) GetPromotions(ctx context.Context, tx *sql.Tx) ([]*model.Promotion, error) {
+	return sc.queryPromotions(ctx, tx, "SELECT "+promotionColumns+" FROM promotion;")
+}
+
+func (sc *StoreContext) GetActivePromotions(ctx context.Context, tx *sql.Tx, now time.Time) ([]*model.Promotion, error) {
+	query := "SELECT " + promotionColumns + ` FROM promotion
+		WHERE (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY priority DESC, id;`
+	return sc.queryPromotions(ctx, tx, query, now.UTC())
+}
+
+func (sc *StoreContext) queryPromotions(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]*model.Promotion, error) {
+	rows, err := sc.conn(ctx, tx).Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var promotions []*model.Promotion
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err = sc.loadPromotionTargets(ctx, tx, promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// loadPromotionTargets fills in the products, categories and tags of the promotions
+func (sc *StoreContext) loadPromotionTargets(ctx context.Context, tx *sql.Tx, promotions []*model.Promotion) error {
+	if len(promotions) == 0 {
+		return nil
+	}
+	byId := make(map[int]*model.Promotion, len(promotions))
+	ids := make([]int, len(promotions))
+	for i, promotion := range promotions {
+		byId[promotion.Id] = promotion
+		ids[i] = promotion.Id
+	}
+	in, args := idList(ids)
+	query := "SELECT promotion_id, scope, target FROM promotion_target WHERE promotion_id IN (" + in + ") ORDER BY promotion_id, scope, target;"
+	rows, err := sc.conn(ctx, tx).Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var scope, target string
+		if err := rows.Scan(&id, &scope, &target); err != nil {
+			return err
+		}
+		promotion := byId[id]
+		switch scope {
+		case model.PromotionScopeProduct:
+			if v, err := strconv.Atoi(target); err == nil {
+				promotion.Products = append(promotion.Products, v)
+			}
+		case model.PromotionScopeCategory:
+			if v, err := strconv.Atoi(target); err == nil {
+				promotion.Categories = append(promotion.Categories, v)
+			}
+		case model.PromotionScopeTag:
+			promotion.Tags = append(promotion.Tags, target)
+		}
+	}
+	return rows.Err()
+}
+
+// forgetPromotionTarget drops a deleted product or category from the targets of
+// the promotions, its id could be given to another one later
+func (sc *StoreContext) forgetPromotionTarget(ctx context.Context, tx *sql.Tx, scope string, id int) error {
+	_, err := sc.conn(ctx, tx).Exec("DELETE FROM promotion_target WHERE scope = $1 AND target = $2;", scope, strconv.Itoa(id))
+	return err
+}
+
+// setPromotionTargets replaces the targets of a promotion
+func (sc *StoreContext) setPromotionTargets(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) error {
+	conn := sc.conn(ctx, tx)
+	if _, err := conn.Exec("DELETE FROM promotion_target WHERE promotion_id = $1;", promotion.Id); err != nil {
+		return err
+	}
+	query := "INSERT OR IGNORE INTO promotion_target(promotion_id, scope, target) VALUES($1, $2, $3);"
+	for _, id := range promotion.Products {
+		if _, err := conn.Exec(query, promotion.Id, model.PromotionScopeProduct, strconv.Itoa(id)); err != nil {
+			return err
+		}
+	}
+	for _, id := range promotion.Categories {
+		if _, err := conn.Exec(query, promotion.Id, model.PromotionScopeCategory, strconv.Itoa(id)); err != nil {
+			return err
+		}
+	}
+	for _, tag := range promotion.Tags {
+		if _, err := conn.Exec(query, promotion.Id, model.PromotionScopeTag, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StoreContext) CreatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) (*int, error) {
+	query := `INSERT INTO promotion(name, type, value, starts_at, ends_at, priority, stackable)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+	var id int
+	err := sc.conn(ctx, tx).QueryRow(query, promotion.Name, promotion.Type, promotion.Value,
+		utc(promotion.StartsAt), utc(promotion.EndsAt), promotion.Priority, promotion.Stackable).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	promotion.Id = id
+	if err = sc.setPromotionTargets(ctx, tx, promotion); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (sc *StoreContext) UpdatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) error {
+	query := `UPDATE promotion SET name=$1, type=$2, value=$3, starts_at=$4, ends_at=$5, priority=$6, stackable=$7
+		WHERE id = $8;`
+	res, err := sc.conn(ctx, tx).Exec(query, promotion.Name, promotion.Type, promotion.Value,
+		utc(promotion.StartsAt), utc(promotion.EndsAt), promotion.Priority, promotion.Stackable, promotion.Id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return sc.setPromotionTargets(ctx, tx, promotion)
+}
+
+func (sc *StoreContext) DeletePromotion(ctx context.Context, tx *sql.Tx, id int) error {
+	conn := sc.conn(ctx, tx)
+	res, err := conn.Exec("DELETE FROM promotion WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = conn.Exec("DELETE FROM promotion_target WHERE promotion_id = $1;", id)
+	return err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetProductRelations(ctx context.Context, tx *sql.Tx, id int, relationType string) ([]model.ProductRelation, error) {
+	query := `SELECT type, related_id FROM product_relation WHERE product_id = $1 AND ($2 = '' OR type = $2)
+		ORDER BY type, related_id;`
+	rows, err := sc.conn(ctx, tx).Query(query, id, relationType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var relations []model.ProductRelation
+	for rows.Next() {
+		var relation model.ProductRelation
+		if err := rows.Scan(&relation.Type, &relation.Product); err != nil {
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+func (sc *StoreContext) AddProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	conn := sc.conn(ctx, tx)
+	for _, relation := range relations {
+		query := "INSERT OR IGNORE INTO product_relation(product_id, related_id, type) VALUES($1, $2, $3);"
+		if _, err := conn.Exec(query, id, relation.Product, relation.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StoreContext) RemoveProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	conn := sc.conn(ctx, tx)
+	for _, relation := range relations {
+		query := "DELETE FROM product_relation WHERE product_id = $1 AND related_id = $2 AND type = $3;"
+		if _, err := conn.Exec(query, id, relation.Product, relation.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StoreContext) ClearProductRelations(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := sc.conn(ctx, tx).Exec("DELETE FROM product_relation WHERE product_id = $1;", id)
+	return err
+}
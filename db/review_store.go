@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetReviews(ctx context.Context, tx *sql.Tx, product int, status string) ([]*model.Review, error) {
+	query := `SELECT id, product_id, rating, title, body, author_name, status, created_at FROM review
+		WHERE product_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at DESC, id DESC;`
+	rows, err := sc.conn(ctx, tx).Query(query, product, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var reviews []*model.Review
+	for rows.Next() {
+		review := &model.Review{}
+		err := rows.Scan(&review.Id, &review.Product, &review.Rating, &review.Title, &review.Body,
+			&review.AuthorName, &review.Status, &review.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (sc *StoreContext) CreateReview(ctx context.Context, tx *sql.Tx, review *model.Review) (*int, error) {
+	query := `INSERT INTO review(product_id, rating, title, body, author_name, status, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+	var id int
+	err := sc.conn(ctx, tx).QueryRow(query, review.Product, review.Rating, review.Title, review.Body,
+		review.AuthorName, review.Status, review.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (sc *StoreContext) SetReviewStatus(ctx context.Context, tx *sql.Tx, id int, status string) error {
+	res, err := sc.conn(ctx, tx).Exec("UPDATE review SET status = $1 WHERE id = $2;", status, id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
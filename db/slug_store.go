@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// slugTable checks the entity before it is used as a table name
+func slugTable(entity string) (string, error) {
+	switch entity {
+	case model.SlugEntityProduct, model.SlugEntityCategory:
+		return entity, nil
+	}
+	return "", fmt.Errorf("no slugs for %q", entity)
+}
+
+func (sc *StoreContext) ResolveSlug(ctx context.Context, tx *sql.Tx, entity string, slug string) (*int, error) {
+	table, err := slugTable(entity)
+	if err != nil {
+		return nil, err
+	}
+	// Current slugs win over former ones
+	query := fmt.Sprintf(`SELECT id FROM (
+			SELECT id, 0 AS former FROM "%s" WHERE slug = $1
+			UNION ALL SELECT target_id, 1 FROM slug_history WHERE entity = $2 AND slug = $1
+		) ORDER BY former LIMIT 1;`, table)
+	var id int
+	if err = sc.conn(ctx, tx).QueryRow(query, slug, entity).Scan(&id); err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	return &id, nil
+}
+
+// retireSlug keeps the current slug of a product or category in the history
+// when it is about to be replaced by `slug`, so that old URLs can redirect
+func (sc *StoreContext) retireSlug(ctx context.Context, tx *sql.Tx, entity string, id int, slug string) error {
+	if slug == "" {
+		return nil
+	}
+	table, err := slugTable(entity)
+	if err != nil {
+		return err
+	}
+	conn := sc.conn(ctx, tx)
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO slug_history(entity, slug, target_id)
+		SELECT $1, slug, id FROM "%s" WHERE id = $2 AND slug IS NOT NULL AND slug != $3;`, table)
+	if _, err = conn.Exec(query, entity, id, slug); err != nil {
+		return err
+	}
+	// A slug taken back is current again
+	_, err = conn.Exec("DELETE FROM slug_history WHERE entity = $1 AND slug = $2 AND target_id = $3;", entity, slug, id)
+	return err
+}
+
+// forgetSlugs drops the former slugs of a deleted product or category
+func (sc *StoreContext) forgetSlugs(ctx context.Context, tx *sql.Tx, entity string, id int) error {
+	_, err := sc.conn(ctx, tx).Exec("DELETE FROM slug_history WHERE entity = $1 AND target_id = $2;", entity, id)
+	return err
+}
+
+// backfillSlugs generates the slugs of rows created before slugs existed
+func backfillSlugs(db *sql.DB) error {
+	for _, entity := range []string{model.SlugEntityProduct, model.SlugEntityCategory} {
+		rows, err := db.Query(fmt.Sprintf(`SELECT id, name FROM "%s" WHERE slug IS NULL;`, entity))
+		if err != nil {
+			return err
+		}
+		names := map[int]string{}
+		for rows.Next() {
+			var id int
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				rows.Close()
+				return err
+			}
+			names[id] = name
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		for id, name := range names {
+			slug := model.Slugify(name)
+			if slug == "" {
+				slug = entity
+			}
+			// The id keeps generated slugs apart
+			for n := 0; ; n++ {
+				candidate := slug
+				if n > 0 {
+					candidate += "-" + strconv.Itoa(id)
+				}
+				if n > 1 {
+					candidate += "-" + strconv.Itoa(n)
+				}
+				var taken int
+				query := fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE slug = $1;`, entity)
+				if err = db.QueryRow(query, candidate).Scan(&taken); err != nil {
+					return err
+				}
+				if taken == 0 {
+					slug = candidate
+					break
+				}
+			}
+			if _, err = db.Exec(fmt.Sprintf(`UPDATE "%s" SET slug = $1 WHERE id = $2;`, entity), slug, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
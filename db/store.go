@@ -1,48 +1,371 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/logging"
 	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Store interface {
 	// Begin transaction
-	Begin() (*sql.Tx, error)
+	Begin(ctx context.Context) (*sql.Tx, error)
 	// Close storage
 	Close() error
+	// Statistics of the connection pool
+	Stats() sql.DBStats
 	// Commit transaction
-	Commit(tx *sql.Tx) error
+	Commit(ctx context.Context, tx *sql.Tx) error
 	// Rollback transaction
-	Rollback(tx *sql.Tx) error
+	Rollback(ctx context.Context, tx *sql.Tx) error
+	// Mark a point of the transaction to roll back to, `name` is an identifier
+	Savepoint(ctx context.Context, tx *sql.Tx, name string) error
+	// Keep the changes made since the savepoint and forget it
+	Release(ctx context.Context, tx *sql.Tx, name string) error
+	// Undo the changes made since the savepoint and forget it
+	RollbackTo(ctx context.Context, tx *sql.Tx, name string) error
 	// Get product by id
-	GetProduct(tx *sql.Tx, id int) (*model.Product, error)
-	// Get all products
-	GetProducts(tx *sql.Tx, category *int) ([]*model.Product, error)
+	GetProduct(ctx context.Context, tx *sql.Tx, id int) (*model.Product, error)
+	// Get product by barcode, GTIN-12 and GTIN-13 forms of a code are the same
+	GetProductByBarcode(ctx context.Context, tx *sql.Tx, code string) (*model.Product, error)
+	// Get product by SKU
+	GetProductBySKU(ctx context.Context, tx *sql.Tx, sku string) (*model.Product, error)
+	// Get all products matching the filter, nil filter returns every product
+	GetProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.Product, error)
+	// Call `fn` with each product matching the filter as it is read, stopping at its first error
+	ExportProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error
 	// Create a new product
-	CreateProduct(tx *sql.Tx, product *model.Product) (*int, error)
+	CreateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) (*int, error)
 	// Update an existing product
-	UpdateProduct(tx *sql.Tx, product *model.Product) error
+	UpdateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) error
 	// Delete an existing product
-	DeleteProduct(tx *sql.Tx, id int) error
+	DeleteProduct(ctx context.Context, tx *sql.Tx, id int) error
+	// Get the relations of a product, of every type when `relationType` is empty
+	GetProductRelations(ctx context.Context, tx *sql.Tx, id int, relationType string) ([]model.ProductRelation, error)
+	// Add relations to a product, existing ones are kept
+	AddProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error
+	// Remove relations from a product
+	RemoveProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error
+	// Remove every relation of a product
+	ClearProductRelations(ctx context.Context, tx *sql.Tx, id int) error
+	// Get the reviews of a product, of every status when `status` is empty
+	GetReviews(ctx context.Context, tx *sql.Tx, product int, status string) ([]*model.Review, error)
+	// Create a new review
+	CreateReview(ctx context.Context, tx *sql.Tx, review *model.Review) (*int, error)
+	// Set the moderation status of a review
+	SetReviewStatus(ctx context.Context, tx *sql.Tx, id int, status string) error
+	// Get the id of the product or category owning a current or former slug, nil when unknown
+	ResolveSlug(ctx context.Context, tx *sql.Tx, entity string, slug string) (*int, error)
+	// Get ids of the bundles containing the product
+	GetBundlesContaining(ctx context.Context, tx *sql.Tx, id int) ([]int, error)
+	// Publish drafts and archive published products whose schedule is due
+	ApplyPublicationSchedule(ctx context.Context, tx *sql.Tx, now time.Time) (published int64, archived int64, err error)
+	// Get promotion by id
+	GetPromotion(ctx context.Context, tx *sql.Tx, id int) (*model.Promotion, error)
+	// Get all promotions
+	GetPromotions(ctx context.Context, tx *sql.Tx) ([]*model.Promotion, error)
+	// Get promotions whose validity window contains `now`
+	GetActivePromotions(ctx context.Context, tx *sql.Tx, now time.Time) ([]*model.Promotion, error)
+	// Create a new promotion
+	CreatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) (*int, error)
+	// Update an existing promotion
+	UpdatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) error
+	// Delete an existing promotion
+	DeletePromotion(ctx context.Context, tx *sql.Tx, id int) error
+	// Get tax class by id
+	GetTaxClass(ctx context.Context, tx *sql.Tx, id int) (*model.TaxClass, error)
+	// Get all tax classes
+	GetTaxClasses(ctx context.Context, tx *sql.Tx) ([]*model.TaxClass, error)
+	// Create a new tax class
+	CreateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) (*int, error)
+	// Update an existing tax class
+	UpdateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) error
+	// Delete an existing tax class, products and categories fall back to no class
+	DeleteTaxClass(ctx context.Context, tx *sql.Tx, id int) error
+	// Get the tax rates of a region keyed by tax class, nil when the region is unknown
+	GetTaxRates(ctx context.Context, tx *sql.Tx, region string) (map[int]float64, error)
+	// Get supplier by id
+	GetSupplier(ctx context.Context, tx *sql.Tx, id int) (*model.Supplier, error)
+	// Get all suppliers
+	GetSuppliers(ctx context.Context, tx *sql.Tx) ([]*model.Supplier, error)
+	// Create a new supplier
+	CreateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) (*int, error)
+	// Update an existing supplier
+	UpdateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) error
+	// Delete an existing supplier along with its product links
+	DeleteSupplier(ctx context.Context, tx *sql.Tx, id int) error
+	// Get the suppliers of a product
+	GetProductSuppliers(ctx context.Context, tx *sql.Tx, id int) ([]model.ProductSupplier, error)
+	// Replace the suppliers of a product
+	SetProductSuppliers(ctx context.Context, tx *sql.Tx, id int, suppliers []model.ProductSupplier) error
+	// Get brand by id
+	GetBrand(ctx context.Context, tx *sql.Tx, id int) (*model.Brand, error)
+	// Get all brands
+	GetBrands(ctx context.Context, tx *sql.Tx) ([]*model.Brand, error)
+	// Create a new brand
+	CreateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) (*int, error)
+	// Update an existing brand
+	UpdateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) error
+	// Delete an existing brand, its products become unbranded
+	DeleteBrand(ctx context.Context, tx *sql.Tx, id int) error
+	// Count the products matching the filter per brand, the brand restriction of the filter is ignored
+	GetBrandFacets(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.BrandFacet, error)
+	// Get API key by id
+	GetApiKey(ctx context.Context, tx *sql.Tx, id int) (*model.ApiKey, error)
+	// Get API key by the hash of the key, nil when unknown
+	GetApiKeyByHash(ctx context.Context, tx *sql.Tx, hash string) (*model.ApiKey, error)
+	// Get all API keys, revoked ones included
+	GetApiKeys(ctx context.Context, tx *sql.Tx) ([]*model.ApiKey, error)
+	// Count the API keys, revoked ones included
+	CountApiKeys(ctx context.Context, tx *sql.Tx) (int, error)
+	// Create a new API key
+	CreateApiKey(ctx context.Context, tx *sql.Tx, key *model.ApiKey) (*int, error)
+	// Replace the key of an existing API key, given by its prefix and hash
+	SetApiKeyHash(ctx context.Context, tx *sql.Tx, id int, prefix string, hash string) error
+	// Revoke an existing API key, keeping the time of a former revocation
+	RevokeApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error
+	// Record the last use of an existing API key
+	TouchApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error
+	// Get an idempotency key along with its response, nil when unknown
+	GetIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) (*model.IdempotencyKey, error)
+	// Create an idempotency key without response, false when the key exists
+	CreateIdempotencyKey(ctx context.Context, tx *sql.Tx, key *model.IdempotencyKey) (bool, error)
+	// Lock again an idempotency key without response, locked before `before`
+	// by a request which died since. False when the key is locked or answered.
+	RelockIdempotencyKey(ctx context.Context, tx *sql.Tx, key string, before time.Time, at time.Time) (bool, error)
+	// Store the response of an existing idempotency key
+	SetIdempotentResponse(ctx context.Context, tx *sql.Tx, key string, response *model.IdempotentResponse) error
+	// Delete an idempotency key, if it exists
+	DeleteIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) error
+	// Delete the idempotency keys created before `before`
+	DeleteIdempotencyKeys(ctx context.Context, tx *sql.Tx, before time.Time) (int64, error)
 	// Get category by id
-	GetCategory(tx *sql.Tx, id int) (*model.Category, error)
+	GetCategory(ctx context.Context, tx *sql.Tx, id int) (*model.Category, error)
 	// Get all categories
-	GetCategories(tx *sql.Tx) ([]*model.Category, error)
+	GetCategories(ctx context.Context, tx *sql.Tx) ([]*model.Category, error)
 	// Create an existing category
-	CreateCategory(tx *sql.Tx, category *model.Category) (*int, error)
+	CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) (*int, error)
 	// Update an existing category
-	UpdateCategory(tx *sql.Tx, category *model.Category) error
+	UpdateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error
 	// Delete an existing category
-	DeleteCategory(tx *sql.Tx, id int) error
+	DeleteCategory(ctx context.Context, tx *sql.Tx, id int) error
+	// Count the categories
+	CountCategories(ctx context.Context, tx *sql.Tx) (int, error)
+	// Count the products per status
+	CountProducts(ctx context.Context, tx *sql.Tx) (map[string]int, error)
 }
 
 type StoreContext struct {
 	db *sql.DB
 }
 
+// executor is implemented by both *sql.DB and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querier runs the statements of a store method
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+var tracer = otel.Tracer("github.com/mrlightwood/golang-products-api/db")
+
+// conn runs statements on behalf of a request, logging them through its
+// logger and tracing them in its trace
+type conn struct {
+	ctx context.Context
+	executor
+}
+
+func (c *conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := c.trace(query)
+	start := time.Now()
+	res, err := c.ExecContext(ctx, query, args...)
+	c.log(query, start, err)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (c *conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := c.trace(query)
+	start := time.Now()
+	rows, err := c.QueryContext(ctx, query, args...)
+	c.log(query, start, err)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (c *conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := c.trace(query)
+	start := time.Now()
+	row := c.QueryRowContext(ctx, query, args...)
+	c.log(query, start, row.Err())
+	tracing.End(span, row.Err())
+	return row
+}
+
+// statement puts a query on a single line
+func statement(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// trace starts the span of a statement, named by its operation
+func (c *conn) trace(query string) (context.Context, trace.Span) {
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0])
+	ctx, span := tracer.Start(c.ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecording() {
+		span.SetAttributes(semconv.DBSystemSqlite, semconv.DBOperationKey.String(operation), semconv.DBStatementKey.String(statement(query)))
+	}
+	return ctx, span
+}
+
+// log writes a statement run since `start` at debug level
+func (c *conn) log(query string, start time.Time, err error) {
+	entry := logging.Entry(c.ctx)
+	if !entry.Logger.IsLevelEnabled(log.DebugLevel) {
+		return
+	}
+	entry = entry.WithField("query", statement(query)).WithField("duration", time.Since(start).String())
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Debug("SQL statement")
+}
+
+// conn runs the statements in the transaction when there is one, in the database otherwise
+func (sc *StoreContext) conn(ctx context.Context, tx *sql.Tx) querier {
+	if tx != nil {
+		return &conn{ctx: ctx, executor: tx}
+	}
+	return &conn{ctx: ctx, executor: sc.db}
+}
+
+// Columns added to the tables after their initial release.
+// Missing ones are added on startup, so existing databases keep working.
+var migrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"product", "status", `TEXT NOT NULL DEFAULT 'published'`},
+	{"product", "publish_at", "DATETIME"},
+	{"product", "unpublish_at", "DATETIME"},
+	{"product", "tax_class", "INTEGER"},
+	{"category", "tax_class", "INTEGER"},
+	{"product", "type", `TEXT NOT NULL DEFAULT 'simple'`},
+	{"product", "stock", "INTEGER NOT NULL DEFAULT 0"},
+	{"product", "bundle_pricing", "TEXT NOT NULL DEFAULT ''"},
+	{"product", "bundle_discount", "REAL NOT NULL DEFAULT 0"},
+	{"product", "brand", "INTEGER"},
+	{"product", "slug", "TEXT"},
+	{"category", "slug", "TEXT"},
+	{"product", "barcode", "TEXT"},
+	{"product", "weight", "REAL"},
+	{"product", "length", "REAL"},
+	{"product", "width", "REAL"},
+	{"product", "height", "REAL"},
+	{"product", "sku", "TEXT"},
+	{"idempotency_key", "locked_at", "DATETIME"},
+}
+
+// Indexes on migrated columns, created once the columns exist
+var indexes = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS "product_slug" ON "product" ("slug");`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "category_slug" ON "category" ("slug");`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "product_barcode" ON "product" (` + barcodeKey + `);`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "product_sku" ON "product" ("sku");`,
+	// Categories have no parent, their names are unique among all of them
+	`CREATE UNIQUE INDEX IF NOT EXISTS "category_name" ON "category" ("name" COLLATE NOCASE);`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "brand_name" ON "brand" ("name" COLLATE NOCASE);`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "tax_class_name" ON "tax_class" ("name" COLLATE NOCASE);`,
+}
+
+// barcodeKey is the GTIN-14 form barcodes are compared in
+const barcodeKey = `substr('00000000000000' || barcode, -14, 14)`
+
+const productColumns = `id, name, COALESCE(slug, ''), COALESCE(sku, ''), COALESCE(barcode, ''), description, category, price, status, publish_at, unpublish_at, tax_class,
+	type, stock, bundle_pricing, bundle_discount, brand, weight, length, width, height,
+	(SELECT json_group_array(tag) FROM product_tag WHERE product_id = product.id),
+	(SELECT json_group_array(json_object('product', product_id, 'quantity', quantity))
+		FROM product_component WHERE bundle_id = product.id),
+	(SELECT AVG(rating) FROM review WHERE product_id = product.id AND status = 'approved') AS average_rating,
+	(SELECT COUNT(*) FROM review WHERE product_id = product.id AND status = 'approved') AS review_count,
+	(SELECT cost_price FROM product_supplier WHERE product_id = product.id
+		ORDER BY preferred DESC, cost_price LIMIT 1) AS cost_price`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row scanner) (*model.Product, error) {
+	product := &model.Product{}
+	var tags, components string
+	err := row.Scan(&product.Id, &product.Name, &product.Slug, &product.SKU, &product.Barcode, &product.Description, &product.Category, &product.Price,
+		&product.Status, &product.PublishAt, &product.UnpublishAt, &product.TaxClass,
+		&product.Type, &product.Stock, &product.BundlePricing, &product.BundleDiscount, &product.Brand,
+		&product.Weight, &product.Length, &product.Width, &product.Height, &tags, &components,
+		&product.AverageRating, &product.ReviewCount, &product.CostPrice)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(tags), &product.Tags); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(components), &product.Components); err != nil {
+		return nil, err
+	}
+	if len(product.Components) == 0 {
+		product.Components = nil
+	}
+	return product, nil
+}
+
+// productOrder translates a sort key into an ORDER BY clause.
+// Products without rating come last in both directions.
+func productOrder(sort string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+	var order string
+	switch sort {
+	case "name", "price", "review_count":
+		order = sort + " " + direction
+	case "average_rating":
+		order = "average_rating IS NULL, average_rating " + direction
+	default:
+		return "id"
+	}
+	return order + ", id"
+}
+
+// utc normalizes optional timestamps so they compare correctly as stored text
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
 func initialize(db *sql.DB, tx *sql.Tx) error {
 	var query = `CREATE TABLE IF NOT EXISTS "category" (
 		"id"	INTEGER NOT NULL,
@@ -56,7 +379,112 @@ func initialize(db *sql.DB, tx *sql.Tx) error {
 		"category"	INTEGER,
 		"price"	REAL NOT NULL,
 		PRIMARY KEY("id" AUTOINCREMENT)
-	);`
+	);
+	CREATE TABLE IF NOT EXISTS "product_tag" (
+		"product_id"	INTEGER NOT NULL,
+		"tag"	TEXT NOT NULL,
+		PRIMARY KEY("product_id", "tag")
+	);
+	CREATE TABLE IF NOT EXISTS "product_component" (
+		"bundle_id"	INTEGER NOT NULL,
+		"product_id"	INTEGER NOT NULL,
+		"quantity"	INTEGER NOT NULL,
+		PRIMARY KEY("bundle_id", "product_id")
+	);
+	CREATE TABLE IF NOT EXISTS "product_relation" (
+		"product_id"	INTEGER NOT NULL,
+		"related_id"	INTEGER NOT NULL,
+		"type"	TEXT NOT NULL,
+		PRIMARY KEY("product_id", "related_id", "type")
+	);
+	CREATE INDEX IF NOT EXISTS "product_relation_related" ON "product_relation" ("related_id");
+	CREATE TABLE IF NOT EXISTS "review" (
+		"id"	INTEGER NOT NULL,
+		"product_id"	INTEGER NOT NULL,
+		"rating"	INTEGER NOT NULL,
+		"title"	TEXT NOT NULL,
+		"body"	TEXT NOT NULL,
+		"author_name"	TEXT NOT NULL,
+		"status"	TEXT NOT NULL,
+		"created_at"	DATETIME NOT NULL,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE INDEX IF NOT EXISTS "review_product" ON "review" ("product_id", "status");
+	CREATE TABLE IF NOT EXISTS "promotion" (
+		"id"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		"type"	TEXT NOT NULL,
+		"value"	REAL NOT NULL,
+		"starts_at"	DATETIME,
+		"ends_at"	DATETIME,
+		"priority"	INTEGER NOT NULL DEFAULT 0,
+		"stackable"	INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE TABLE IF NOT EXISTS "promotion_target" (
+		"promotion_id"	INTEGER NOT NULL,
+		"scope"	TEXT NOT NULL,
+		"target"	TEXT NOT NULL,
+		PRIMARY KEY("promotion_id", "scope", "target")
+	);
+	CREATE TABLE IF NOT EXISTS "slug_history" (
+		"entity"	TEXT NOT NULL,
+		"slug"	TEXT NOT NULL,
+		"target_id"	INTEGER NOT NULL,
+		PRIMARY KEY("entity", "slug")
+	);
+	CREATE TABLE IF NOT EXISTS "supplier" (
+		"id"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		"email"	TEXT NOT NULL,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE TABLE IF NOT EXISTS "product_supplier" (
+		"product_id"	INTEGER NOT NULL,
+		"supplier_id"	INTEGER NOT NULL,
+		"sku"	TEXT NOT NULL,
+		"cost_price"	REAL NOT NULL,
+		"preferred"	INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY("product_id", "supplier_id")
+	);
+	CREATE TABLE IF NOT EXISTS "brand" (
+		"id"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE TABLE IF NOT EXISTS "tax_class" (
+		"id"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE TABLE IF NOT EXISTS "tax_rate" (
+		"tax_class"	INTEGER NOT NULL,
+		"region"	TEXT NOT NULL,
+		"rate"	REAL NOT NULL,
+		PRIMARY KEY("tax_class", "region")
+	);
+	CREATE TABLE IF NOT EXISTS "api_key" (
+		"id"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		"prefix"	TEXT NOT NULL,
+		"scopes"	TEXT NOT NULL,
+		"expires_at"	DATETIME,
+		"last_used_at"	DATETIME,
+		"created_at"	DATETIME NOT NULL,
+		"revoked_at"	DATETIME,
+		"hash"	TEXT NOT NULL UNIQUE,
+		PRIMARY KEY("id" AUTOINCREMENT)
+	);
+	CREATE TABLE IF NOT EXISTS "idempotency_key" (
+		"key"	TEXT NOT NULL,
+		"fingerprint"	TEXT NOT NULL,
+		"created_at"	DATETIME NOT NULL,
+		"status"	INTEGER,
+		"content_type"	TEXT,
+		"body"	BLOB,
+		PRIMARY KEY("key")
+	);
+	CREATE INDEX IF NOT EXISTS "idempotency_key_created_at" ON "idempotency_key" ("created_at");`
 
 	var err error
 
@@ -69,6 +497,44 @@ func initialize(db *sql.DB, tx *sql.Tx) error {
 		return err
 	}
 
+	if err = migrate(db); err != nil {
+		return err
+	}
+	if err = backfillSlugs(db); err != nil {
+		return err
+	}
+	for _, names := range []struct{ table, plural string }{
+		{"category", "categories"},
+		{"brand", "brands"},
+		{"tax_class", "tax classes"},
+	} {
+		if err = checkNames(db, names.table, names.plural); err != nil {
+			return err
+		}
+	}
+	for _, index := range indexes {
+		if _, err = db.Exec(index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s') WHERE name = $1;", m.table), m.column)
+		if err != nil {
+			return err
+		}
+		exists := rows.Next()
+		rows.Close()
+		if exists {
+			continue
+		}
+		if _, err = db.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s;`, m.table, m.column, m.definition)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -94,36 +560,69 @@ func (sc *StoreContext) Close() error {
 	return sc.db.Close()
 }
 
-func (sc *StoreContext) Begin() (*sql.Tx, error) {
-	return sc.db.Begin()
+func (sc *StoreContext) Stats() sql.DBStats {
+	return sc.db.Stats()
+}
+
+// Begin starts a transaction of the request of `ctx`, rolled back if the request is canceled
+func (sc *StoreContext) Begin(ctx context.Context) (*sql.Tx, error) {
+	return sc.db.BeginTx(ctx, nil)
 }
 
-func (sc *StoreContext) Commit(tx *sql.Tx) error {
+func (sc *StoreContext) Commit(ctx context.Context, tx *sql.Tx) error {
 	if tx == nil {
 		return errors.New("transaction is nil")
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logging.Entry(ctx).Debug("Transaction committed")
+	return nil
+}
 
-	return tx.Commit()
+func (sc *StoreContext) Rollback(ctx context.Context, tx *sql.Tx) error {
+	if tx == nil {
+		return errors.New("transaction is nil")
+	}
+	if err := tx.Rollback(); err != nil {
+		return err
+	}
+	logging.Entry(ctx).Debug("Transaction rolled back")
+	return nil
 }
 
-func (sc *StoreContext) Rollback(tx *sql.Tx) error {
+func (sc *StoreContext) Savepoint(ctx context.Context, tx *sql.Tx, name string) error {
 	if tx == nil {
 		return errors.New("transaction is nil")
 	}
-	return tx.Rollback()
+	_, err := sc.conn(ctx, tx).Exec(fmt.Sprintf(`SAVEPOINT "%s";`, name))
+	return err
 }
 
-func (sc *StoreContext) GetCategory(tx *sql.Tx, id int) (*model.Category, error) {
-	var query = "SELECT id, name FROM category WHERE id= $1;"
-	var row *sql.Row
+func (sc *StoreContext) Release(ctx context.Context, tx *sql.Tx, name string) error {
+	if tx == nil {
+		return errors.New("transaction is nil")
+	}
+	_, err := sc.conn(ctx, tx).Exec(fmt.Sprintf(`RELEASE "%s";`, name))
+	return err
+}
 
-	if tx != nil {
-		row = tx.QueryRow(query, id)
-	} else {
-		row = sc.db.QueryRow(query, id)
+func (sc *StoreContext) RollbackTo(ctx context.Context, tx *sql.Tx, name string) error {
+	if tx == nil {
+		return errors.New("transaction is nil")
 	}
+	// ROLLBACK TO keeps the savepoint open
+	_, err := sc.conn(ctx, tx).Exec(fmt.Sprintf(`ROLLBACK TO "%s"; RELEASE "%s";`, name, name))
+	return err
+}
+
+func (sc *StoreContext) GetCategory(ctx context.Context, tx *sql.Tx, id int) (*model.Category, error) {
+	var query = "SELECT id, name, COALESCE(slug, ''), tax_class FROM category WHERE id= $1;"
+	var row *sql.Row
+
+	row = sc.conn(ctx, tx).QueryRow(query, id)
 	category := &model.Category{}
-	if err := row.Scan(&category.Id, &category.Name); err != nil {
+	if err := row.Scan(&category.Id, &category.Name, &category.Slug, &category.TaxClass); err != nil {
 		if err != sql.ErrNoRows {
 			return nil, err
 		} else {
@@ -133,15 +632,11 @@ func (sc *StoreContext) GetCategory(tx *sql.Tx, id int) (*model.Category, error)
 	return category, nil
 }
 
-func (sc *StoreContext) GetCategories(tx *sql.Tx) ([]*model.Category, error) {
-	query := "SELECT id, name FROM category;"
+func (sc *StoreContext) GetCategories(ctx context.Context, tx *sql.Tx) ([]*model.Category, error) {
+	query := "SELECT id, name, COALESCE(slug, ''), tax_class FROM category;"
 	var rows *sql.Rows
 	var err error
-	if tx != nil {
-		rows, err = tx.Query(query)
-	} else {
-		rows, err = sc.db.Query(query)
-	}
+	rows, err = sc.conn(ctx, tx).Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +644,7 @@ func (sc *StoreContext) GetCategories(tx *sql.Tx) ([]*model.Category, error) {
 	var categories []*model.Category
 	for rows.Next() {
 		category := &model.Category{}
-		if err := rows.Scan(&category.Id, &category.Name); err != nil {
+		if err := rows.Scan(&category.Id, &category.Name, &category.Slug, &category.TaxClass); err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
@@ -157,32 +652,28 @@ func (sc *StoreContext) GetCategories(tx *sql.Tx) ([]*model.Category, error) {
 	return categories, nil
 }
 
-func (sc *StoreContext) CreateCategory(tx *sql.Tx, category *model.Category) (*int, error) {
-	var query = "INSERT INTO category(name) VALUES($1) RETURNING id;"
+func (sc *StoreContext) CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) (*int, error) {
+	var query = "INSERT INTO category(name, slug, tax_class) VALUES($1, NULLIF($2, ''), $3) RETURNING id;"
 	var id int
 	var err error
-	if tx != nil {
-		err = tx.QueryRow(query, category.Name).Scan(&id)
-	} else {
-		err = sc.db.QueryRow(query, category.Name).Scan(&id)
-	}
+	err = sc.conn(ctx, tx).QueryRow(query, category.Name, category.Slug, category.TaxClass).Scan(&id)
 	if err != nil {
-		return nil, err
+		return nil, sc.conflict(ctx, tx, err, "category", 0, categoryKeys(category))
 	}
 	return &id, nil
 }
 
-func (sc *StoreContext) UpdateCategory(tx *sql.Tx, category *model.Category) error {
-	query := "UPDATE category SET name =$1 WHERE id = $2;"
+func (sc *StoreContext) UpdateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error {
+	// An empty slug keeps the current one
+	query := "UPDATE category SET name =$1, slug = COALESCE(NULLIF($2, ''), slug), tax_class = $3 WHERE id = $4;"
+	if err := sc.retireSlug(ctx, tx, model.SlugEntityCategory, category.Id, category.Slug); err != nil {
+		return err
+	}
 	var res sql.Result
 	var err error
-	if tx != nil {
-		res, err = tx.Exec(query, category.Name, category.Id)
-	} else {
-		res, err = sc.db.Exec(query, category.Name, category.Id)
-	}
+	res, err = sc.conn(ctx, tx).Exec(query, category.Name, category.Slug, category.TaxClass, category.Id)
 	if err != nil {
-		return err
+		return sc.conflict(ctx, tx, err, "category", category.Id, categoryKeys(category))
 	}
 	if a, err := res.RowsAffected(); err != nil {
 		return err
@@ -192,15 +683,11 @@ func (sc *StoreContext) UpdateCategory(tx *sql.Tx, category *model.Category) err
 	return nil
 }
 
-func (sc *StoreContext) DeleteCategory(tx *sql.Tx, id int) error {
+func (sc *StoreContext) DeleteCategory(ctx context.Context, tx *sql.Tx, id int) error {
 	query := "DELETE FROM category WHERE id = $1;"
 	var res sql.Result
 	var err error
-	if tx != nil {
-		res, err = tx.Exec(query, id)
-	} else {
-		res, err = sc.db.Exec(query, id)
-	}
+	res, err = sc.conn(ctx, tx).Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -209,19 +696,42 @@ func (sc *StoreContext) DeleteCategory(tx *sql.Tx, id int) error {
 	} else if a == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if err = sc.forgetPromotionTarget(ctx, tx, model.PromotionScopeCategory, id); err != nil {
+		return err
+	}
+	return sc.forgetSlugs(ctx, tx, model.SlugEntityCategory, id)
 }
 
-func (sc *StoreContext) GetProduct(tx *sql.Tx, id int) (*model.Product, error) {
-	var query = "SELECT id, name, description, category, price FROM product WHERE id= $1;"
-	var row *sql.Row
-	if tx != nil {
-		row = tx.QueryRow(query, id)
-	} else {
-		row = sc.db.QueryRow(query, id)
+func (sc *StoreContext) CountCategories(ctx context.Context, tx *sql.Tx) (int, error) {
+	var count int
+	err := sc.conn(ctx, tx).QueryRow("SELECT COUNT(*) FROM category;").Scan(&count)
+	return count, err
+}
+
+func (sc *StoreContext) CountProducts(ctx context.Context, tx *sql.Tx) (map[string]int, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT status, COUNT(*) FROM product GROUP BY status;")
+	if err != nil {
+		return nil, err
 	}
-	product := &model.Product{}
-	if err := row.Scan(&product.Id, &product.Name, &product.Description, &product.Category, &product.Price); err != nil {
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err = rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+func (sc *StoreContext) GetProduct(ctx context.Context, tx *sql.Tx, id int) (*model.Product, error) {
+	var query = "SELECT " + productColumns + " FROM product WHERE id= $1;"
+	var row *sql.Row
+	row = sc.conn(ctx, tx).QueryRow(query, id)
+	product, err := scanProduct(row)
+	if err != nil {
 		if err != sql.ErrNoRows {
 			return nil, err
 		} else {
@@ -231,25 +741,98 @@ func (sc *StoreContext) GetProduct(tx *sql.Tx, id int) (*model.Product, error) {
 	return product, nil
 }
 
-func (sc *StoreContext) GetProducts(tx *sql.Tx, category *int) ([]*model.Product, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
-	if category == nil {
-		query = "SELECT id, name, description, category, price FROM product;"
-		if tx != nil {
-			rows, err = tx.Query(query)
+// idList lists the ids as the placeholders of an IN clause and their arguments
+func idList(ids []int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// productConditions translates a filter into a WHERE clause and its arguments
+func productConditions(filter *model.ProductFilter) (string, []interface{}) {
+	if filter == nil {
+		return "", nil
+	}
+	var conditions []string
+	var args []interface{}
+	if filter.Ids != nil {
+		placeholders := make([]string, len(filter.Ids))
+		for i, id := range filter.Ids {
+			args = append(args, id)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "product.id IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.Category != nil {
+		args = append(args, *filter.Category)
+		conditions = append(conditions, fmt.Sprintf("product.category = $%d", len(args)))
+	}
+	if filter.Brand != nil {
+		args = append(args, *filter.Brand)
+		conditions = append(conditions, fmt.Sprintf("product.brand = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "product.status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	for _, bound := range []struct {
+		column string
+		max    *float64
+	}{{"weight", filter.WeightMax}, {"length", filter.LengthMax}, {"width", filter.WidthMax}, {"height", filter.HeightMax}} {
+		if bound.max != nil {
+			args = append(args, *bound.max)
+			conditions = append(conditions, fmt.Sprintf("product.%s <= $%d", bound.column, len(args)))
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (sc *StoreContext) GetProductByBarcode(ctx context.Context, tx *sql.Tx, code string) (*model.Product, error) {
+	query := "SELECT " + productColumns + " FROM product WHERE " + barcodeKey + " = $1;"
+	product, err := scanProduct(sc.conn(ctx, tx).QueryRow(query, model.GTIN14(code)))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
 		} else {
-			rows, err = sc.db.Query(query)
+			return nil, nil
 		}
-	} else {
-		query = "SELECT id, name, description, category, price FROM product WHERE category= $1;"
-		if tx != nil {
-			rows, err = tx.Query(query, category)
+	}
+	return product, nil
+}
+
+func (sc *StoreContext) GetProductBySKU(ctx context.Context, tx *sql.Tx, sku string) (*model.Product, error) {
+	product, err := scanProduct(sc.conn(ctx, tx).QueryRow("SELECT "+productColumns+" FROM product WHERE sku = $1;", sku))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
 		} else {
-			rows, err = sc.db.Query(query, category)
+			return nil, nil
 		}
 	}
+	return product, nil
+}
+
+func (sc *StoreContext) GetProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.Product, error) {
+	where, args := productConditions(filter)
+	query := "SELECT " + productColumns + " FROM product" + where
+	if filter != nil && filter.Sort != "" {
+		query += " ORDER BY " + productOrder(filter.Sort)
+	}
+	query += ";"
+	var rows *sql.Rows
+	var err error
+	rows, err = sc.conn(ctx, tx).Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -257,8 +840,8 @@ func (sc *StoreContext) GetProducts(tx *sql.Tx, category *int) ([]*model.Product
 	var products []*model.Product
 
 	for rows.Next() {
-		product := &model.Product{}
-		if err := rows.Scan(&product.Id, &product.Name, &product.Description, &product.Category, &product.Price); err != nil {
+		product, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
 		products = append(products, product)
@@ -266,50 +849,66 @@ func (sc *StoreContext) GetProducts(tx *sql.Tx, category *int) ([]*model.Product
 	return products, nil
 }
 
-func (sc *StoreContext) CreateProduct(tx *sql.Tx, product *model.Product) (*int, error) {
-	var query = "INSERT INTO product( name, description, category, price) VALUES($1, $2, $3, $4) RETURNING id;"
+func (sc *StoreContext) CreateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) (*int, error) {
+	var query = `INSERT INTO product( name, slug, description, category, price, status, publish_at, unpublish_at, tax_class,
+		type, stock, bundle_pricing, bundle_discount, brand, barcode, weight, length, width, height, sku)
+		VALUES($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), $16, $17, $18, $19,
+			NULLIF($20, ''))
+		RETURNING id;`
+	args := []interface{}{product.Name, product.Slug, product.Description, product.Category, product.Price,
+		product.Status, utc(product.PublishAt), utc(product.UnpublishAt), product.TaxClass,
+		product.Type, product.Stock, product.BundlePricing, product.BundleDiscount, product.Brand, product.Barcode,
+		product.Weight, product.Length, product.Width, product.Height, product.SKU}
 	var id int
 	var err error
-	if tx != nil {
-		err = tx.QueryRow(query, product.Name, product.Description, product.Category, product.Price).Scan(&id)
-	} else {
-		err = sc.db.QueryRow(query, product.Name, product.Description, product.Category, product.Price).Scan(&id)
-	}
+	err = sc.conn(ctx, tx).QueryRow(query, args...).Scan(&id)
 	if err != nil {
+		return nil, sc.conflict(ctx, tx, err, "product", 0, productKeys(product))
+	}
+	if err = sc.setProductTags(ctx, tx, id, product.Tags); err != nil {
+		return nil, err
+	}
+	if err = sc.setProductComponents(ctx, tx, id, product.Components); err != nil {
 		return nil, err
 	}
 	return &id, nil
 }
 
-func (sc *StoreContext) UpdateProduct(tx *sql.Tx, product *model.Product) error {
-	query := "UPDATE product SET name=$1, description=$2, category=$3, price=$4  WHERE id = $5;"
+func (sc *StoreContext) UpdateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) error {
+	// An empty status, type or slug keeps the current one
+	query := `UPDATE product SET name=$1, description=$2, category=$3, price=$4, status=COALESCE(NULLIF($5, ''), status),
+		publish_at=$6, unpublish_at=$7, tax_class=$8, type=COALESCE(NULLIF($9, ''), type), stock=$10,
+		bundle_pricing=$11, bundle_discount=$12, brand=$13, slug=COALESCE(NULLIF($14, ''), slug), barcode=NULLIF($15, ''),
+		weight=$16, length=$17, width=$18, height=$19, sku=NULLIF($20, '') WHERE id = $21;`
+	args := []interface{}{product.Name, product.Description, product.Category, product.Price,
+		product.Status, utc(product.PublishAt), utc(product.UnpublishAt), product.TaxClass,
+		product.Type, product.Stock, product.BundlePricing, product.BundleDiscount, product.Brand, product.Slug,
+		product.Barcode, product.Weight, product.Length, product.Width, product.Height, product.SKU, product.Id}
+	if err := sc.retireSlug(ctx, tx, model.SlugEntityProduct, product.Id, product.Slug); err != nil {
+		return err
+	}
 	var res sql.Result
 	var err error
-	if tx != nil {
-		res, err = tx.Exec(query, product.Name, product.Description, product.Category, product.Price, product.Id)
-	} else {
-		res, err = sc.db.Exec(query, product.Name, product.Description, product.Category, product.Price, product.Id)
-	}
+	res, err = sc.conn(ctx, tx).Exec(query, args...)
 	if err != nil {
-		return err
+		return sc.conflict(ctx, tx, err, "product", product.Id, productKeys(product))
 	}
 	if a, err := res.RowsAffected(); err != nil {
 		return err
 	} else if a == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if err = sc.setProductTags(ctx, tx, product.Id, product.Tags); err != nil {
+		return err
+	}
+	return sc.setProductComponents(ctx, tx, product.Id, product.Components)
 }
 
-func (sc *StoreContext) DeleteProduct(tx *sql.Tx, id int) error {
+func (sc *StoreContext) DeleteProduct(ctx context.Context, tx *sql.Tx, id int) error {
 	query := "DELETE FROM product WHERE id = $1;"
 	var res sql.Result
 	var err error
-	if tx != nil {
-		res, err = tx.Exec(query, id)
-	} else {
-		res, err = sc.db.Exec(query, id)
-	}
+	res, err = sc.conn(ctx, tx).Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -318,5 +917,94 @@ func (sc *StoreContext) DeleteProduct(tx *sql.Tx, id int) error {
 	} else if a == 0 {
 		return sql.ErrNoRows
 	}
+	if _, err = sc.conn(ctx, tx).Exec("DELETE FROM product_tag WHERE product_id = $1;", id); err != nil {
+		return err
+	}
+	if _, err = sc.conn(ctx, tx).Exec("DELETE FROM review WHERE product_id = $1;", id); err != nil {
+		return err
+	}
+	if err = sc.forgetSlugs(ctx, tx, model.SlugEntityProduct, id); err != nil {
+		return err
+	}
+	if err = sc.forgetPromotionTarget(ctx, tx, model.PromotionScopeProduct, id); err != nil {
+		return err
+	}
+	if err = sc.SetProductSuppliers(ctx, tx, id, nil); err != nil {
+		return err
+	}
+	// Links pointing to the product are dropped along with its own
+	if _, err = sc.conn(ctx, tx).Exec("DELETE FROM product_relation WHERE product_id = $1 OR related_id = $1;", id); err != nil {
+		return err
+	}
+	return sc.setProductComponents(ctx, tx, id, nil)
+}
+
+func (sc *StoreContext) GetBundlesContaining(ctx context.Context, tx *sql.Tx, id int) ([]int, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT bundle_id FROM product_component WHERE product_id = $1 ORDER BY bundle_id;", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bundles []int
+	for rows.Next() {
+		var bundle int
+		if err := rows.Scan(&bundle); err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, rows.Err()
+}
+
+func (sc *StoreContext) ApplyPublicationSchedule(ctx context.Context, tx *sql.Tx, now time.Time) (int64, int64, error) {
+	publish := "UPDATE product SET status = $1, publish_at = NULL WHERE status = $2 AND publish_at <= $3;"
+	archive := "UPDATE product SET status = $1, unpublish_at = NULL WHERE status = $2 AND unpublish_at <= $3;"
+	exec := sc.conn(ctx, tx).Exec
+	now = now.UTC()
+	res, err := exec(publish, model.ProductStatusPublished, model.ProductStatusDraft, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	published, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err = exec(archive, model.ProductStatusArchived, model.ProductStatusPublished, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	archived, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	return published, archived, nil
+}
+
+// setProductTags replaces the tags of a product
+func (sc *StoreContext) setProductTags(ctx context.Context, tx *sql.Tx, id int, tags []string) error {
+	conn := sc.conn(ctx, tx)
+	if _, err := conn.Exec("DELETE FROM product_tag WHERE product_id = $1;", id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := conn.Exec("INSERT OR IGNORE INTO product_tag(product_id, tag) VALUES($1, $2);", id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setProductComponents replaces the components of a bundle
+func (sc *StoreContext) setProductComponents(ctx context.Context, tx *sql.Tx, id int, components []model.BundleComponent) error {
+	conn := sc.conn(ctx, tx)
+	if _, err := conn.Exec("DELETE FROM product_component WHERE bundle_id = $1;", id); err != nil {
+		return err
+	}
+	for _, component := range components {
+		query := "INSERT INTO product_component(bundle_id, product_id, quantity) VALUES($1, $2, $3);"
+		if _, err := conn.Exec(query, id, component.Product, component.Quantity); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetSupplier(ctx context.Context, tx *sql.Tx, id int) (*model.Supplier, error) {
+	supplier := &model.Supplier{}
+	err := sc.conn(ctx, tx).QueryRow("SELECT id, name, email FROM supplier WHERE id = $1;", id).
+		Scan(&supplier.Id, &supplier.Name, &supplier.Email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	return supplier, nil
+}
+
+func (sc *StoreContext) GetSuppliers(ctx context.Context, tx *sql.Tx) ([]*model.Supplier, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT id, name, email FROM supplier;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var suppliers []*model.Supplier
+	for rows.Next() {
+		supplier := &model.Supplier{}
+		if err := rows.Scan(&supplier.Id, &supplier.Name, &supplier.Email); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers, rows.Err()
+}
+
+func (sc *StoreContext) CreateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) (*int, error) {
+	var id int
+	query := "INSERT INTO supplier(name, email) VALUES($1, $2) RETURNING id;"
+	if err := sc.conn(ctx, tx).QueryRow(query, supplier.Name, supplier.Email).Scan(&id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (sc *StoreContext) UpdateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) error {
+	res, err := sc.conn(ctx, tx).Exec("UPDATE supplier SET name = $1, email = $2 WHERE id = $3;",
+		supplier.Name, supplier.Email, supplier.Id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (sc *StoreContext) DeleteSupplier(ctx context.Context, tx *sql.Tx, id int) error {
+	conn := sc.conn(ctx, tx)
+	res, err := conn.Exec("DELETE FROM supplier WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = conn.Exec("DELETE FROM product_supplier WHERE supplier_id = $1;", id)
+	return err
+}
+
+func (sc *StoreContext) GetProductSuppliers(ctx context.Context, tx *sql.Tx, id int) ([]model.ProductSupplier, error) {
+	query := `SELECT supplier_id, sku, cost_price, preferred FROM product_supplier
+		WHERE product_id = $1 ORDER BY preferred DESC, supplier_id;`
+	rows, err := sc.conn(ctx, tx).Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var suppliers []model.ProductSupplier
+	for rows.Next() {
+		var supplier model.ProductSupplier
+		if err := rows.Scan(&supplier.Supplier, &supplier.SKU, &supplier.CostPrice, &supplier.Preferred); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers, rows.Err()
+}
+
+func (sc *StoreContext) SetProductSuppliers(ctx context.Context, tx *sql.Tx, id int, suppliers []model.ProductSupplier) error {
+	conn := sc.conn(ctx, tx)
+	if _, err := conn.Exec("DELETE FROM product_supplier WHERE product_id = $1;", id); err != nil {
+		return err
+	}
+	query := `INSERT INTO product_supplier(product_id, supplier_id, sku, cost_price, preferred)
+		VALUES($1, $2, $3, $4, $5);`
+	for _, supplier := range suppliers {
+		if _, err := conn.Exec(query, id, supplier.Supplier, supplier.SKU, supplier.CostPrice, supplier.Preferred); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+func (sc *StoreContext) GetTaxClass(ctx context.Context, tx *sql.Tx, id int) (*model.TaxClass, error) {
+	taxClass := &model.TaxClass{}
+	err := sc.conn(ctx, tx).QueryRow("SELECT id, name FROM tax_class WHERE id = $1;", id).Scan(&taxClass.Id, &taxClass.Name)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	}
+	if err = sc.loadTaxRates(ctx, tx, []*model.TaxClass{taxClass}); err != nil {
+		return nil, err
+	}
+	return taxClass, nil
+}
+
+func (sc *StoreContext) GetTaxClasses(ctx context.Context, tx *sql.Tx) ([]*model.TaxClass, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT id, name FROM tax_class;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var taxClasses []*model.TaxClass
+	for rows.Next() {
+		taxClass := &model.TaxClass{}
+		if err := rows.Scan(&taxClass.Id, &taxClass.Name); err != nil {
+			return nil, err
+		}
+		taxClasses = append(taxClasses, taxClass)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err = sc.loadTaxRates(ctx, tx, taxClasses); err != nil {
+		return nil, err
+	}
+	return taxClasses, nil
+}
+
+// loadTaxRates fills in the regional rates of the tax classes
+func (sc *StoreContext) loadTaxRates(ctx context.Context, tx *sql.Tx, taxClasses []*model.TaxClass) error {
+	if len(taxClasses) == 0 {
+		return nil
+	}
+	byId := make(map[int]*model.TaxClass, len(taxClasses))
+	ids := make([]int, len(taxClasses))
+	for i, taxClass := range taxClasses {
+		taxClass.Rates = map[string]float64{}
+		byId[taxClass.Id] = taxClass
+		ids[i] = taxClass.Id
+	}
+	in, args := idList(ids)
+	rows, err := sc.conn(ctx, tx).Query("SELECT tax_class, region, rate FROM tax_rate WHERE tax_class IN ("+in+");", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var region string
+		var rate float64
+		if err := rows.Scan(&id, &region, &rate); err != nil {
+			return err
+		}
+		byId[id].Rates[region] = rate
+	}
+	return rows.Err()
+}
+
+// setTaxRates replaces the regional rates of a tax class
+func (sc *StoreContext) setTaxRates(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) error {
+	conn := sc.conn(ctx, tx)
+	if _, err := conn.Exec("DELETE FROM tax_rate WHERE tax_class = $1;", taxClass.Id); err != nil {
+		return err
+	}
+	for region, rate := range taxClass.Rates {
+		if _, err := conn.Exec("INSERT INTO tax_rate(tax_class, region, rate) VALUES($1, $2, $3);", taxClass.Id, region, rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StoreContext) CreateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) (*int, error) {
+	var id int
+	if err := sc.conn(ctx, tx).QueryRow("INSERT INTO tax_class(name) VALUES($1) RETURNING id;", taxClass.Name).Scan(&id); err != nil {
+		return nil, sc.conflict(ctx, tx, err, "tax_class", 0, taxClassKeys(taxClass))
+	}
+	taxClass.Id = id
+	if err := sc.setTaxRates(ctx, tx, taxClass); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (sc *StoreContext) UpdateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) error {
+	res, err := sc.conn(ctx, tx).Exec("UPDATE tax_class SET name = $1 WHERE id = $2;", taxClass.Name, taxClass.Id)
+	if err != nil {
+		return sc.conflict(ctx, tx, err, "tax_class", taxClass.Id, taxClassKeys(taxClass))
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	return sc.setTaxRates(ctx, tx, taxClass)
+}
+
+func (sc *StoreContext) DeleteTaxClass(ctx context.Context, tx *sql.Tx, id int) error {
+	conn := sc.conn(ctx, tx)
+	res, err := conn.Exec("DELETE FROM tax_class WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+	if a, err := res.RowsAffected(); err != nil {
+		return err
+	} else if a == 0 {
+		return sql.ErrNoRows
+	}
+	for _, query := range []string{
+		"DELETE FROM tax_rate WHERE tax_class = $1;",
+		"UPDATE product SET tax_class = NULL WHERE tax_class = $1;",
+		"UPDATE category SET tax_class = NULL WHERE tax_class = $1;",
+	} {
+		if _, err = conn.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StoreContext) GetTaxRates(ctx context.Context, tx *sql.Tx, region string) (map[int]float64, error) {
+	rows, err := sc.conn(ctx, tx).Query("SELECT tax_class, rate FROM tax_rate WHERE region = $1;", region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rates map[int]float64
+	for rows.Next() {
+		var id int
+		var rate float64
+		if err := rows.Scan(&id, &rate); err != nil {
+			return nil, err
+		}
+		if rates == nil {
+			rates = map[int]float64{}
+		}
+		rates[id] = rate
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,23 @@
+// Package logging carries the logger of a request through the layers handling it
+package logging
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// WithEntry returns a copy of `ctx` logging through `entry`
+func WithEntry(ctx context.Context, entry *log.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// Entry returns the logger of `ctx`, the standard logger when it has none
+func Entry(ctx context.Context) *log.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*log.Entry); ok {
+		return entry
+	}
+	return log.NewEntry(log.StandardLogger())
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mrlightwood/golang-products-api/api"
 	"github.com/mrlightwood/golang-products-api/config"
 	"github.com/mrlightwood/golang-products-api/db"
 	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
 )
 
 func main() {
@@ -34,13 +40,71 @@ func main() {
 	defer store.Close()
 	log.Info("Store created successfully")
 
+	// Metrics of the runtime, the store and the requests
+	metrics := prometheus.NewRegistry()
+	metrics.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if store, err = db.Measure(store, metrics); err != nil {
+		log.Fatal(err)
+	}
+
+	// Spans go nowhere until a provider is set
+	tp, err := tracing.NewProvider(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if tp != nil {
+		otel.SetTracerProvider(tp)
+		defer tp.Shutdown(context.Background())
+	}
+
 	// Initialization of services
-	cs := service.NewCategoryService(store)
-	ps := service.NewProductService(store)
+	cs := service.TraceCategoryService(service.NewCategoryService(store))
+	bs := service.NewBrandService(store)
+	ps := service.TraceProductService(service.NewProductService(store))
+	prs := service.NewPromotionService(store)
+	tcs := service.NewTaxClassService(store)
+	rs := service.NewReviewService(store)
+	ss := service.NewSupplierService(store)
+	rps := service.NewReportService(store)
+	bas := service.NewBatchService(store)
+	ims := service.NewImportService(store)
+	aks := service.NewApiKeyService(store)
+	is := service.NewIdempotencyService(store, time.Duration(conf.Api.IdempotencyExpiry)*time.Second,
+		time.Duration(conf.Api.IdempotencyLease)*time.Second)
+	// Tokens of the identity provider are accepted once a key verifies them
+	var ts service.TokenService
+	if len(conf.Jwt.Secrets) > 0 || conf.Jwt.JwksFile != "" {
+		if ts, err = service.NewTokenService(conf); err != nil {
+			log.Fatal(err)
+		}
+	}
 	log.Info("Services created successfully")
 
+	// Clients authenticate with API keys, the editor token would bypass their roles
+	if conf.Api.EditorToken != "" {
+		log.Warn("Ignoring api.editortoken, clients authenticate with API keys and tokens")
+	}
+
+	// Without any key the API couldn't be used at all
+	bootstrap, err := aks.Bootstrap(context.Background(), conf.Api.BootstrapKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if bootstrap != nil && conf.Api.BootstrapKey == "" {
+		log.WithField("key", bootstrap.Key).Warn("Created the admin API key, it won't be shown again")
+	} else if bootstrap != nil {
+		log.WithField("prefix", bootstrap.Prefix).Info("Created the admin API key from the configuration")
+	}
+
+	// Background publication of scheduled products
+	scheduler := service.NewPublicationScheduler(store, time.Duration(conf.Scheduler.Interval)*time.Second)
+	scheduler.Start()
+	defer scheduler.Stop()
+
 	// Initialization of an API
-	api := api.NewApi(conf, cs, ps)
+	api := api.NewApi(conf, api.Services{Categories: cs, Brands: bs, Products: ps, Promotions: prs, TaxClasses: tcs, Reviews: rs,
+		Suppliers: ss, Reports: rps, Batches: bas, Imports: ims, ApiKeys: aks, Tokens: ts, Idempotency: is,
+		Metrics: metrics})
 	log.WithField("address", api.GetApiInfo().Address).
 		WithField("mw", api.GetApiInfo().MW).
 		WithField("routes", api.GetApiInfo().Routes).
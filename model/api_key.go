@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// API key scopes, each one grants the ones before it
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// scopeRoles are the roles of the clients holding a scope
+var scopeRoles = map[string]string{ScopeRead: RoleViewer, ScopeWrite: RoleEditor, ScopeAdmin: RoleAdmin}
+
+// ApiKey authenticates a client of the API. Only a hash of the key is
+// stored, the key itself is shown once when it is created or rotated.
+type ApiKey struct {
+	Id   int    `json:"id"`
+	Name string `json:"name" validate:"required,max=100"`
+	// First characters of the key, telling keys apart
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes" validate:"required,unique,dive,oneof=read write admin"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	// SHA-256 of the key, hex encoded
+	Hash string `json:"-"`
+}
+
+// Role is the role granted by the scopes of the key
+func (k *ApiKey) Role() string {
+	roles := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		roles[i] = scopeRoles[scope]
+	}
+	return HighestRole(roles...)
+}
+
+// Expired reports whether the key is past its expiry at `now`
+func (k *ApiKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IssuedApiKey is an API key along with the key itself, answered once
+type IssuedApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "strings"
+
+// ValidGTIN reports whether the code is a GTIN-8, GTIN-12 (UPC-A),
+// GTIN-13 (EAN-13) or GTIN-14 with a correct check digit
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	sum := 0
+	for i := len(code) - 1; i >= 0; i-- {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		// Weights alternate 1 and 3 from the check digit leftwards
+		if (len(code)-1-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// GTIN14 pads a code with leading zeros, so that the same item scanned
+// as UPC-A or EAN-13 compares equal
+func GTIN14(code string) string {
+	if len(code) >= 14 {
+		return code
+	}
+	return strings.Repeat("0", 14-len(code)) + code
+}
@@ -0,0 +1,60 @@
+package model
+
+import "encoding/json"
+
+// Batch operations
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// Batch entities
+const (
+	BatchEntityProduct  = "product"
+	BatchEntityCategory = "category"
+)
+
+// Batch modes: everything or nothing is written, or failed operations are
+// skipped and the others written
+const (
+	BatchModeAtomic   = "atomic"
+	BatchModeContinue = "continue"
+)
+
+// Batch is an ordered list of operations run in one transaction
+type Batch struct {
+	Mode       string           `json:"mode" validate:"omitempty,oneof=atomic continue"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=10000,dive"`
+}
+
+// BatchOperation creates, updates or deletes a product or category.
+// Objects {"$ref": name} in the id or data stand for the id created by an
+// earlier operation of the batch under that name.
+type BatchOperation struct {
+	Op     string `json:"op" validate:"required,oneof=create update delete"`
+	Entity string `json:"entity" validate:"required,oneof=product category"`
+	// Name later operations refer to the created id by
+	Ref string `json:"ref"`
+	// Target of updates and deletes
+	Id json.RawMessage `json:"id"`
+	// Product or category of creates and updates
+	Data json.RawMessage `json:"data"`
+}
+
+// BatchResult is the outcome of one operation, in the order of the batch
+type BatchResult struct {
+	Index int `json:"index"`
+	// HTTP status the operation would have had on its own
+	Status int    `json:"status"`
+	Id     *int   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Error of the operation, translated into Status and Error by the API
+	Err error `json:"-"`
+}
+
+// BatchResponse tells whether the batch was committed and how each operation went
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
@@ -0,0 +1,21 @@
+package model
+
+type Brand struct {
+	Id   int    `json:"id"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// BrandFacet is the number of listed products made by a brand
+type BrandFacet struct {
+	Brand int    `json:"brand"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ProductListing is a page of products along with the facets of the listing
+type ProductListing struct {
+	Products []*Product `json:"products"`
+	Facets   struct {
+		Brands []*BrandFacet `json:"brands"`
+	} `json:"facets"`
+}
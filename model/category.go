@@ -1,6 +1,11 @@
 package model
 
 type Category struct {
-	Id   int    `json:"id"`
+	Id int `json:"id"`
+	// Unique regardless of case
 	Name string `json:"name" validate:"required,min=3"`
+	// Unique URL name, generated from the name when empty
+	Slug string `json:"slug" validate:"omitempty,max=100,slug"`
+	// Tax class inherited by products without their own
+	TaxClass *int `json:"tax_class"`
 }
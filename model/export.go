@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Formats of catalog exports
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// ExportColumns names the values of ExportedProduct.Values, the same columns
+// the product import reads
+var ExportColumns = []string{"id", "sku", "name", "slug", "description", "category", "category_name", "brand", "barcode",
+	"price", "status", "tags", "tax_class", "stock", "weight", "length", "width", "height", "publish_at", "unpublish_at"}
+
+// ExportedProduct is a row of a catalog export: the stored fields of a
+// product, without prices computed from promotions or taxes, and the name of
+// its category. Derived bundles export the price computed from their
+// components, their stored one is meaningless.
+type ExportedProduct struct {
+	Id           int        `json:"id"`
+	SKU          string     `json:"sku"`
+	Name         string     `json:"name"`
+	Slug         string     `json:"slug"`
+	Description  string     `json:"description"`
+	Category     int        `json:"category"`
+	CategoryName string     `json:"category_name"`
+	Brand        *int       `json:"brand"`
+	Barcode      string     `json:"barcode"`
+	Price        float64    `json:"price"`
+	Status       string     `json:"status"`
+	Tags         []string   `json:"tags"`
+	TaxClass     *int       `json:"tax_class"`
+	Stock        int        `json:"stock"`
+	Weight       *float64   `json:"weight"`
+	Length       *float64   `json:"length"`
+	Width        *float64   `json:"width"`
+	Height       *float64   `json:"height"`
+	PublishAt    *time.Time `json:"publish_at"`
+	UnpublishAt  *time.Time `json:"unpublish_at"`
+	// BundlePricing tells which rows need their price derived, it isn't exported
+	BundlePricing string `json:"-"`
+}
+
+// Values lists the fields in ExportColumns order: ints, float64s, strings
+// and times, nil for missing ones, tags separated by "|"
+func (p *ExportedProduct) Values() []interface{} {
+	return []interface{}{p.Id, p.SKU, p.Name, p.Slug, p.Description, p.Category, p.CategoryName, optional(p.Brand), p.Barcode,
+		p.Price, p.Status, strings.Join(p.Tags, "|"), optional(p.TaxClass), p.Stock, optional(p.Weight), optional(p.Length),
+		optional(p.Width), optional(p.Height), optional(p.PublishAt), optional(p.UnpublishAt)}
+}
+
+// optional dereferences the pointer, nil pointers become untyped nil
+func optional(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *int:
+		if v != nil {
+			return *v
+		}
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	}
+	return nil
+}
+
+// ToUnits converts metric weights and dimensions to `units`
+func (p *ExportedProduct) ToUnits(units string) {
+	if units != UnitsImperial {
+		return
+	}
+	scale(p.Weight, 1/kilogramsPerPound)
+	for _, length := range []*float64{p.Length, p.Width, p.Height} {
+		scale(length, 1/metersPerInch)
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a key sent by a client with a request so that its
+// retries get the response of the first request instead of running again
+type IdempotencyKey struct {
+	Key string
+	// Hash of the request first sent with the key
+	Fingerprint string
+	CreatedAt   time.Time
+	// Response of the first request, nil while it runs
+	Response *IdempotentResponse
+}
+
+// IdempotentResponse is the response replayed to the retries of a request
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
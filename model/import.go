@@ -0,0 +1,29 @@
+package model
+
+// Outcomes of the rows of an import
+const (
+	ImportStatusCreated   = "created"
+	ImportStatusUpdated   = "updated"
+	ImportStatusUnchanged = "unchanged"
+	ImportStatusError     = "error"
+)
+
+// ImportRow is the outcome of one row of an import
+type ImportRow struct {
+	// Line of the row in the file, the header is line 1
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	// Product created or updated, unknown for rows created by a dry run
+	Id      *int   `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ImportReport tells what an import did, or would do on a dry run
+type ImportReport struct {
+	DryRun    bool        `json:"dry_run"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Unchanged int         `json:"unchanged"`
+	Failed    int         `json:"failed"`
+	Rows      []ImportRow `json:"rows"`
+}
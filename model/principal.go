@@ -0,0 +1,46 @@
+package model
+
+import "strconv"
+
+// Roles of the clients, each one grants the permissions of the ones before it
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleLevels = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// Principal is the client a request was authenticated as
+type Principal struct {
+	// Subject of the token or name of the API key
+	Subject string
+	// Highest role of the client, empty without any
+	Role string
+	// API key the client authenticated with, nil for tokens
+	ApiKey *ApiKey
+}
+
+// Id identifies the principal across requests
+func (p *Principal) Id() string {
+	if p.ApiKey != nil {
+		return "key:" + strconv.Itoa(p.ApiKey.Id)
+	}
+	return "sub:" + p.Subject
+}
+
+// Has reports whether the role of the principal grants `role`
+func (p *Principal) Has(role string) bool {
+	return p.Role != "" && roleLevels[p.Role] >= roleLevels[role]
+}
+
+// HighestRole returns the role granting the most of `roles`, empty when none is known
+func HighestRole(roles ...string) string {
+	highest := ""
+	for _, role := range roles {
+		if roleLevels[role] > roleLevels[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
@@ -1,9 +1,95 @@
 package model
 
+import "time"
+
+// Product types
+const (
+	ProductTypeSimple = "simple"
+	ProductTypeBundle = "bundle"
+)
+
+// Bundle pricing modes: a fixed price, or the sum of the components minus a discount
+const (
+	BundlePricingFixed   = "fixed"
+	BundlePricingDerived = "derived"
+)
+
+// Product publication statuses
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
 type Product struct {
-	Id          int     `json:"id"`
-	Name        string  `json:"name" validate:"required,min=3"`
-	Description string  `json:"description"`
-	Category    int     `json:"category"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
+	Id   int    `json:"id"`
+	Name string `json:"name" validate:"required,min=3"`
+	// Unique URL name, generated from the name when empty
+	Slug string `json:"slug" validate:"omitempty,max=100,slug"`
+	// Stock keeping unit, unique among products
+	SKU         string `json:"sku" validate:"max=100"`
+	Description string `json:"description"`
+	Category    int    `json:"category"`
+	Brand       *int   `json:"brand"`
+	// GTIN-8, 12, 13 or 14, unique among products
+	Barcode     string     `json:"barcode" validate:"omitempty,gtin"`
+	Price       float64    `json:"price" validate:"required_unless=BundlePricing derived,gte=0"`
+	Status      string     `json:"status" validate:"omitempty,oneof=draft published archived"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Tags        []string   `json:"tags" validate:"dive,required,max=50"`
+	// Own tax class, the category one is used when empty
+	TaxClass *int   `json:"tax_class"`
+	Type     string `json:"type" validate:"omitempty,oneof=simple bundle"`
+	// Units in stock, computed from the scarcest component for bundles
+	Stock int `json:"stock" validate:"gte=0"`
+	// Shipping weight and dimensions, kilograms and meters unless other units are requested
+	Weight *float64 `json:"weight" validate:"omitempty,gt=0"`
+	Length *float64 `json:"length" validate:"omitempty,gt=0"`
+	Width  *float64 `json:"width" validate:"omitempty,gt=0"`
+	Height *float64 `json:"height" validate:"omitempty,gt=0"`
+	// Contents and pricing of bundles
+	Components     []BundleComponent `json:"components,omitempty" validate:"dive"`
+	BundlePricing  string            `json:"bundle_pricing,omitempty" validate:"omitempty,oneof=fixed derived"`
+	BundleDiscount float64           `json:"bundle_discount,omitempty" validate:"gte=0,lte=100"`
+	// Price after active promotions, computed by the product service
+	EffectivePrice    float64 `json:"effective_price"`
+	AppliedPromotions []int   `json:"applied_promotions"`
+	// Present when prices are requested for a region
+	Tax *TaxBreakdown `json:"tax,omitempty"`
+	// Cost of the preferred or else cheapest supplier, shown to editors only
+	CostPrice *float64 `json:"cost_price,omitempty"`
+	// Aggregates of the approved reviews, no average without reviews
+	AverageRating *float64 `json:"average_rating"`
+	ReviewCount   int      `json:"review_count"`
+}
+
+// BundleComponent is a product contained in a bundle
+type BundleComponent struct {
+	Product  int `json:"product" validate:"required"`
+	Quantity int `json:"quantity" validate:"required,gt=0"`
 }
+
+// IsBundle reports whether the product is composed of other products
+func (p *Product) IsBundle() bool {
+	return p.Type == ProductTypeBundle
+}
+
+// ProductFilter narrows down the products returned by a listing.
+// Zero values mean "no restriction".
+type ProductFilter struct {
+	Ids      []int
+	Category *int
+	Brand    *int
+	Statuses []string
+	// Upper bounds in kilograms and meters, products lacking the value are left out
+	WeightMax *float64
+	LengthMax *float64
+	WidthMax  *float64
+	HeightMax *float64
+	// Sort key, descending when prefixed with "-"
+	Sort string
+}
+
+// ProductSortKeys are the accepted values of ProductFilter.Sort
+var ProductSortKeys = []string{"name", "price", "average_rating", "review_count"}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Promotion discount types
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+)
+
+// Promotion target scopes
+const (
+	PromotionScopeProduct  = "product"
+	PromotionScopeCategory = "category"
+	PromotionScopeTag      = "tag"
+)
+
+// Promotion is a discount applied to the products it targets while its
+// validity window is open. Promotions are applied by descending priority:
+// a non-stackable promotion applies alone, stackable ones are combined.
+type Promotion struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name" validate:"required,min=3"`
+	Type       string     `json:"type" validate:"required,oneof=percentage fixed"`
+	Value      float64    `json:"value" validate:"required,gt=0"`
+	Products   []int      `json:"products"`
+	Categories []int      `json:"categories"`
+	Tags       []string   `json:"tags" validate:"dive,required"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	Priority   int        `json:"priority"`
+	Stackable  bool       `json:"stackable"`
+}
+
+// Targets reports whether the promotion applies to the product
+func (p *Promotion) Targets(product *Product) bool {
+	for _, id := range p.Products {
+		if id == product.Id {
+			return true
+		}
+	}
+	for _, id := range p.Categories {
+		if id == product.Category {
+			return true
+		}
+	}
+	for _, tag := range p.Tags {
+		for _, t := range product.Tags {
+			if tag == t {
+				return true
+			}
+		}
+	}
+	return false
+}
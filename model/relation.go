@@ -0,0 +1,22 @@
+package model
+
+// Relation types, read from the product the relation is stored on:
+// its accessories, its alternatives, its successors and its up-sell offers
+const (
+	RelationAccessoryOf   = "accessory-of"
+	RelationAlternativeTo = "alternative-to"
+	RelationReplacedBy    = "replaced-by"
+	RelationUpSell        = "up-sell"
+)
+
+// ProductRelation is a typed, directional link to another product
+type ProductRelation struct {
+	Type    string `json:"type" validate:"required,oneof=accessory-of alternative-to replaced-by up-sell"`
+	Product int    `json:"product" validate:"required"`
+}
+
+// RelatedProduct is a product linked by a relation
+type RelatedProduct struct {
+	Type    string   `json:"type"`
+	Product *Product `json:"product"`
+}
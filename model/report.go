@@ -0,0 +1,33 @@
+package model
+
+// ProductMargin is the margin made on a product at its list price
+type ProductMargin struct {
+	Product  int     `json:"product"`
+	Name     string  `json:"name"`
+	Category int     `json:"category"`
+	Price    float64 `json:"price"`
+	Cost     float64 `json:"cost"`
+	Margin   float64 `json:"margin"`
+	// Margin relative to the price, nil for free products
+	MarginPercent *float64 `json:"margin_percent"`
+	// Margin relative to the cost, nil for products costing nothing
+	MarkupPercent *float64 `json:"markup_percent"`
+}
+
+// CategoryMargin sums up the margins of the products of a category,
+// as if one unit of each was sold
+type CategoryMargin struct {
+	Category      int      `json:"category"`
+	Products      int      `json:"products"`
+	Price         float64  `json:"price"`
+	Cost          float64  `json:"cost"`
+	Margin        float64  `json:"margin"`
+	MarginPercent *float64 `json:"margin_percent"`
+	MarkupPercent *float64 `json:"markup_percent"`
+}
+
+// MarginReport lists the margins of the products having a cost price
+type MarginReport struct {
+	Products   []*ProductMargin  `json:"products"`
+	Categories []*CategoryMargin `json:"categories"`
+}
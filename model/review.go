@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Review moderation statuses
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// Review of a product by a customer, published once approved
+type Review struct {
+	Id         int       `json:"id"`
+	Product    int       `json:"product"`
+	Rating     int       `json:"rating" validate:"required,min=1,max=5"`
+	Title      string    `json:"title" validate:"required,max=200"`
+	Body       string    `json:"body" validate:"max=5000"`
+	AuthorName string    `json:"author_name" validate:"required,max=100"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReviewModeration is the decision of a moderator on a review
+type ReviewModeration struct {
+	Status string `json:"status" validate:"required,oneof=pending approved rejected"`
+}
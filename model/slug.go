@@ -0,0 +1,36 @@
+package model
+
+import "strings"
+
+// Entities having slugs, named after their table
+const (
+	SlugEntityProduct  = "product"
+	SlugEntityCategory = "category"
+)
+
+// SlugMaxLength is the longest slug accepted or generated
+const SlugMaxLength = 100
+
+// Slugify derives a slug from a name: lowercase ASCII letters and digits
+// separated by single hyphens. Names without any of them give an empty slug.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			hyphen = true
+			continue
+		}
+		if hyphen && b.Len() > 0 {
+			if b.Len()+2 > SlugMaxLength {
+				break
+			}
+			b.WriteByte('-')
+		} else if b.Len()+1 > SlugMaxLength {
+			break
+		}
+		b.WriteRune(r)
+		hyphen = false
+	}
+	return b.String()
+}
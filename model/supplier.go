@@ -0,0 +1,17 @@
+package model
+
+type Supplier struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// ProductSupplier links a product to a supplier delivering it
+type ProductSupplier struct {
+	Supplier int    `json:"supplier" validate:"required"`
+	SKU      string `json:"sku" validate:"max=100"`
+	// Price paid to the supplier for one unit
+	CostPrice float64 `json:"cost_price" validate:"gte=0"`
+	// The preferred supplier gives the cost price of the product
+	Preferred bool `json:"preferred"`
+}
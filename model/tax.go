@@ -0,0 +1,31 @@
+package model
+
+// Price display modes
+const (
+	PricesNet   = "net"
+	PricesGross = "gross"
+)
+
+// TaxClass groups products taxed alike, e.g. "standard" or "reduced".
+// Rates are percentages keyed by ISO 3166-1 alpha-2 region code.
+type TaxClass struct {
+	Id    int                `json:"id"`
+	Name  string             `json:"name" validate:"required,min=3"`
+	Rates map[string]float64 `json:"rates" validate:"dive,keys,len=2,uppercase,endkeys,gte=0,lte=100"`
+}
+
+// PriceView selects the region and mode product prices are rendered in.
+// Stored prices are net.
+type PriceView struct {
+	Region string `json:"region" validate:"required_if=Prices gross,omitempty,len=2,alpha,uppercase"`
+	Prices string `json:"prices" validate:"omitempty,oneof=net gross"`
+}
+
+// TaxBreakdown of the effective price of a product in a region
+type TaxBreakdown struct {
+	Region     string  `json:"region"`
+	Rate       float64 `json:"rate"`
+	NetPrice   float64 `json:"net_price"`
+	TaxAmount  float64 `json:"tax_amount"`
+	GrossPrice float64 `json:"gross_price"`
+}
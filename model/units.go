@@ -0,0 +1,57 @@
+package model
+
+// Unit systems of weights and dimensions. Products are stored in metric
+// units, kilograms and meters, imperial ones are pounds and inches.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+const (
+	kilogramsPerPound = 0.45359237
+	metersPerInch     = 0.0254
+)
+
+// FromUnits converts weights and dimensions given in `units` to metric ones
+func (p *Product) FromUnits(units string) {
+	if units != UnitsImperial {
+		return
+	}
+	scale(p.Weight, kilogramsPerPound)
+	for _, length := range []*float64{p.Length, p.Width, p.Height} {
+		scale(length, metersPerInch)
+	}
+}
+
+// ToUnits converts metric weights and dimensions to `units`
+func (p *Product) ToUnits(units string) {
+	if units != UnitsImperial {
+		return
+	}
+	scale(p.Weight, 1/kilogramsPerPound)
+	for _, length := range []*float64{p.Length, p.Width, p.Height} {
+		scale(length, 1/metersPerInch)
+	}
+}
+
+// WeightFromUnits converts a weight given in `units` to kilograms
+func WeightFromUnits(weight float64, units string) float64 {
+	if units == UnitsImperial {
+		return weight * kilogramsPerPound
+	}
+	return weight
+}
+
+// LengthFromUnits converts a length given in `units` to meters
+func LengthFromUnits(length float64, units string) float64 {
+	if units == UnitsImperial {
+		return length * metersPerInch
+	}
+	return length
+}
+
+func scale(value *float64, factor float64) {
+	if value != nil {
+		*value *= factor
+	}
+}
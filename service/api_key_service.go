@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/logging"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+const (
+	// apiKeyPrefix starts every generated key, telling them apart from other secrets
+	apiKeyPrefix = "pk_"
+	// apiKeyShown is the length of the start of a key kept in clear
+	apiKeyShown = len(apiKeyPrefix) + 8
+	// minApiKeySecret is the fewest characters of a configured key after its
+	// prefix, most of them never shown
+	minApiKeySecret = 32
+	// lastUseInterval is how often the use of a key is recorded, sparing
+	// a write to most requests
+	lastUseInterval = time.Minute
+	// bootstrapKeyName names the admin key created on first startup
+	bootstrapKeyName = "bootstrap"
+)
+
+type ApiKeyService interface {
+	// Authenticate returns the active API key `key` and records its use
+	Authenticate(ctx context.Context, key string) (*model.ApiKey, error)
+	GetApiKey(ctx context.Context, id int) (*model.ApiKey, error)
+	GetApiKeys(ctx context.Context) ([]*model.ApiKey, error)
+	CreateApiKey(ctx context.Context, key *model.ApiKey) (*model.IssuedApiKey, error)
+	// RotateApiKey replaces the key of an API key, the former one stops working at once
+	RotateApiKey(ctx context.Context, id int) (*model.IssuedApiKey, error)
+	RevokeApiKey(ctx context.Context, id int) error
+	// Bootstrap creates an admin key when there is no key at all, from `key`
+	// when given or else a random one. Nil is returned when keys exist. A given
+	// key must look like generated ones, the start of the keys being shown.
+	Bootstrap(ctx context.Context, key string) (*model.IssuedApiKey, error)
+}
+
+type ApiKeyServiceContext struct {
+	store db.Store
+}
+
+func NewApiKeyService(store db.Store) ApiKeyService {
+	return &ApiKeyServiceContext{store: store}
+}
+
+// hashApiKey is the form keys are stored and looked up in. Keys are random
+// enough for a fast hash not to help guessing them.
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateApiKey returns a new random key
+func generateApiKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// issue sets the prefix and hash of the API key from `key`
+func issue(apiKey *model.ApiKey, key string) {
+	apiKey.Prefix = key
+	if len(key) > apiKeyShown {
+		apiKey.Prefix = key[:apiKeyShown]
+	}
+	apiKey.Hash = hashApiKey(key)
+}
+
+func (aksc *ApiKeyServiceContext) Authenticate(ctx context.Context, key string) (*model.ApiKey, error) {
+	apiKey, err := aksc.store.GetApiKeyByHash(ctx, nil, hashApiKey(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey == nil {
+		return nil, &AuthenticationError{Reason: "unknown API key"}
+	}
+	var reason string
+	switch {
+	case apiKey.RevokedAt != nil:
+		reason = "API key revoked"
+	case apiKey.Expired(now):
+		reason = "API key expired"
+	}
+	if reason != "" {
+		logging.Entry(ctx).WithField("api_key", apiKey.Id).WithField("reason", reason).Info("API key rejected")
+		return nil, &AuthenticationError{Reason: reason}
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUseInterval {
+		if err = aksc.store.TouchApiKey(ctx, nil, apiKey.Id, now); err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = &now
+	}
+	return apiKey, nil
+}
+
+func (aksc *ApiKeyServiceContext) GetApiKey(ctx context.Context, id int) (*model.ApiKey, error) {
+	apiKey, err := aksc.store.GetApiKey(ctx, nil, id)
+	if err == nil && apiKey == nil {
+		err = &NotFoundError{Entity: "API key", Field: "id", Value: id}
+	}
+	return apiKey, err
+}
+
+func (aksc *ApiKeyServiceContext) GetApiKeys(ctx context.Context) ([]*model.ApiKey, error) {
+	return aksc.store.GetApiKeys(ctx, nil)
+}
+
+func (aksc *ApiKeyServiceContext) CreateApiKey(ctx context.Context, apiKey *model.ApiKey) (*model.IssuedApiKey, error) {
+	key, err := generateApiKey()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := aksc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := aksc.createApiKey(ctx, tx, apiKey, key)
+	if err != nil {
+		aksc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = aksc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	logging.Entry(ctx).WithField("api_key", issued.Id).WithField("name", issued.Name).Info("API key created")
+	return issued, nil
+}
+
+// createApiKey stores the API key with the key `key`
+func (aksc *ApiKeyServiceContext) createApiKey(ctx context.Context, tx *sql.Tx, apiKey *model.ApiKey, key string) (*model.IssuedApiKey, error) {
+	apiKey.CreatedAt = time.Now()
+	apiKey.LastUsedAt = nil
+	apiKey.RevokedAt = nil
+	issue(apiKey, key)
+	id, err := aksc.store.CreateApiKey(ctx, tx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	apiKey.Id = *id
+	return &model.IssuedApiKey{ApiKey: *apiKey, Key: key}, nil
+}
+
+func (aksc *ApiKeyServiceContext) RotateApiKey(ctx context.Context, id int) (*model.IssuedApiKey, error) {
+	key, err := generateApiKey()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := aksc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := aksc.store.GetApiKey(ctx, tx, id)
+	if err == nil && apiKey == nil {
+		err = &NotFoundError{Entity: "API key", Field: "id", Value: id}
+	}
+	if err == nil && apiKey.RevokedAt != nil {
+		err = &PreconditionFailedError{Reason: "revoked API keys can't be rotated"}
+	}
+	var issued *model.IssuedApiKey
+	if err == nil {
+		issue(apiKey, key)
+		issued = &model.IssuedApiKey{ApiKey: *apiKey, Key: key}
+		err = aksc.store.SetApiKeyHash(ctx, tx, id, apiKey.Prefix, apiKey.Hash)
+	}
+	if err != nil {
+		aksc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = aksc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	logging.Entry(ctx).WithField("api_key", id).Info("API key rotated")
+	return issued, nil
+}
+
+func (aksc *ApiKeyServiceContext) RevokeApiKey(ctx context.Context, id int) error {
+	tx, err := aksc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(aksc.store.RevokeApiKey(ctx, tx, id, time.Now()), "API key", id)
+	if err != nil {
+		aksc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = aksc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	logging.Entry(ctx).WithField("api_key", id).Info("API key revoked")
+	return nil
+}
+
+func (aksc *ApiKeyServiceContext) Bootstrap(ctx context.Context, key string) (*model.IssuedApiKey, error) {
+	var err error
+	if key == "" {
+		if key, err = generateApiKey(); err != nil {
+			return nil, err
+		}
+	} else if !strings.HasPrefix(key, apiKeyPrefix) || len(key) < len(apiKeyPrefix)+minApiKeySecret {
+		return nil, invalid("bootstrapkey", fmt.Sprintf("must start with `%s` followed by at least %d characters", apiKeyPrefix, minApiKeySecret))
+	}
+	tx, err := aksc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count, err := aksc.store.CountApiKeys(ctx, tx)
+	var issued *model.IssuedApiKey
+	if err == nil && count == 0 {
+		issued, err = aksc.createApiKey(ctx, tx, &model.ApiKey{Name: bootstrapKeyName, Scopes: []string{model.ScopeAdmin}}, key)
+	}
+	if err != nil {
+		aksc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = aksc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// ErrNotExecuted is the result of the operations following a failure in an atomic batch
+var ErrNotExecuted = errors.New("not executed, an earlier operation failed")
+
+// InvalidOperationError is returned for batch operations whose id or data
+// can't be decoded, resolved or validated
+type InvalidOperationError struct {
+	Err error
+}
+
+func (e *InvalidOperationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidOperationError) Unwrap() error {
+	return e.Err
+}
+
+// batchSavepoint isolates the operations of batches that continue on error
+const batchSavepoint = "batch_operation"
+
+type BatchService interface {
+	// ExecuteBatch runs the operations in order in one transaction, `check`
+	// validates each decoded product or category before it is written
+	ExecuteBatch(ctx context.Context, batch *model.Batch, check func(interface{}) error) (*model.BatchResponse, error)
+}
+
+type BatchServiceContext struct {
+	store db.Store
+}
+
+func NewBatchService(store db.Store) BatchService {
+	return &BatchServiceContext{store: store}
+}
+
+func (bsc *BatchServiceContext) ExecuteBatch(ctx context.Context, batch *model.Batch, check func(interface{}) error) (*model.BatchResponse, error) {
+	keepGoing := batch.Mode == model.BatchModeContinue
+	tx, err := bsc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.BatchResponse{Results: make([]model.BatchResult, len(batch.Operations))}
+	refs := map[string]int{}
+	failed := false
+	for i := range batch.Operations {
+		operation := &batch.Operations[i]
+		result := &response.Results[i]
+		result.Index = i
+		if failed {
+			result.Err = ErrNotExecuted
+			continue
+		}
+		if keepGoing {
+			if err = bsc.store.Savepoint(ctx, tx, batchSavepoint); err != nil {
+				bsc.store.Rollback(ctx, tx)
+				return nil, err
+			}
+		}
+		result.Id, result.Err = bsc.execute(ctx, tx, operation, refs, check)
+		switch {
+		case result.Err == nil && keepGoing:
+			err = bsc.store.Release(ctx, tx, batchSavepoint)
+		case result.Err != nil && keepGoing:
+			err = bsc.store.RollbackTo(ctx, tx, batchSavepoint)
+		case result.Err != nil:
+			failed = true
+		}
+		if err != nil {
+			bsc.store.Rollback(ctx, tx)
+			return nil, err
+		}
+		if result.Err == nil && operation.Ref != "" {
+			refs[operation.Ref] = *result.Id
+		}
+	}
+	if failed {
+		bsc.store.Rollback(ctx, tx)
+		return response, nil
+	}
+	if err = bsc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	response.Committed = true
+	return response, nil
+}
+
+// execute runs one operation, returning the id of the product or category
+func (bsc *BatchServiceContext) execute(ctx context.Context, tx *sql.Tx, operation *model.BatchOperation, refs map[string]int, check func(interface{}) error) (*int, error) {
+	if operation.Ref != "" {
+		if operation.Op != model.BatchOpCreate {
+			return nil, &InvalidOperationError{errors.New("`ref` is only allowed on create")}
+		}
+		if _, ok := refs[operation.Ref]; ok {
+			return nil, &InvalidOperationError{fmt.Errorf("`ref` %q is already defined", operation.Ref)}
+		}
+	}
+	var id int
+	if operation.Op != model.BatchOpCreate {
+		if err := resolveRefs(operation.Id, refs, &id); err != nil {
+			return nil, err
+		}
+	}
+	if operation.Op == model.BatchOpDelete {
+		if operation.Entity == model.BatchEntityProduct {
+			return &id, deleteProduct(ctx, bsc.store, tx, id)
+		}
+		return &id, notFound(bsc.store.DeleteCategory(ctx, tx, id), "category", id)
+	}
+	var value interface{}
+	if operation.Entity == model.BatchEntityProduct {
+		value = &model.Product{}
+	} else {
+		value = &model.Category{}
+	}
+	if err := resolveRefs(operation.Data, refs, value); err != nil {
+		return nil, err
+	}
+	if err := check(value); err != nil {
+		return nil, &InvalidOperationError{err}
+	}
+	switch value := value.(type) {
+	case *model.Product:
+		if operation.Op == model.BatchOpCreate {
+			return createProduct(ctx, bsc.store, tx, value)
+		}
+		value.Id = id
+		return &id, updateProduct(ctx, bsc.store, tx, value)
+	case *model.Category:
+		if operation.Op == model.BatchOpCreate {
+			return createCategory(ctx, bsc.store, tx, value)
+		}
+		value.Id = id
+		return &id, updateCategory(ctx, bsc.store, tx, value)
+	}
+	return nil, nil
+}
+
+// resolveRefs decodes `raw` into `target` once the {"$ref": name} objects
+// are replaced by the ids created under those names
+func resolveRefs(raw json.RawMessage, refs map[string]int, target interface{}) error {
+	if len(raw) == 0 {
+		return &InvalidOperationError{errors.New("missing `id` or `data`")}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &InvalidOperationError{err}
+	}
+	value, err := substituteRefs(value, refs)
+	if err != nil {
+		return err
+	}
+	resolved, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(resolved, target); err != nil {
+		return &InvalidOperationError{err}
+	}
+	return nil
+}
+
+func substituteRefs(value interface{}, refs map[string]int) (interface{}, error) {
+	var err error
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if name, ok := value["$ref"].(string); ok && len(value) == 1 {
+			id, ok := refs[name]
+			if !ok {
+				return nil, &InvalidOperationError{fmt.Errorf("unknown `$ref` %q", name)}
+			}
+			return id, nil
+		}
+		for key, item := range value {
+			if value[key], err = substituteRefs(item, refs); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if value[i], err = substituteRefs(item, refs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// ErrUnknownBrand is returned when a product is made by a missing brand
+var ErrUnknownBrand error = invalid("brand", "brand not found")
+
+type BrandService interface {
+	CreateBrand(ctx context.Context, brand *model.Brand) (*int, error)
+	UpdateBrand(ctx context.Context, brand *model.Brand) error
+	DeleteBrand(ctx context.Context, id int) error
+	GetBrand(ctx context.Context, id int) (*model.Brand, error)
+	GetBrands(ctx context.Context) ([]*model.Brand, error)
+}
+
+type BrandServiceContext struct {
+	store db.Store
+}
+
+func NewBrandService(store db.Store) BrandService {
+	return &BrandServiceContext{store: store}
+}
+
+func (bsc *BrandServiceContext) GetBrand(ctx context.Context, id int) (*model.Brand, error) {
+	brand, err := bsc.store.GetBrand(ctx, nil, id)
+	if err == nil && brand == nil {
+		err = &NotFoundError{Entity: "brand", Field: "id", Value: id}
+	}
+	return brand, err
+}
+
+func (bsc *BrandServiceContext) GetBrands(ctx context.Context) ([]*model.Brand, error) {
+	return bsc.store.GetBrands(ctx, nil)
+}
+
+func (bsc *BrandServiceContext) CreateBrand(ctx context.Context, brand *model.Brand) (*int, error) {
+	tx, err := bsc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := bsc.store.CreateBrand(ctx, tx, brand)
+	if err != nil {
+		bsc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = bsc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (bsc *BrandServiceContext) UpdateBrand(ctx context.Context, brand *model.Brand) error {
+	tx, err := bsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(bsc.store.UpdateBrand(ctx, tx, brand), "brand", brand.Id)
+	if err != nil {
+		bsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = bsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (bsc *BrandServiceContext) DeleteBrand(ctx context.Context, id int) error {
+	tx, err := bsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(bsc.store.DeleteBrand(ctx, tx, id), "brand", id)
+	if err != nil {
+		bsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = bsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkBrand fails with ErrUnknownBrand unless the brand, if any, exists
+func checkBrand(ctx context.Context, store db.Store, tx *sql.Tx, id *int) error {
+	if id == nil {
+		return nil
+	}
+	brand, err := store.GetBrand(ctx, tx, *id)
+	if err != nil {
+		return err
+	}
+	if brand == nil {
+		return ErrUnknownBrand
+	}
+	return nil
+}
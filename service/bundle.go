@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+var (
+	// ErrBundleCycle is returned when a bundle would contain itself, directly or through another bundle
+	ErrBundleCycle error = invalid("components", "bundle can't contain itself")
+	// ErrUnknownComponent is returned when a bundle component doesn't exist
+	ErrUnknownComponent error = invalid("components", "bundle component not found")
+	// ErrProductInBundle is returned when deleting a product still used by a bundle
+	ErrProductInBundle error = &PreconditionFailedError{"product is a component of a bundle"}
+)
+
+// checkBundle verifies that every component exists and that the bundle
+// doesn't appear anywhere in its own component tree
+func checkBundle(ctx context.Context, store db.Store, tx *sql.Tx, bundle *model.Product) error {
+	seen := map[int]bool{}
+	var check func(id int) error
+	check = func(id int) error {
+		if bundle.Id != 0 && id == bundle.Id {
+			return ErrBundleCycle
+		}
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+		component, err := store.GetProduct(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if component == nil {
+			return ErrUnknownComponent
+		}
+		for _, c := range component.Components {
+			if err := check(c.Product); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, component := range bundle.Components {
+		if err := check(component.Product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleResolver computes the stock and derived prices of bundles from
+// their components, loading the components that aren't already known
+type bundleResolver struct {
+	ctx      context.Context
+	store    db.Store
+	products map[int]*model.Product
+	resolved map[int]bool
+	visiting map[int]bool
+}
+
+func newBundleResolver(ctx context.Context, store db.Store, products []*model.Product) *bundleResolver {
+	r := &bundleResolver{
+		ctx:      ctx,
+		store:    store,
+		products: make(map[int]*model.Product, len(products)),
+		resolved: map[int]bool{},
+		visiting: map[int]bool{},
+	}
+	for _, product := range products {
+		r.products[product.Id] = product
+	}
+	return r
+}
+
+// resolveBundles sets the stock and the derived prices of the bundles among `products`
+func resolveBundles(ctx context.Context, store db.Store, products []*model.Product) error {
+	resolver := newBundleResolver(ctx, store, products)
+	for _, product := range products {
+		if err := resolver.resolve(product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bundleResolver) get(id int) (*model.Product, error) {
+	if product, ok := r.products[id]; ok {
+		return product, nil
+	}
+	product, err := r.store.GetProduct(r.ctx, nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if product != nil {
+		r.products[id] = product
+	}
+	return product, nil
+}
+
+// resolve sets the stock of the bundle to the number of complete bundles the
+// scarcest component allows, and its price when derived from the components
+func (r *bundleResolver) resolve(bundle *model.Product) error {
+	if !bundle.IsBundle() || r.resolved[bundle.Id] {
+		return nil
+	}
+	if r.visiting[bundle.Id] {
+		return ErrBundleCycle
+	}
+	r.visiting[bundle.Id] = true
+	defer delete(r.visiting, bundle.Id)
+
+	stock := -1
+	var price float64
+	for _, c := range bundle.Components {
+		component, err := r.get(c.Product)
+		if err != nil {
+			return err
+		}
+		available := 0
+		if component != nil {
+			if err = r.resolve(component); err != nil {
+				return err
+			}
+			available = component.Stock / c.Quantity
+			price += component.Price * float64(c.Quantity)
+		}
+		if stock < 0 || available < stock {
+			stock = available
+		}
+	}
+	if stock < 0 {
+		stock = 0
+	}
+	bundle.Stock = stock
+	if bundle.BundlePricing == model.BundlePricingDerived {
+		bundle.Price = roundPrice(price * (1 - bundle.BundleDiscount/100))
+	}
+	r.resolved[bundle.Id] = true
+	return nil
+}
@@ -1,16 +1,20 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/mrlightwood/golang-products-api/db"
 	"github.com/mrlightwood/golang-products-api/model"
 )
 
 type CategoryService interface {
-	CreateCategory(category *model.Category) (*int, error)
-	UpdateCategory(category *model.Category) error
-	DeleteCategory(id int) error
-	GetCategory(id int) (*model.Category, error)
-	GetCategories() ([]*model.Category, error)
+	CreateCategory(ctx context.Context, category *model.Category) (*int, error)
+	UpdateCategory(ctx context.Context, category *model.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	GetCategory(ctx context.Context, id int) (*model.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error)
+	GetCategories(ctx context.Context) ([]*model.Category, error)
 }
 
 type CategoryServiceContext struct {
@@ -21,58 +25,98 @@ func NewCategoryService(store db.Store) CategoryService {
 	return &CategoryServiceContext{store: store}
 }
 
-func (csc *CategoryServiceContext) GetCategory(id int) (*model.Category, error) {
-	return csc.store.GetCategory(nil, id)
+func (csc *CategoryServiceContext) GetCategory(ctx context.Context, id int) (*model.Category, error) {
+	category, err := csc.store.GetCategory(ctx, nil, id)
+	if err == nil && category == nil {
+		err = &NotFoundError{Entity: "category", Field: "id", Value: id}
+	}
+	return category, err
 }
 
-func (csc *CategoryServiceContext) GetCategories() ([]*model.Category, error) {
-	return csc.store.GetCategories(nil)
+// GetCategoryBySlug finds the category by its current or a former slug,
+// the returned category tells which one is current
+func (csc *CategoryServiceContext) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	id, err := csc.store.ResolveSlug(ctx, nil, model.SlugEntityCategory, slug)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, &NotFoundError{Entity: "category", Field: "slug", Value: slug}
+	}
+	return csc.GetCategory(ctx, *id)
 }
 
-func (csc *CategoryServiceContext) CreateCategory(category *model.Category) (*int, error) {
-	tx, err := csc.store.Begin()
+func (csc *CategoryServiceContext) GetCategories(ctx context.Context) ([]*model.Category, error) {
+	return csc.store.GetCategories(ctx, nil)
+}
+
+func (csc *CategoryServiceContext) CreateCategory(ctx context.Context, category *model.Category) (*int, error) {
+	tx, err := csc.store.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	cat, err := csc.store.CreateCategory(tx, category)
+	cat, err := createCategory(ctx, csc.store, tx, category)
 	if err != nil {
-		csc.store.Rollback(tx)
+		csc.store.Rollback(ctx, tx)
 		return nil, err
 	}
-	if err = csc.store.Commit(tx); err != nil {
+	if err = csc.store.Commit(ctx, tx); err != nil {
 		return nil, err
 	}
 	return cat, nil
 }
 
-func (csc *CategoryServiceContext) UpdateCategory(category *model.Category) error {
-	tx, err := csc.store.Begin()
+func (csc *CategoryServiceContext) UpdateCategory(ctx context.Context, category *model.Category) error {
+	tx, err := csc.store.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	err = csc.store.UpdateCategory(tx, category)
+	err = updateCategory(ctx, csc.store, tx, category)
 	if err != nil {
-		csc.store.Rollback(tx)
+		csc.store.Rollback(ctx, tx)
 		return err
 	}
-	if err = csc.store.Commit(tx); err != nil {
+	if err = csc.store.Commit(ctx, tx); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (csc *CategoryServiceContext) DeleteCategory(id int) error {
-	tx, err := csc.store.Begin()
+func (csc *CategoryServiceContext) DeleteCategory(ctx context.Context, id int) error {
+	tx, err := csc.store.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	err = csc.store.DeleteCategory(tx, id)
+	err = notFound(csc.store.DeleteCategory(ctx, tx, id), "category", id)
 	if err != nil {
-		csc.store.Rollback(tx)
+		csc.store.Rollback(ctx, tx)
 		return err
 	}
-	if err = csc.store.Commit(tx); err != nil {
+	if err = csc.store.Commit(ctx, tx); err != nil {
 		return err
 	}
 	return nil
 }
+
+// createCategory and updateCategory do the work of the service methods
+// inside a transaction of the caller, shared by batches
+
+func createCategory(ctx context.Context, store db.Store, tx *sql.Tx, category *model.Category) (*int, error) {
+	if err := checkTaxClass(ctx, store, tx, category.TaxClass); err != nil {
+		return nil, err
+	}
+	if err := assignSlug(ctx, store, tx, model.SlugEntityCategory, 0, &category.Slug, category.Name); err != nil {
+		return nil, err
+	}
+	return store.CreateCategory(ctx, tx, category)
+}
+
+func updateCategory(ctx context.Context, store db.Store, tx *sql.Tx, category *model.Category) error {
+	if err := checkTaxClass(ctx, store, tx, category.TaxClass); err != nil {
+		return err
+	}
+	if err := assignSlug(ctx, store, tx, model.SlugEntityCategory, category.Id, &category.Slug, category.Name); err != nil {
+		return err
+	}
+	return notFound(store.UpdateCategory(ctx, tx, category), "category", category.Id)
+}
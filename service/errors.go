@@ -0,0 +1,79 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// The errors of the services tell what went wrong in terms of the catalog,
+// the errors of the store are only returned for unexpected failures
+
+// NotFoundError is returned when the resource a request is about doesn't exist
+type NotFoundError struct {
+	// Kind of resource, like "product" or "tax class"
+	Entity string
+	// Field the resource was looked up by and its value
+	Field string
+	Value interface{}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s `%s` = %v not found", e.Entity, e.Field, e.Value)
+}
+
+// notFound translates the sql.ErrNoRows of the store updates and deletes of
+// resource `id` into a NotFoundError
+func notFound(err error, entity string, id int) error {
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Entity: entity, Field: "id", Value: id}
+	}
+	return err
+}
+
+// FieldError is an invalid value of a request
+type FieldError struct {
+	// Field or query param, named as in requests
+	Field string `json:"field"`
+	// Rule the value breaks, like "required" or "oneof"
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when values of a request are invalid
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// invalid returns the ValidationError of one field
+func invalid(field string, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// PreconditionFailedError is returned when the state of a resource doesn't
+// allow the operation, until something else changes
+type PreconditionFailedError struct {
+	Reason string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return e.Reason
+}
+
+// AuthenticationError is returned when the credentials of a client are
+// missing or can't be used
+type AuthenticationError struct {
+	Reason string
+}
+
+func (e *AuthenticationError) Error() string {
+	return e.Reason
+}
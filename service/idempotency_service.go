@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/logging"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+var (
+	// ErrRequestInProgress is returned for a retry arriving while the first
+	// request with its idempotency key still runs
+	ErrRequestInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrIdempotencyKeyReused is returned when an idempotency key comes with
+	// another request than the first one
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used for another request")
+)
+
+type IdempotencyService interface {
+	// Begin reserves `key` for the request of `fingerprint`. It returns the
+	// response of the former request with the key, nil when the request is to
+	// run: the first time, or again once the former request died.
+	Begin(ctx context.Context, key string, fingerprint string) (*model.IdempotentResponse, error)
+	// Complete stores the response of the request of `key`
+	Complete(ctx context.Context, key string, response *model.IdempotentResponse) error
+	// Release forgets `key`, so that the request runs again when retried
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyServiceContext struct {
+	store db.Store
+	// How long responses are replayed
+	expiry time.Duration
+	// How long a request holds its key, a request still without response by
+	// then is taken for dead and its retries run
+	lease time.Duration
+}
+
+func NewIdempotencyService(store db.Store, expiry time.Duration, lease time.Duration) IdempotencyService {
+	return &IdempotencyServiceContext{store: store, expiry: expiry, lease: lease}
+}
+
+func (isc *IdempotencyServiceContext) Begin(ctx context.Context, key string, fingerprint string) (*model.IdempotentResponse, error) {
+	tx, err := isc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := isc.begin(ctx, tx, key, fingerprint)
+	if err != nil {
+		isc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = isc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// begin forgets the expired keys before reserving `key`
+func (isc *IdempotencyServiceContext) begin(ctx context.Context, tx *sql.Tx, key string, fingerprint string) (*model.IdempotentResponse, error) {
+	now := time.Now()
+	if _, err := isc.store.DeleteIdempotencyKeys(ctx, tx, now.Add(-isc.expiry)); err != nil {
+		return nil, err
+	}
+	created, err := isc.store.CreateIdempotencyKey(ctx, tx, &model.IdempotencyKey{Key: key, Fingerprint: fingerprint, CreatedAt: now})
+	if err != nil || created {
+		return nil, err
+	}
+	existing, err := isc.store.GetIdempotencyKey(ctx, tx, key)
+	switch {
+	case err != nil:
+		return nil, err
+	case existing == nil:
+		// Deleted since, by the release of a failed request
+		return nil, ErrRequestInProgress
+	case existing.Fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case existing.Response == nil:
+		relocked, err := isc.store.RelockIdempotencyKey(ctx, tx, key, now.Add(-isc.lease), now)
+		if err != nil {
+			return nil, err
+		}
+		if !relocked {
+			return nil, ErrRequestInProgress
+		}
+		logging.Entry(ctx).WithField("created_at", existing.CreatedAt).Info("Running again the abandoned request of the idempotency key")
+		return nil, nil
+	}
+	logging.Entry(ctx).WithField("created_at", existing.CreatedAt).Debug("Replaying the response of the idempotency key")
+	return existing.Response, nil
+}
+
+func (isc *IdempotencyServiceContext) Complete(ctx context.Context, key string, response *model.IdempotentResponse) error {
+	tx, err := isc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = isc.store.SetIdempotentResponse(ctx, tx, key, response); err != nil {
+		isc.store.Rollback(ctx, tx)
+		return err
+	}
+	return isc.store.Commit(ctx, tx)
+}
+
+func (isc *IdempotencyServiceContext) Release(ctx context.Context, key string) error {
+	tx, err := isc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = isc.store.DeleteIdempotencyKey(ctx, tx, key); err != nil {
+		isc.store.Rollback(ctx, tx)
+		return err
+	}
+	return isc.store.Commit(ctx, tx)
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/logging"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// importSavepoint isolates the rows of an import
+const importSavepoint = "import_row"
+
+// importColumns sets the product field of each column from its CSV value,
+// empty values clear the field
+var importColumns = map[string]func(p *model.Product, value string) error{
+	"sku":         func(p *model.Product, value string) error { p.SKU = value; return nil },
+	"name":        func(p *model.Product, value string) error { p.Name = value; return nil },
+	"slug":        func(p *model.Product, value string) error { p.Slug = value; return nil },
+	"description": func(p *model.Product, value string) error { p.Description = value; return nil },
+	"barcode":     func(p *model.Product, value string) error { p.Barcode = value; return nil },
+	"status":      func(p *model.Product, value string) error { p.Status = value; return nil },
+	"tags": func(p *model.Product, value string) error {
+		p.Tags = []string{}
+		for _, tag := range strings.Split(value, "|") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				p.Tags = append(p.Tags, tag)
+			}
+		}
+		return nil
+	},
+	"category": func(p *model.Product, value string) error {
+		category, err := optionalInt(value)
+		if err == nil {
+			p.Category = 0
+			if category != nil {
+				p.Category = *category
+			}
+		}
+		return err
+	},
+	"brand":        func(p *model.Product, value string) (err error) { p.Brand, err = optionalInt(value); return },
+	"tax_class":    func(p *model.Product, value string) (err error) { p.TaxClass, err = optionalInt(value); return },
+	"publish_at":   func(p *model.Product, value string) (err error) { p.PublishAt, err = optionalTime(value); return },
+	"unpublish_at": func(p *model.Product, value string) (err error) { p.UnpublishAt, err = optionalTime(value); return },
+	"weight":       func(p *model.Product, value string) (err error) { p.Weight, err = optionalFloat(value); return },
+	"length":       func(p *model.Product, value string) (err error) { p.Length, err = optionalFloat(value); return },
+	"width":        func(p *model.Product, value string) (err error) { p.Width, err = optionalFloat(value); return },
+	"height":       func(p *model.Product, value string) (err error) { p.Height, err = optionalFloat(value); return },
+	"price": func(p *model.Product, value string) error {
+		price, err := optionalFloat(value)
+		if err == nil {
+			p.Price = 0
+			if price != nil {
+				p.Price = *price
+			}
+		}
+		return err
+	},
+	"stock": func(p *model.Product, value string) error {
+		stock, err := optionalInt(value)
+		if err == nil {
+			p.Stock = 0
+			if stock != nil {
+				p.Stock = *stock
+			}
+		}
+		return err
+	},
+}
+
+func optionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.New("not an integer")
+	}
+	return &i, nil
+}
+
+func optionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, errors.New("not a number")
+	}
+	return &f, nil
+}
+
+func optionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("not an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
+
+type ImportService interface {
+	// ImportProducts creates or updates a product per CSV row, matched by
+	// the id column or else by SKU. `mapping` renames header columns to product
+	// fields and `check` validates each product before it is written. Rows
+	// failing are reported and skipped, a dry run writes nothing.
+	ImportProducts(ctx context.Context, r io.Reader, mapping map[string]string, dryRun bool, check func(interface{}) error) (*model.ImportReport, error)
+}
+
+type ImportServiceContext struct {
+	store db.Store
+}
+
+func NewImportService(store db.Store) ImportService {
+	return &ImportServiceContext{store: store}
+}
+
+func (isc *ImportServiceContext) ImportProducts(ctx context.Context, r io.Reader, mapping map[string]string, dryRun bool, check func(interface{}) error) (*model.ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, &InvalidOperationError{fmt.Errorf("reading CSV header: %v", err)}
+	}
+	columns, err := importHeader(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := isc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.ImportReport{DryRun: dryRun, Rows: []model.ImportRow{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var row model.ImportRow
+		if err != nil {
+			// The reader carries on with the next row
+			row.Status, row.Message = model.ImportStatusError, err.Error()
+			if e, ok := err.(*csv.ParseError); ok {
+				row.Line = e.StartLine
+			}
+		} else {
+			row.Line, _ = reader.FieldPos(0)
+			if err = isc.importSafely(ctx, tx, &row, columns, record, check); err != nil {
+				isc.store.Rollback(ctx, tx)
+				return nil, err
+			}
+		}
+		switch row.Status {
+		case model.ImportStatusCreated:
+			report.Created++
+			if dryRun {
+				row.Id = nil
+			}
+		case model.ImportStatusUpdated:
+			report.Updated++
+		case model.ImportStatusUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	logging.Entry(ctx).WithField("dry_run", dryRun).WithField("created", report.Created).WithField("updated", report.Updated).
+		WithField("unchanged", report.Unchanged).WithField("failed", report.Failed).Info("Products imported")
+	if dryRun {
+		isc.store.Rollback(ctx, tx)
+		return report, nil
+	}
+	if err = isc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importHeader names the product field of each column, "" for the ignored ones
+func importHeader(header []string, mapping map[string]string) ([]string, error) {
+	renames := make(map[string]string, len(mapping))
+	for column, field := range mapping {
+		if _, ok := importColumns[field]; !ok && field != "id" {
+			return nil, &InvalidOperationError{fmt.Errorf("unknown product field %q in mapping", field)}
+		}
+		renames[strings.ToLower(strings.TrimSpace(column))] = field
+	}
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if field, ok := renames[column]; ok {
+			column = field
+		}
+		if _, ok := importColumns[column]; !ok && column != "id" {
+			continue
+		}
+		if seen[column] {
+			return nil, &InvalidOperationError{fmt.Errorf("column %q appears twice", column)}
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+	return columns, nil
+}
+
+// importSafely imports a row within a savepoint, reporting the errors due
+// to the row and returning the others
+func (isc *ImportServiceContext) importSafely(ctx context.Context, tx *sql.Tx, row *model.ImportRow, columns []string, record []string, check func(interface{}) error) error {
+	if err := isc.store.Savepoint(ctx, tx, importSavepoint); err != nil {
+		return err
+	}
+	var err error
+	row.Status, row.Id, err = isc.importRow(ctx, tx, columns, record, check)
+	if err == nil {
+		return isc.store.Release(ctx, tx, importSavepoint)
+	}
+	if !rowError(err) {
+		return err
+	}
+	row.Status, row.Id, row.Message = model.ImportStatusError, nil, err.Error()
+	return isc.store.RollbackTo(ctx, tx, importSavepoint)
+}
+
+// importRow writes the product of a row, unless nothing changes
+func (isc *ImportServiceContext) importRow(ctx context.Context, tx *sql.Tx, columns []string, record []string, check func(interface{}) error) (string, *int, error) {
+	var id, sku string
+	for i, column := range columns {
+		switch column {
+		case "id":
+			id = record[i]
+		case "sku":
+			sku = record[i]
+		}
+	}
+	var existing *model.Product
+	var err error
+	if id != "" {
+		productId, err := strconv.Atoi(id)
+		if err != nil {
+			return "", nil, &InvalidOperationError{errors.New("id: not an integer")}
+		}
+		if existing, err = isc.store.GetProduct(ctx, tx, productId); err != nil {
+			return "", nil, err
+		}
+		if existing == nil {
+			return "", nil, &InvalidOperationError{fmt.Errorf("product `id` = %d not found", productId)}
+		}
+	} else if sku != "" {
+		if existing, err = isc.store.GetProductBySKU(ctx, tx, sku); err != nil {
+			return "", nil, err
+		}
+	}
+	product := &model.Product{}
+	if existing != nil {
+		copied := *existing
+		product = &copied
+	}
+	for i, column := range columns {
+		if column == "" || column == "id" {
+			continue
+		}
+		if err = importColumns[column](product, record[i]); err != nil {
+			return "", nil, &InvalidOperationError{fmt.Errorf("%s: %v", column, err)}
+		}
+	}
+	if err = check(product); err != nil {
+		return "", nil, &InvalidOperationError{err}
+	}
+	if existing == nil {
+		created, err := createProduct(ctx, isc.store, tx, product)
+		return model.ImportStatusCreated, created, err
+	}
+	if unchanged(existing, product, columns) {
+		return model.ImportStatusUnchanged, &existing.Id, nil
+	}
+	return model.ImportStatusUpdated, &existing.Id, updateProduct(ctx, isc.store, tx, product)
+}
+
+// unchanged reports whether the columns of a row hold the values of the stored
+// product: no tags and empty tags are alike, so are the same times in other zones
+// and products published later, kept as drafts
+func unchanged(existing, product *model.Product, columns []string) bool {
+	schedule(product, time.Now())
+	for _, column := range columns {
+		var same bool
+		switch column {
+		case "", "id":
+			same = true
+		case "sku":
+			same = existing.SKU == product.SKU
+		case "name":
+			same = existing.Name == product.Name
+		case "slug":
+			same = existing.Slug == product.Slug
+		case "description":
+			same = existing.Description == product.Description
+		case "barcode":
+			same = existing.Barcode == product.Barcode
+		case "status":
+			same = existing.Status == product.Status
+		case "tags":
+			same = strings.Join(existing.Tags, "|") == strings.Join(product.Tags, "|")
+		case "category":
+			same = existing.Category == product.Category
+		case "brand":
+			same = sameInt(existing.Brand, product.Brand)
+		case "tax_class":
+			same = sameInt(existing.TaxClass, product.TaxClass)
+		case "publish_at":
+			same = sameTime(existing.PublishAt, product.PublishAt)
+		case "unpublish_at":
+			same = sameTime(existing.UnpublishAt, product.UnpublishAt)
+		case "weight":
+			same = sameFloat(existing.Weight, product.Weight)
+		case "length":
+			same = sameFloat(existing.Length, product.Length)
+		case "width":
+			same = sameFloat(existing.Width, product.Width)
+		case "height":
+			same = sameFloat(existing.Height, product.Height)
+		case "price":
+			same = existing.Price == product.Price
+		case "stock":
+			same = existing.Stock == product.Stock
+		}
+		if !same {
+			return false
+		}
+	}
+	return true
+}
+
+func sameInt(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func sameFloat(a, b *float64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	return a == nil && b == nil || a != nil && b != nil && a.Equal(*b)
+}
+
+// rowError reports whether the error is due to the row rather than the database
+func rowError(err error) bool {
+	switch err.(type) {
+	case *InvalidOperationError, *ConflictError, *ValidationError:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"math"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// ErrUnknownRegion is returned when prices are requested for a region without tax rates
+var ErrUnknownRegion error = invalid("region", "unknown tax region")
+
+// applyPromotions sets the effective price of the product.
+// `promotions` must be the active ones, sorted by descending priority.
+// The first matching promotion always applies; if it is stackable,
+// every following stackable promotion is applied on top of it.
+func applyPromotions(product *model.Product, promotions []*model.Promotion) {
+	price := product.Price
+	applied := []int{}
+	for _, promotion := range promotions {
+		if !promotion.Targets(product) {
+			continue
+		}
+		if len(applied) > 0 && !promotion.Stackable {
+			continue
+		}
+		switch promotion.Type {
+		case model.PromotionTypePercentage:
+			price -= price * promotion.Value / 100
+		case model.PromotionTypeFixed:
+			price -= promotion.Value
+		}
+		applied = append(applied, promotion.Id)
+		if !promotion.Stackable {
+			break
+		}
+	}
+	product.EffectivePrice = roundPrice(math.Max(price, 0))
+	product.AppliedPromotions = applied
+}
+
+// applyTax adds the tax breakdown of the effective price at `rate` percent.
+// Stored prices are net; with gross `prices` the price fields include tax.
+func applyTax(product *model.Product, view *model.PriceView, rate float64) {
+	net := roundPrice(product.EffectivePrice)
+	tax := roundPrice(net * rate / 100)
+	product.Tax = &model.TaxBreakdown{
+		Region:     view.Region,
+		Rate:       rate,
+		NetPrice:   net,
+		TaxAmount:  tax,
+		GrossPrice: roundPrice(net + tax),
+	}
+	if view.Prices == model.PricesGross {
+		product.Price = roundPrice(product.Price + roundPrice(product.Price*rate/100))
+		product.EffectivePrice = product.Tax.GrossPrice
+	}
+}
+
+// roundPrice rounds half away from zero to whole cents.
+// The nudge keeps binary artifacts like 1.005 -> 1.00499.. from rounding down.
+func roundPrice(price float64) float64 {
+	return math.Round(price*100+math.Copysign(1e-7, price)) / 100
+}
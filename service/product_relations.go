@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+var (
+	// ErrSelfRelation is returned when a product would be linked to itself
+	ErrSelfRelation error = invalid("product", "product can't be related to itself")
+	// ErrUnknownRelated is returned when a linked product doesn't exist
+	ErrUnknownRelated error = invalid("product", "related product not found")
+)
+
+// GetRelatedProducts returns the products linked from product `id` that match the filter,
+// or a NotFoundError when the product doesn't exist or has another status
+func (psc *ProductServiceContext) GetRelatedProducts(ctx context.Context, id int, relationType string, filter *model.ProductFilter, view *model.PriceView, productStatuses []string) ([]*model.RelatedProduct, error) {
+	product, err := psc.store.GetProduct(ctx, nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil || (len(productStatuses) > 0 && !contains(productStatuses, product.Status)) {
+		return nil, &NotFoundError{Entity: "product", Field: "id", Value: id}
+	}
+	relations, err := psc.store.GetProductRelations(ctx, nil, id, relationType)
+	if err != nil {
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return nil, nil
+	}
+	related := model.ProductFilter{}
+	if filter != nil {
+		related = *filter
+	}
+	related.Ids = make([]int, len(relations))
+	for i, relation := range relations {
+		related.Ids[i] = relation.Product
+	}
+	products, err := psc.GetProducts(ctx, &related, view)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int]*model.Product, len(products))
+	for _, p := range products {
+		byId[p.Id] = p
+	}
+	var res []*model.RelatedProduct
+	for _, relation := range relations {
+		if p, ok := byId[relation.Product]; ok {
+			res = append(res, &model.RelatedProduct{Type: relation.Type, Product: p})
+		}
+	}
+	return res, nil
+}
+
+// AddProductRelations links product `id` to other products, keeping its existing links
+func (psc *ProductServiceContext) AddProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	return psc.changeRelations(ctx, id, relations, false, psc.store.AddProductRelations)
+}
+
+// ReplaceProductRelations replaces every link of product `id`
+func (psc *ProductServiceContext) ReplaceProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	return psc.changeRelations(ctx, id, relations, true, psc.store.AddProductRelations)
+}
+
+// RemoveProductRelations unlinks product `id` from other products
+func (psc *ProductServiceContext) RemoveProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	return psc.changeRelations(ctx, id, relations, false, psc.store.RemoveProductRelations)
+}
+
+func (psc *ProductServiceContext) changeRelations(ctx context.Context, id int, relations []model.ProductRelation, clear bool,
+	change func(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error) error {
+	tx, err := psc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = psc.checkRelations(ctx, tx, id, relations); err != nil {
+		psc.store.Rollback(ctx, tx)
+		return err
+	}
+	if clear {
+		if err = psc.store.ClearProductRelations(ctx, tx, id); err != nil {
+			psc.store.Rollback(ctx, tx)
+			return err
+		}
+	}
+	if err = change(ctx, tx, id, relations); err != nil {
+		psc.store.Rollback(ctx, tx)
+		return err
+	}
+	return psc.store.Commit(ctx, tx)
+}
+
+// checkRelations verifies that both ends of every relation exist
+func (psc *ProductServiceContext) checkRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	product, err := psc.store.GetProduct(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return &NotFoundError{Entity: "product", Field: "id", Value: id}
+	}
+	checked := map[int]bool{}
+	for _, relation := range relations {
+		if relation.Product == id {
+			return ErrSelfRelation
+		}
+		if checked[relation.Product] {
+			continue
+		}
+		related, err := psc.store.GetProduct(ctx, tx, relation.Product)
+		if err != nil {
+			return err
+		}
+		if related == nil {
+			return ErrUnknownRelated
+		}
+		checked[relation.Product] = true
+	}
+	return nil
+}
@@ -1,16 +1,33 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
 	"github.com/mrlightwood/golang-products-api/db"
 	"github.com/mrlightwood/golang-products-api/model"
 )
 
+// ConflictError is returned when the slug, SKU or barcode of a product, or the
+// name or slug of a category, belongs to another one
+type ConflictError = db.ConflictError
+
 type ProductService interface {
-	CreateProduct(product *model.Product) (*int, error)
-	UpdateProduct(product *model.Product) error
-	DeleteProduct(id int) error
-	GetProduct(id int) (*model.Product, error)
-	GetProducts(category *int) ([]*model.Product, error)
+	CreateProduct(ctx context.Context, product *model.Product) (*int, error)
+	UpdateProduct(ctx context.Context, product *model.Product) error
+	DeleteProduct(ctx context.Context, id int) error
+	GetProduct(ctx context.Context, id int, view *model.PriceView) (*model.Product, error)
+	GetProductBySlug(ctx context.Context, slug string, view *model.PriceView) (*model.Product, error)
+	GetProductByBarcode(ctx context.Context, code string, view *model.PriceView) (*model.Product, error)
+	GetProducts(ctx context.Context, filter *model.ProductFilter, view *model.PriceView) ([]*model.Product, error)
+	ExportProducts(ctx context.Context, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error
+	GetBrandFacets(ctx context.Context, filter *model.ProductFilter) ([]*model.BrandFacet, error)
+	GetRelatedProducts(ctx context.Context, id int, relationType string, filter *model.ProductFilter, view *model.PriceView, productStatuses []string) ([]*model.RelatedProduct, error)
+	AddProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error
+	ReplaceProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error
+	RemoveProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error
 }
 
 func NewProductService(store db.Store) ProductService {
@@ -21,58 +38,243 @@ type ProductServiceContext struct {
 	store db.Store
 }
 
-func (psc *ProductServiceContext) GetProduct(id int) (*model.Product, error) {
-	return psc.store.GetProduct(nil, id)
+func (psc *ProductServiceContext) GetProduct(ctx context.Context, id int, view *model.PriceView) (*model.Product, error) {
+	product, err := psc.store.GetProduct(ctx, nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &NotFoundError{Entity: "product", Field: "id", Value: id}
+	}
+	if err = psc.price(ctx, []*model.Product{product}, view); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (psc *ProductServiceContext) GetProducts(category *int) ([]*model.Product, error) {
-	return psc.store.GetProducts(nil, category)
+// GetProductBySlug finds the product by its current or a former slug,
+// the returned product tells which one is current
+func (psc *ProductServiceContext) GetProductBySlug(ctx context.Context, slug string, view *model.PriceView) (*model.Product, error) {
+	id, err := psc.store.ResolveSlug(ctx, nil, model.SlugEntityProduct, slug)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, &NotFoundError{Entity: "product", Field: "slug", Value: slug}
+	}
+	return psc.GetProduct(ctx, *id, view)
 }
 
-func (psc *ProductServiceContext) CreateProduct(product *model.Product) (*int, error) {
-	tx, err := psc.store.Begin()
+func (psc *ProductServiceContext) GetProductByBarcode(ctx context.Context, code string, view *model.PriceView) (*model.Product, error) {
+	product, err := psc.store.GetProductByBarcode(ctx, nil, code)
 	if err != nil {
 		return nil, err
 	}
-	cat, err := psc.store.CreateProduct(tx, product)
+	if product == nil {
+		return nil, &NotFoundError{Entity: "product", Field: "barcode", Value: code}
+	}
+	if err = psc.price(ctx, []*model.Product{product}, view); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (psc *ProductServiceContext) GetProducts(ctx context.Context, filter *model.ProductFilter, view *model.PriceView) ([]*model.Product, error) {
+	products, err := psc.store.GetProducts(ctx, nil, filter)
 	if err != nil {
-		psc.store.Rollback(tx)
 		return nil, err
 	}
-	if err = psc.store.Commit(tx); err != nil {
+	if err = psc.price(ctx, products, view); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// ExportProducts streams the stored products, without the promotions and
+// taxes of listings, so that exports of the whole catalog stay small in memory.
+// Derived bundles get the price of their components, only the bundles and
+// their components are kept meanwhile.
+func (psc *ProductServiceContext) ExportProducts(ctx context.Context, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	resolver := newBundleResolver(ctx, psc.store, nil)
+	return psc.store.ExportProducts(ctx, nil, filter, func(exported *model.ExportedProduct) error {
+		if exported.BundlePricing == model.BundlePricingDerived {
+			bundle, err := resolver.get(exported.Id)
+			if err != nil {
+				return err
+			}
+			if bundle != nil {
+				if err = resolver.resolve(bundle); err != nil {
+					return err
+				}
+				exported.Price = bundle.Price
+			}
+		}
+		return fn(exported)
+	})
+}
+
+func (psc *ProductServiceContext) GetBrandFacets(ctx context.Context, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	return psc.store.GetBrandFacets(ctx, nil, filter)
+}
+
+// price computes the effective prices and, when a region is requested,
+// their taxes, so that list and detail responses agree
+func (psc *ProductServiceContext) price(ctx context.Context, products []*model.Product, view *model.PriceView) error {
+	if len(products) == 0 {
+		return nil
+	}
+	if err := resolveBundles(ctx, psc.store, products); err != nil {
+		return err
+	}
+	promotions, err := psc.store.GetActivePromotions(ctx, nil, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		applyPromotions(product, promotions)
+	}
+	if view == nil || view.Region == "" {
+		return nil
+	}
+	rates, err := psc.store.GetTaxRates(ctx, nil, view.Region)
+	if err != nil {
+		return err
+	}
+	if rates == nil {
+		return ErrUnknownRegion
+	}
+	categories, err := psc.store.GetCategories(ctx, nil)
+	if err != nil {
+		return err
+	}
+	categoryTaxClass := make(map[int]*int, len(categories))
+	for _, category := range categories {
+		categoryTaxClass[category.Id] = category.TaxClass
+	}
+	for _, product := range products {
+		taxClass := product.TaxClass
+		if taxClass == nil {
+			taxClass = categoryTaxClass[product.Category]
+		}
+		var rate float64
+		if taxClass != nil {
+			var ok bool
+			if rate, ok = rates[*taxClass]; !ok {
+				return &PreconditionFailedError{fmt.Sprintf("tax class %d has no rate in region %s", *taxClass, view.Region)}
+			}
+		}
+		applyTax(product, view, rate)
+	}
+	return nil
+}
+
+func (psc *ProductServiceContext) CreateProduct(ctx context.Context, product *model.Product) (*int, error) {
+	tx, err := psc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := createProduct(ctx, psc.store, tx, product)
+	if err != nil {
+		psc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = psc.store.Commit(ctx, tx); err != nil {
 		return nil, err
 	}
 	return cat, nil
 }
 
-func (psc *ProductServiceContext) UpdateProduct(product *model.Product) error {
-	tx, err := psc.store.Begin()
+func (psc *ProductServiceContext) UpdateProduct(ctx context.Context, product *model.Product) error {
+	tx, err := psc.store.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	err = psc.store.UpdateProduct(tx, product)
+	err = updateProduct(ctx, psc.store, tx, product)
 	if err != nil {
-		psc.store.Rollback(tx)
+		psc.store.Rollback(ctx, tx)
 		return err
 	}
-	if err = psc.store.Commit(tx); err != nil {
+	if err = psc.store.Commit(ctx, tx); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (psc *ProductServiceContext) DeleteProduct(id int) error {
-	tx, err := psc.store.Begin()
+func (psc *ProductServiceContext) DeleteProduct(ctx context.Context, id int) error {
+	tx, err := psc.store.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	err = psc.store.DeleteProduct(tx, id)
+	err = deleteProduct(ctx, psc.store, tx, id)
 	if err != nil {
-		psc.store.Rollback(tx)
+		psc.store.Rollback(ctx, tx)
 		return err
 	}
-	if err = psc.store.Commit(tx); err != nil {
+	if err = psc.store.Commit(ctx, tx); err != nil {
 		return err
 	}
 	return nil
 }
+
+// createProduct, updateProduct and deleteProduct do the work of the service
+// methods inside a transaction of the caller, shared by batches
+
+func createProduct(ctx context.Context, store db.Store, tx *sql.Tx, product *model.Product) (*int, error) {
+	// New products stay hidden until they are published explicitly or by schedule
+	if product.Status == "" {
+		product.Status = model.ProductStatusDraft
+	}
+	schedule(product, time.Now())
+	if product.Type == "" {
+		product.Type = model.ProductTypeSimple
+	}
+	if err := checkBundle(ctx, store, tx, product); err != nil {
+		return nil, err
+	}
+	if err := checkTaxClass(ctx, store, tx, product.TaxClass); err != nil {
+		return nil, err
+	}
+	if err := checkBrand(ctx, store, tx, product.Brand); err != nil {
+		return nil, err
+	}
+	if err := assignSlug(ctx, store, tx, model.SlugEntityProduct, 0, &product.Slug, product.Name); err != nil {
+		return nil, err
+	}
+	return store.CreateProduct(ctx, tx, product)
+}
+
+func updateProduct(ctx context.Context, store db.Store, tx *sql.Tx, product *model.Product) error {
+	schedule(product, time.Now())
+	if err := checkBundle(ctx, store, tx, product); err != nil {
+		return err
+	}
+	if err := checkTaxClass(ctx, store, tx, product.TaxClass); err != nil {
+		return err
+	}
+	if err := checkBrand(ctx, store, tx, product.Brand); err != nil {
+		return err
+	}
+	if err := assignSlug(ctx, store, tx, model.SlugEntityProduct, product.Id, &product.Slug, product.Name); err != nil {
+		return err
+	}
+	return notFound(store.UpdateProduct(ctx, tx, product), "product", product.Id)
+}
+
+// schedule keeps the products published at a later time as drafts, the
+// scheduler publishes them when the time comes
+func schedule(product *model.Product, now time.Time) {
+	if product.Status == model.ProductStatusPublished && product.PublishAt != nil && product.PublishAt.After(now) {
+		product.Status = model.ProductStatusDraft
+	}
+}
+
+func deleteProduct(ctx context.Context, store db.Store, tx *sql.Tx, id int) error {
+	bundles, err := store.GetBundlesContaining(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if len(bundles) > 0 {
+		return ErrProductInBundle
+	}
+	return notFound(store.DeleteProduct(ctx, tx, id), "product", id)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, promotion *model.Promotion) (*int, error)
+	UpdatePromotion(ctx context.Context, promotion *model.Promotion) error
+	DeletePromotion(ctx context.Context, id int) error
+	GetPromotion(ctx context.Context, id int) (*model.Promotion, error)
+	GetPromotions(ctx context.Context) ([]*model.Promotion, error)
+}
+
+type PromotionServiceContext struct {
+	store db.Store
+}
+
+func NewPromotionService(store db.Store) PromotionService {
+	return &PromotionServiceContext{store: store}
+}
+
+func (prsc *PromotionServiceContext) GetPromotion(ctx context.Context, id int) (*model.Promotion, error) {
+	promotion, err := prsc.store.GetPromotion(ctx, nil, id)
+	if err == nil && promotion == nil {
+		err = &NotFoundError{Entity: "promotion", Field: "id", Value: id}
+	}
+	return promotion, err
+}
+
+func (prsc *PromotionServiceContext) GetPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	return prsc.store.GetPromotions(ctx, nil)
+}
+
+func (prsc *PromotionServiceContext) CreatePromotion(ctx context.Context, promotion *model.Promotion) (*int, error) {
+	tx, err := prsc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := prsc.store.CreatePromotion(ctx, tx, promotion)
+	if err != nil {
+		prsc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = prsc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (prsc *PromotionServiceContext) UpdatePromotion(ctx context.Context, promotion *model.Promotion) error {
+	tx, err := prsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(prsc.store.UpdatePromotion(ctx, tx, promotion), "promotion", promotion.Id)
+	if err != nil {
+		prsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = prsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (prsc *PromotionServiceContext) DeletePromotion(ctx context.Context, id int) error {
+	tx, err := prsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(prsc.store.DeletePromotion(ctx, tx, id), "promotion", id)
+	if err != nil {
+		prsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = prsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// PublicationScheduler periodically publishes and archives products
+// according to their `publish_at` and `unpublish_at` timestamps.
+type PublicationScheduler struct {
+	store    db.Store
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewPublicationScheduler(store db.Store, interval time.Duration) *PublicationScheduler {
+	return &PublicationScheduler{store: store, interval: interval}
+}
+
+// Start runs the scheduler in the background until Stop is called, it
+// doesn't run without a positive interval
+func (s *PublicationScheduler) Start() {
+	if s.interval <= 0 {
+		log.WithField("interval", s.interval).Warn("Publication scheduler disabled")
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			if err := s.RunOnce(time.Now()); err != nil {
+				log.WithError(err).Error("Publication schedule failed")
+			}
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop terminates the background loop and waits for the current run to finish
+func (s *PublicationScheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+// RunOnce applies every schedule entry that is due at `now`
+func (s *PublicationScheduler) RunOnce(now time.Time) error {
+	ctx := context.Background()
+	tx, err := s.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	published, archived, err := s.store.ApplyPublicationSchedule(ctx, tx, now)
+	if err != nil {
+		s.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = s.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	if published > 0 || archived > 0 {
+		log.WithField("published", published).WithField("archived", archived).Info("Publication schedule applied")
+	}
+	return nil
+}
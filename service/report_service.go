@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+type ReportService interface {
+	GetMarginReport(ctx context.Context, filter *model.ProductFilter) (*model.MarginReport, error)
+}
+
+func NewReportService(store db.Store) ReportService {
+	return &ReportServiceContext{store: store}
+}
+
+type ReportServiceContext struct {
+	store db.Store
+}
+
+// GetMarginReport computes the margins of the products matching the filter
+// from their list price, derived from the components for bundles as in
+// listings, and the cost of their preferred supplier. Products without
+// supplier are left out. The statuses of the filter tell whether drafts
+// count: the API reports on the products on sale, drafts on demand.
+func (rsc *ReportServiceContext) GetMarginReport(ctx context.Context, filter *model.ProductFilter) (*model.MarginReport, error) {
+	products, err := rsc.store.GetProducts(ctx, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err = resolveBundles(ctx, rsc.store, products); err != nil {
+		return nil, err
+	}
+	report := &model.MarginReport{Products: []*model.ProductMargin{}, Categories: []*model.CategoryMargin{}}
+	categories := map[int]*model.CategoryMargin{}
+	for _, product := range products {
+		if product.CostPrice == nil {
+			continue
+		}
+		margin := &model.ProductMargin{
+			Product:  product.Id,
+			Name:     product.Name,
+			Category: product.Category,
+			Price:    product.Price,
+			Cost:     *product.CostPrice,
+		}
+		margin.Margin, margin.MarginPercent, margin.MarkupPercent = margins(margin.Price, margin.Cost)
+		report.Products = append(report.Products, margin)
+
+		category, ok := categories[product.Category]
+		if !ok {
+			category = &model.CategoryMargin{Category: product.Category}
+			categories[product.Category] = category
+			report.Categories = append(report.Categories, category)
+		}
+		category.Products++
+		category.Price += margin.Price
+		category.Cost += margin.Cost
+	}
+	for _, category := range report.Categories {
+		category.Price, category.Cost = roundPrice(category.Price), roundPrice(category.Cost)
+		category.Margin, category.MarginPercent, category.MarkupPercent = margins(category.Price, category.Cost)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Category < report.Categories[j].Category
+	})
+	return report, nil
+}
+
+// margins returns the margin of a sale along with its ratio to the price
+// and to the cost, in percent. Ratios to zero are left out.
+func margins(price float64, cost float64) (float64, *float64, *float64) {
+	margin := roundPrice(price - cost)
+	var marginPercent, markupPercent *float64
+	if price != 0 {
+		v := roundPrice(margin / price * 100)
+		marginPercent = &v
+	}
+	if cost != 0 {
+		v := roundPrice(margin / cost * 100)
+		markupPercent = &v
+	}
+	return margin, marginPercent, markupPercent
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+type ReviewService interface {
+	CreateReview(ctx context.Context, review *model.Review) (*int, error)
+	// GetReviews returns the reviews of a product whose status is one of
+	// `productStatuses`, a product of any status when empty
+	GetReviews(ctx context.Context, product int, status string, productStatuses []string) ([]*model.Review, error)
+	ModerateReview(ctx context.Context, id int, status string) error
+}
+
+type ReviewServiceContext struct {
+	store db.Store
+}
+
+func NewReviewService(store db.Store) ReviewService {
+	return &ReviewServiceContext{store: store}
+}
+
+// GetReviews returns the reviews of a product, or a NotFoundError when the
+// product doesn't exist or has another status
+func (rsc *ReviewServiceContext) GetReviews(ctx context.Context, product int, status string, productStatuses []string) ([]*model.Review, error) {
+	p, err := rsc.store.GetProduct(ctx, nil, product)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || (len(productStatuses) > 0 && !contains(productStatuses, p.Status)) {
+		return nil, &NotFoundError{Entity: "product", Field: "id", Value: product}
+	}
+	return rsc.store.GetReviews(ctx, nil, product, status)
+}
+
+// CreateReview stores a review awaiting moderation, or returns a NotFoundError
+// when the product doesn't exist or isn't published
+func (rsc *ReviewServiceContext) CreateReview(ctx context.Context, review *model.Review) (*int, error) {
+	review.Status = model.ReviewStatusPending
+	review.CreatedAt = time.Now()
+	tx, err := rsc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := rsc.store.GetProduct(ctx, tx, review.Product)
+	if err != nil {
+		rsc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if product == nil || product.Status != model.ProductStatusPublished {
+		rsc.store.Rollback(ctx, tx)
+		return nil, &NotFoundError{Entity: "product", Field: "id", Value: review.Product}
+	}
+	id, err := rsc.store.CreateReview(ctx, tx, review)
+	if err != nil {
+		rsc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = rsc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (rsc *ReviewServiceContext) ModerateReview(ctx context.Context, id int, status string) error {
+	tx, err := rsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(rsc.store.SetReviewStatus(ctx, tx, id, status), "review", id)
+	if err != nil {
+		rsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = rsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// contains reports whether `value` is one of `values`
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
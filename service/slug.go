@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// assignSlug makes sure the slug of a product or category is free. New ones
+// without slug get one generated from their name, numbered when needed,
+// updates without slug keep the current one.
+func assignSlug(ctx context.Context, store db.Store, tx *sql.Tx, entity string, id int, slug *string, name string) error {
+	if *slug != "" {
+		owner, err := store.ResolveSlug(ctx, tx, entity, *slug)
+		if err != nil {
+			return err
+		}
+		if owner != nil && *owner != id {
+			return &ConflictError{Entity: entity, Field: "slug", Id: *owner}
+		}
+		return nil
+	}
+	if id != 0 {
+		return nil
+	}
+	base := model.Slugify(name)
+	if base == "" {
+		base = entity
+	}
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			suffix := "-" + strconv.Itoa(n)
+			if len(candidate)+len(suffix) > model.SlugMaxLength {
+				candidate = strings.TrimSuffix(candidate[:model.SlugMaxLength-len(suffix)], "-")
+			}
+			candidate += suffix
+		}
+		owner, err := store.ResolveSlug(ctx, tx, entity, candidate)
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			*slug = candidate
+			return nil
+		}
+	}
+}
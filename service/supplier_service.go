@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+var (
+	// ErrUnknownSupplier is returned when a product is linked to a missing supplier
+	ErrUnknownSupplier error = invalid("supplier", "supplier not found")
+	// ErrPreferredSupplier is returned when several suppliers of a product are preferred
+	ErrPreferredSupplier error = invalid("preferred", "only one supplier can be preferred")
+)
+
+type SupplierService interface {
+	CreateSupplier(ctx context.Context, supplier *model.Supplier) (*int, error)
+	UpdateSupplier(ctx context.Context, supplier *model.Supplier) error
+	DeleteSupplier(ctx context.Context, id int) error
+	GetSupplier(ctx context.Context, id int) (*model.Supplier, error)
+	GetSuppliers(ctx context.Context) ([]*model.Supplier, error)
+	GetProductSuppliers(ctx context.Context, id int) ([]model.ProductSupplier, error)
+	SetProductSuppliers(ctx context.Context, id int, suppliers []model.ProductSupplier) error
+}
+
+type SupplierServiceContext struct {
+	store db.Store
+}
+
+func NewSupplierService(store db.Store) SupplierService {
+	return &SupplierServiceContext{store: store}
+}
+
+func (ssc *SupplierServiceContext) GetSupplier(ctx context.Context, id int) (*model.Supplier, error) {
+	supplier, err := ssc.store.GetSupplier(ctx, nil, id)
+	if err == nil && supplier == nil {
+		err = &NotFoundError{Entity: "supplier", Field: "id", Value: id}
+	}
+	return supplier, err
+}
+
+func (ssc *SupplierServiceContext) GetSuppliers(ctx context.Context) ([]*model.Supplier, error) {
+	return ssc.store.GetSuppliers(ctx, nil)
+}
+
+func (ssc *SupplierServiceContext) CreateSupplier(ctx context.Context, supplier *model.Supplier) (*int, error) {
+	tx, err := ssc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ssc.store.CreateSupplier(ctx, tx, supplier)
+	if err != nil {
+		ssc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = ssc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (ssc *SupplierServiceContext) UpdateSupplier(ctx context.Context, supplier *model.Supplier) error {
+	tx, err := ssc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(ssc.store.UpdateSupplier(ctx, tx, supplier), "supplier", supplier.Id)
+	if err != nil {
+		ssc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = ssc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ssc *SupplierServiceContext) DeleteSupplier(ctx context.Context, id int) error {
+	tx, err := ssc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(ssc.store.DeleteSupplier(ctx, tx, id), "supplier", id)
+	if err != nil {
+		ssc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = ssc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetProductSuppliers returns the suppliers of product `id`,
+// or a NotFoundError when the product doesn't exist
+func (ssc *SupplierServiceContext) GetProductSuppliers(ctx context.Context, id int) ([]model.ProductSupplier, error) {
+	product, err := ssc.store.GetProduct(ctx, nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &NotFoundError{Entity: "product", Field: "id", Value: id}
+	}
+	return ssc.store.GetProductSuppliers(ctx, nil, id)
+}
+
+// SetProductSuppliers replaces the suppliers of product `id`
+func (ssc *SupplierServiceContext) SetProductSuppliers(ctx context.Context, id int, suppliers []model.ProductSupplier) error {
+	preferred := 0
+	for _, supplier := range suppliers {
+		if supplier.Preferred {
+			preferred++
+		}
+	}
+	if preferred > 1 {
+		return ErrPreferredSupplier
+	}
+	tx, err := ssc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = ssc.checkProductSuppliers(ctx, tx, id, suppliers); err != nil {
+		ssc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = ssc.store.SetProductSuppliers(ctx, tx, id, suppliers); err != nil {
+		ssc.store.Rollback(ctx, tx)
+		return err
+	}
+	return ssc.store.Commit(ctx, tx)
+}
+
+// checkProductSuppliers makes sure the product and its suppliers exist
+func (ssc *SupplierServiceContext) checkProductSuppliers(ctx context.Context, tx *sql.Tx, id int, suppliers []model.ProductSupplier) error {
+	product, err := ssc.store.GetProduct(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return &NotFoundError{Entity: "product", Field: "id", Value: id}
+	}
+	for _, link := range suppliers {
+		supplier, err := ssc.store.GetSupplier(ctx, tx, link.Supplier)
+		if err != nil {
+			return err
+		}
+		if supplier == nil {
+			return ErrUnknownSupplier
+		}
+	}
+	return nil
+}
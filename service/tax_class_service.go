@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+// ErrUnknownTaxClass is returned when a product or a category is taxed by a missing tax class
+var ErrUnknownTaxClass error = invalid("tax_class", "tax class not found")
+
+type TaxClassService interface {
+	CreateTaxClass(ctx context.Context, taxClass *model.TaxClass) (*int, error)
+	UpdateTaxClass(ctx context.Context, taxClass *model.TaxClass) error
+	DeleteTaxClass(ctx context.Context, id int) error
+	GetTaxClass(ctx context.Context, id int) (*model.TaxClass, error)
+	GetTaxClasses(ctx context.Context) ([]*model.TaxClass, error)
+}
+
+type TaxClassServiceContext struct {
+	store db.Store
+}
+
+func NewTaxClassService(store db.Store) TaxClassService {
+	return &TaxClassServiceContext{store: store}
+}
+
+func (tcsc *TaxClassServiceContext) GetTaxClass(ctx context.Context, id int) (*model.TaxClass, error) {
+	taxClass, err := tcsc.store.GetTaxClass(ctx, nil, id)
+	if err == nil && taxClass == nil {
+		err = &NotFoundError{Entity: "tax class", Field: "id", Value: id}
+	}
+	return taxClass, err
+}
+
+func (tcsc *TaxClassServiceContext) GetTaxClasses(ctx context.Context) ([]*model.TaxClass, error) {
+	return tcsc.store.GetTaxClasses(ctx, nil)
+}
+
+func (tcsc *TaxClassServiceContext) CreateTaxClass(ctx context.Context, taxClass *model.TaxClass) (*int, error) {
+	tx, err := tcsc.store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := tcsc.store.CreateTaxClass(ctx, tx, taxClass)
+	if err != nil {
+		tcsc.store.Rollback(ctx, tx)
+		return nil, err
+	}
+	if err = tcsc.store.Commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (tcsc *TaxClassServiceContext) UpdateTaxClass(ctx context.Context, taxClass *model.TaxClass) error {
+	tx, err := tcsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(tcsc.store.UpdateTaxClass(ctx, tx, taxClass), "tax class", taxClass.Id)
+	if err != nil {
+		tcsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = tcsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tcsc *TaxClassServiceContext) DeleteTaxClass(ctx context.Context, id int) error {
+	tx, err := tcsc.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	err = notFound(tcsc.store.DeleteTaxClass(ctx, tx, id), "tax class", id)
+	if err != nil {
+		tcsc.store.Rollback(ctx, tx)
+		return err
+	}
+	if err = tcsc.store.Commit(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkTaxClass fails with ErrUnknownTaxClass unless the tax class, if any, exists
+func checkTaxClass(ctx context.Context, store db.Store, tx *sql.Tx, id *int) error {
+	if id == nil {
+		return nil
+	}
+	taxClass, err := store.GetTaxClass(ctx, tx, *id)
+	if err != nil {
+		return err
+	}
+	if taxClass == nil {
+		return ErrUnknownTaxClass
+	}
+	return nil
+}
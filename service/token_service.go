@@ -0,0 +1,250 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/model"
+)
+
+type TokenService interface {
+	// Verify returns the principal of a token signed by a configured key, current
+	// and issued for the API
+	Verify(token string) (*model.Principal, error)
+}
+
+// verificationKey is a key verifying the tokens of a family of algorithms
+type verificationKey struct {
+	// Key id of the JWKS, empty for secrets
+	id string
+	// Algorithm prefix, "HS", "RS" or "ES"
+	family string
+	key    interface{}
+}
+
+type TokenServiceContext struct {
+	keys       []verificationKey
+	methods    []string
+	issuer     string
+	audience   string
+	rolesClaim string
+	roles      map[string]string
+}
+
+// NewTokenService verifies tokens with the secrets and the JWKS file of the configuration
+func NewTokenService(conf *config.Config) (TokenService, error) {
+	tsc := &TokenServiceContext{issuer: conf.Jwt.Issuer, audience: conf.Jwt.Audience,
+		rolesClaim: conf.Jwt.RolesClaim, roles: conf.Jwt.Roles}
+	for _, secret := range conf.Jwt.Secrets {
+		tsc.keys = append(tsc.keys, verificationKey{family: "HS", key: []byte(secret)})
+	}
+	if conf.Jwt.JwksFile != "" {
+		keys, err := readJwks(conf.Jwt.JwksFile)
+		if err != nil {
+			return nil, err
+		}
+		tsc.keys = append(tsc.keys, keys...)
+	}
+	if len(tsc.keys) == 0 {
+		return nil, errors.New("no secret nor JWKS to verify tokens with")
+	}
+	families := map[string][]string{
+		"HS": {"HS256", "HS384", "HS512"},
+		"RS": {"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"},
+		"ES": {"ES256", "ES384", "ES512"},
+	}
+	seen := map[string]bool{}
+	for _, key := range tsc.keys {
+		if !seen[key.family] {
+			tsc.methods = append(tsc.methods, families[key.family]...)
+			seen[key.family] = true
+		}
+	}
+	return tsc, nil
+}
+
+// jwk is a public key of a JWKS, RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA modulus and exponent
+	N string `json:"n"`
+	E string `json:"e"`
+	// Curve and coordinates of EC keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// readJwks reads the RSA and EC signing keys of a JWKS file, keys of other
+// types or uses are skipped
+func readJwks(path string) ([]verificationKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("JWKS %s: %w", path, err)
+	}
+	var keys []verificationKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key verificationKey
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k)
+		case "EC":
+			key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JWKS %s, key %d: %w", path, i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func rsaKey(k jwk) (verificationKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	if n.Sign() == 0 || !e.IsInt64() || e.Int64() < 3 {
+		return verificationKey{}, errors.New("invalid RSA key")
+	}
+	return verificationKey{id: k.Kid, family: "RS", key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+}
+
+func ecKey(k jwk) (verificationKey, error) {
+	curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+	curve, ok := curves[k.Crv]
+	if !ok {
+		return verificationKey{}, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return verificationKey{}, errors.New("point not on curve")
+	}
+	return verificationKey{id: k.Kid, family: "ES", key: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+}
+
+// candidates returns the keys a token may be signed with: the one of its
+// `kid`, or else every key of its algorithm
+func (tsc *TokenServiceContext) candidates(token *jwt.Token) []interface{} {
+	family := token.Method.Alg()[:2]
+	if family == "PS" {
+		family = "RS"
+	}
+	kid, _ := token.Header["kid"].(string)
+	var keys []interface{}
+	for _, key := range tsc.keys {
+		if key.family != family {
+			continue
+		}
+		if kid != "" && key.id == kid {
+			return []interface{}{key.key}
+		}
+		keys = append(keys, key.key)
+	}
+	return keys
+}
+
+func (tsc *TokenServiceContext) Verify(raw string) (*model.Principal, error) {
+	parser := &jwt.Parser{ValidMethods: tsc.methods, UseJSONNumber: true}
+	token, _, err := parser.ParseUnverified(raw, jwt.MapClaims{})
+	if err != nil {
+		return nil, &AuthenticationError{Reason: "malformed token"}
+	}
+	keys := tsc.candidates(token)
+	if len(keys) == 0 {
+		return nil, &AuthenticationError{Reason: "token signed with an unknown key"}
+	}
+	claims := jwt.MapClaims{}
+	for _, key := range keys {
+		claims = jwt.MapClaims{}
+		_, err = parser.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) { return key, nil })
+		// Another key may match a wrong signature
+		var validationError *jwt.ValidationError
+		if !errors.As(err, &validationError) || validationError.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, &AuthenticationError{Reason: "invalid token: " + err.Error()}
+	}
+	now := time.Now().Unix()
+	switch {
+	case !claims.VerifyExpiresAt(now, true):
+		return nil, &AuthenticationError{Reason: "token without expiry"}
+	case tsc.issuer != "" && !claims.VerifyIssuer(tsc.issuer, true):
+		return nil, &AuthenticationError{Reason: "token of another issuer"}
+	case tsc.audience != "" && !claims.VerifyAudience(tsc.audience, true):
+		return nil, &AuthenticationError{Reason: "token for another audience"}
+	}
+	subject, _ := claims["sub"].(string)
+	return &model.Principal{Subject: subject, Role: model.HighestRole(tsc.claimedRoles(claims)...)}, nil
+}
+
+// claimedRoles maps the values of the roles claim, a list or a space separated
+// string, to the roles of the API. Values are taken as roles of the API as
+// they are unless a mapping is configured, then unmapped values are dropped.
+func (tsc *TokenServiceContext) claimedRoles(claims jwt.MapClaims) []string {
+	var values []string
+	switch claim := claims[tsc.rolesClaim].(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []interface{}:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	roles := make([]string, 0, len(values))
+	for _, value := range values {
+		if role, ok := tsc.roles[value]; ok {
+			roles = append(roles, role)
+		} else if len(tsc.roles) == 0 {
+			roles = append(roles, value)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mrlightwood/golang-products-api/service")
+
+// tracedCategoryService runs each call of a category service in a span
+type tracedCategoryService struct {
+	cs CategoryService
+}
+
+// TraceCategoryService returns `cs` tracing its calls
+func TraceCategoryService(cs CategoryService) CategoryService {
+	return &tracedCategoryService{cs: cs}
+}
+
+func (tcs *tracedCategoryService) CreateCategory(ctx context.Context, category *model.Category) (*int, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.CreateCategory")
+	result, err := tcs.cs.CreateCategory(ctx, category)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tcs *tracedCategoryService) UpdateCategory(ctx context.Context, category *model.Category) error {
+	ctx, span := tracer.Start(ctx, "CategoryService.UpdateCategory")
+	err := tcs.cs.UpdateCategory(ctx, category)
+	tracing.End(span, err)
+	return err
+}
+
+func (tcs *tracedCategoryService) DeleteCategory(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "CategoryService.DeleteCategory", trace.WithAttributes(attribute.Int("id", id)))
+	err := tcs.cs.DeleteCategory(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (tcs *tracedCategoryService) GetCategory(ctx context.Context, id int) (*model.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetCategory", trace.WithAttributes(attribute.Int("id", id)))
+	result, err := tcs.cs.GetCategory(ctx, id)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tcs *tracedCategoryService) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetCategoryBySlug")
+	result, err := tcs.cs.GetCategoryBySlug(ctx, slug)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tcs *tracedCategoryService) GetCategories(ctx context.Context) ([]*model.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetCategories")
+	result, err := tcs.cs.GetCategories(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// tracedProductService runs each call of a product service in a span
+type tracedProductService struct {
+	ps ProductService
+}
+
+// TraceProductService returns `ps` tracing its calls
+func TraceProductService(ps ProductService) ProductService {
+	return &tracedProductService{ps: ps}
+}
+
+func (tps *tracedProductService) CreateProduct(ctx context.Context, product *model.Product) (*int, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CreateProduct")
+	result, err := tps.ps.CreateProduct(ctx, product)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) UpdateProduct(ctx context.Context, product *model.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProduct")
+	err := tps.ps.UpdateProduct(ctx, product)
+	tracing.End(span, err)
+	return err
+}
+
+func (tps *tracedProductService) DeleteProduct(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.DeleteProduct", trace.WithAttributes(attribute.Int("id", id)))
+	err := tps.ps.DeleteProduct(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (tps *tracedProductService) GetProduct(ctx context.Context, id int, view *model.PriceView) (*model.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProduct", trace.WithAttributes(attribute.Int("id", id)))
+	result, err := tps.ps.GetProduct(ctx, id, view)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) GetProductBySlug(ctx context.Context, slug string, view *model.PriceView) (*model.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductBySlug")
+	result, err := tps.ps.GetProductBySlug(ctx, slug, view)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) GetProductByBarcode(ctx context.Context, code string, view *model.PriceView) (*model.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductByBarcode")
+	result, err := tps.ps.GetProductByBarcode(ctx, code, view)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) GetProducts(ctx context.Context, filter *model.ProductFilter, view *model.PriceView) ([]*model.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProducts")
+	result, err := tps.ps.GetProducts(ctx, filter, view)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) ExportProducts(ctx context.Context, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	ctx, span := tracer.Start(ctx, "ProductService.ExportProducts")
+	err := tps.ps.ExportProducts(ctx, filter, fn)
+	tracing.End(span, err)
+	return err
+}
+
+func (tps *tracedProductService) GetBrandFacets(ctx context.Context, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetBrandFacets")
+	result, err := tps.ps.GetBrandFacets(ctx, filter)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) GetRelatedProducts(ctx context.Context, id int, relationType string, filter *model.ProductFilter, view *model.PriceView, productStatuses []string) ([]*model.RelatedProduct, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetRelatedProducts", trace.WithAttributes(attribute.Int("id", id)))
+	result, err := tps.ps.GetRelatedProducts(ctx, id, relationType, filter, view, productStatuses)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (tps *tracedProductService) AddProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	ctx, span := tracer.Start(ctx, "ProductService.AddProductRelations", trace.WithAttributes(attribute.Int("id", id)))
+	err := tps.ps.AddProductRelations(ctx, id, relations)
+	tracing.End(span, err)
+	return err
+}
+
+func (tps *tracedProductService) ReplaceProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	ctx, span := tracer.Start(ctx, "ProductService.ReplaceProductRelations", trace.WithAttributes(attribute.Int("id", id)))
+	err := tps.ps.ReplaceProductRelations(ctx, id, relations)
+	tracing.End(span, err)
+	return err
+}
+
+func (tps *tracedProductService) RemoveProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	ctx, span := tracer.Start(ctx, "ProductService.RemoveProductRelations", trace.WithAttributes(attribute.Int("id", id)))
+	err := tps.ps.RemoveProductRelations(ctx, id, relations)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestApiKeyService_Authenticate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	aks := service.NewApiKeyService(mockStore)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	recent := time.Now().Add(-time.Second)
+	// unknown
+	mockStore.EXPECT().GetApiKeyByHash(gomock.Any(), nil, hashKey("pk_unknown")).Return(nil, nil).Times(1)
+	r, e := aks.Authenticate(ctx, "pk_unknown")
+	assert.Equal(t, &service.AuthenticationError{Reason: "unknown API key"}, e)
+	assert.Nil(t, r)
+	// revoked
+	mockStore.EXPECT().GetApiKeyByHash(gomock.Any(), nil, hashKey("pk_revoked")).Return(&model.ApiKey{Id: 1, RevokedAt: &past}, nil).Times(1)
+	_, e = aks.Authenticate(ctx, "pk_revoked")
+	assert.Equal(t, &service.AuthenticationError{Reason: "API key revoked"}, e)
+	// expired
+	mockStore.EXPECT().GetApiKeyByHash(gomock.Any(), nil, hashKey("pk_expired")).Return(&model.ApiKey{Id: 2, ExpiresAt: &past}, nil).Times(1)
+	_, e = aks.Authenticate(ctx, "pk_expired")
+	assert.Equal(t, &service.AuthenticationError{Reason: "API key expired"}, e)
+	// first use is recorded
+	mockStore.EXPECT().GetApiKeyByHash(gomock.Any(), nil, hashKey("pk_valid")).Return(&model.ApiKey{Id: 3, ExpiresAt: &future}, nil).Times(1)
+	mockStore.EXPECT().TouchApiKey(gomock.Any(), nil, 3, gomock.Any()).Return(nil).Times(1)
+	r, e = aks.Authenticate(ctx, "pk_valid")
+	assert.Nil(t, e)
+	assert.Equal(t, 3, r.Id)
+	assert.NotNil(t, r.LastUsedAt)
+	// a recent use isn't recorded again
+	mockStore.EXPECT().GetApiKeyByHash(gomock.Any(), nil, hashKey("pk_valid")).Return(&model.ApiKey{Id: 3, LastUsedAt: &recent}, nil).Times(1)
+	r, e = aks.Authenticate(ctx, "pk_valid")
+	assert.Nil(t, e)
+	assert.Equal(t, &recent, r.LastUsedAt)
+}
+
+func TestApiKeyService_CreateApiKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	aks := service.NewApiKeyService(mockStore)
+	tx := new(sql.Tx)
+	id := 4
+	var stored *model.ApiKey
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateApiKey(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, key *model.ApiKey) (*int, error) {
+		stored = key
+		return &id, nil
+	}).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e := aks.CreateApiKey(ctx, &model.ApiKey{Name: "shop", Scopes: []string{model.ScopeRead}})
+	assert.Nil(t, e)
+	assert.Equal(t, 4, r.Id)
+	assert.True(t, strings.HasPrefix(r.Key, "pk_"))
+	assert.Equal(t, r.Key[:11], r.Prefix)
+	// only the hash is stored
+	assert.Equal(t, hashKey(r.Key), stored.Hash)
+	assert.NotContains(t, stored.Hash, r.Key)
+	assert.False(t, stored.CreatedAt.IsZero())
+}
+
+func TestApiKeyService_RotateApiKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	aks := service.NewApiKeyService(mockStore)
+	tx := new(sql.Tx)
+	// not found
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetApiKey(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	r, e := aks.RotateApiKey(ctx, 1)
+	assert.Equal(t, &service.NotFoundError{Entity: "API key", Field: "id", Value: 1}, e)
+	assert.Nil(t, r)
+	// revoked
+	revoked := time.Now()
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetApiKey(gomock.Any(), tx, 2).Return(&model.ApiKey{Id: 2, RevokedAt: &revoked}, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	_, e = aks.RotateApiKey(ctx, 2)
+	assert.IsType(t, &service.PreconditionFailedError{}, e)
+	// rotated
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetApiKey(gomock.Any(), tx, 3).Return(&model.ApiKey{Id: 3, Name: "shop", Prefix: "pk_former", Hash: "former"}, nil).Times(1)
+	mockStore.EXPECT().SetApiKeyHash(gomock.Any(), tx, 3, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, id int, prefix string, hash string) error {
+		assert.NotEqual(t, "former", hash)
+		assert.NotEqual(t, "pk_former", prefix)
+		return nil
+	}).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e = aks.RotateApiKey(ctx, 3)
+	assert.Nil(t, e)
+	assert.Equal(t, "shop", r.Name)
+	assert.Equal(t, hashKey(r.Key), r.Hash)
+}
+
+func TestApiKeyService_RevokeApiKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	aks := service.NewApiKeyService(mockStore)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().RevokeApiKey(gomock.Any(), tx, 1, gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	e := aks.RevokeApiKey(ctx, 1)
+	assert.Equal(t, &service.NotFoundError{Entity: "API key", Field: "id", Value: 1}, e)
+
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().RevokeApiKey(gomock.Any(), tx, 2, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	assert.Nil(t, aks.RevokeApiKey(ctx, 2))
+}
+
+func TestApiKeyService_Bootstrap(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	aks := service.NewApiKeyService(mockStore)
+	tx := new(sql.Tx)
+	// keys exist
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CountApiKeys(gomock.Any(), tx).Return(2, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e := aks.Bootstrap(ctx, "")
+	assert.Nil(t, e)
+	assert.Nil(t, r)
+	// first startup, from the configured key
+	id := 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CountApiKeys(gomock.Any(), tx).Return(0, nil).Times(1)
+	mockStore.EXPECT().CreateApiKey(gomock.Any(), tx, gomock.Any()).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	configured := "pk_configured0123456789abcdefghijklmnop"
+	r, e = aks.Bootstrap(ctx, configured)
+	assert.Nil(t, e)
+	assert.Equal(t, configured, r.Key)
+	assert.Equal(t, hashKey(configured), r.Hash)
+	assert.Equal(t, configured[:11], r.Prefix)
+	assert.Equal(t, []string{model.ScopeAdmin}, r.Scopes)
+	// configured keys which would be shown in full, or mostly
+	for _, key := range []string{"secret", "pk_short", "admin-key-of-the-catalog-0123456789abcdef"} {
+		r, e = aks.Bootstrap(ctx, key)
+		assert.IsType(t, &service.ValidationError{}, e)
+		assert.Nil(t, r)
+	}
+}
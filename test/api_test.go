@@ -1,21 +1,33 @@
 package test
 
 import (
-	"database/sql"
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/mrlightwood/golang-products-api/api"
 	"github.com/mrlightwood/golang-products-api/config"
 	"github.com/mrlightwood/golang-products-api/helpers"
+	"github.com/mrlightwood/golang-products-api/logging"
 	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
 	"github.com/mrlightwood/golang-products-api/test/mock"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 func TestApi_GetCategories(t *testing.T) {
@@ -23,19 +35,19 @@ func TestApi_GetCategories(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 0}
 	cs := mock.NewMockCategoryService(mockCtrl)
-	api := api.NewApi(conf, cs, nil)
+	api := api.NewApi(conf, api.Services{Categories: cs})
 	req := httptest.NewRequest(echo.GET, "/api/categories", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 	rec := httptest.NewRecorder()
 	var cats []*model.Category
-	cs.EXPECT().GetCategories().Return(cats, nil).Times(1)
+	cs.EXPECT().GetCategories(gomock.Any()).Return(cats, nil).Times(1)
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
 	cats = append(cats, &model.Category{Id: 1, Name: "CatName1"})
 	cats = append(cats, &model.Category{Id: 2, Name: "CatName2"})
-	cs.EXPECT().GetCategories().Return(cats, nil).Times(1)
+	cs.EXPECT().GetCategories(gomock.Any()).Return(cats, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -48,17 +60,17 @@ func TestApi_GetCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	cs := mock.NewMockCategoryService(mockCtrl)
-	api := api.NewApi(conf, cs, nil)
+	api := api.NewApi(conf, api.Services{Categories: cs})
 	req := httptest.NewRequest(echo.GET, "/api/categories/2", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	// 404
 	rec := httptest.NewRecorder()
-	cs.EXPECT().GetCategory(2).Return(nil, nil).Times(1)
+	cs.EXPECT().GetCategory(gomock.Any(), 2).Return(nil, &service.NotFoundError{Entity: "category", Field: "id", Value: 2}).Times(1)
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	// 200
 	cat := &model.Category{Id: 2, Name: "Name2"}
-	cs.EXPECT().GetCategory(2).Return(cat, nil).Times(1)
+	cs.EXPECT().GetCategory(gomock.Any(), 2).Return(cat, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -71,7 +83,7 @@ func TestApi_CreateCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	cs := mock.NewMockCategoryService(mockCtrl)
-	api := api.NewApi(conf, cs, nil)
+	api := api.NewApi(conf, api.Services{Categories: cs})
 	// 400
 	catJSON := `{"name": "te"}`
 	req := httptest.NewRequest(echo.POST, "/api/categories/", strings.NewReader(catJSON))
@@ -84,7 +96,7 @@ func TestApi_CreateCategory(t *testing.T) {
 	id := 2
 	req = httptest.NewRequest(echo.POST, "/api/categories/", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	cs.EXPECT().CreateCategory(gomock.Any()).Return(&id, nil).Times(1)
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusCreated, rec.Code)
@@ -98,7 +110,7 @@ func TestApi_UpdateCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	cs := mock.NewMockCategoryService(mockCtrl)
-	api := api.NewApi(conf, cs, nil)
+	api := api.NewApi(conf, api.Services{Categories: cs})
 	// 400
 	catJSON := `{"name": "te"}`
 	req := httptest.NewRequest(echo.PUT, "/api/categories/2", strings.NewReader(catJSON))
@@ -110,15 +122,25 @@ func TestApi_UpdateCategory(t *testing.T) {
 	catJSON = `{"name": "test"}`
 	req = httptest.NewRequest(echo.PUT, "/api/categories/1", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	cs.EXPECT().UpdateCategory(gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	cs.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Return(&service.NotFoundError{Entity: "category", Field: "id", Value: 1}).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 409
+	req = httptest.NewRequest(echo.PUT, "/api/categories/2", strings.NewReader(catJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	cs.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Return(&service.ConflictError{Entity: "category", Field: "name", Id: 4}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"name already in use by category 4","instance":"/api/categories/2","field":"name","id":4}`,
+		helpers.RemoveNewLine(rec.Body.String()))
 	// 204
 	catJSON = `{"name": "test"}`
 	req = httptest.NewRequest(echo.PUT, "/api/categories/2", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	cs.EXPECT().UpdateCategory(gomock.Any()).Return(nil).Times(1)
+	cs.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNoContent, rec.Code)
@@ -129,17 +151,17 @@ func TestApi_DeleteCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	cs := mock.NewMockCategoryService(mockCtrl)
-	api := api.NewApi(conf, cs, nil)
+	api := api.NewApi(conf, api.Services{Categories: cs})
 	// 404
 	req := httptest.NewRequest(echo.DELETE, "/api/categories/1", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	cs.EXPECT().DeleteCategory(gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	cs.EXPECT().DeleteCategory(gomock.Any(), gomock.Any()).Return(&service.NotFoundError{Entity: "category", Field: "id", Value: 1}).Times(1)
 	rec := httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	// 201
 	req = httptest.NewRequest(echo.DELETE, "/api/categories/2", nil)
-	cs.EXPECT().DeleteCategory(gomock.Any()).Return(nil).Times(1)
+	cs.EXPECT().DeleteCategory(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNoContent, rec.Code)
@@ -150,20 +172,20 @@ func TestApi_GetProducts(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	ps := mock.NewMockProductService(mockCtrl)
-	api := api.NewApi(conf, nil, ps)
+	api := api.NewApi(conf, api.Services{Products: ps})
 	req := httptest.NewRequest(echo.GET, "/api/products", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	// 200 [] - ничего не найдено
 	rec := httptest.NewRecorder()
 	var cats []*model.Product
-	ps.EXPECT().GetProducts(gomock.Any()).Return(cats, nil).Times(1)
+	ps.EXPECT().GetProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(cats, nil).Times(1)
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
 	// 200 - ок
 	cats = append(cats, &model.Product{Id: 1, Name: "Name1"})
 	cats = append(cats, &model.Product{Id: 2, Name: "Name2"})
-	ps.EXPECT().GetProducts(gomock.Any()).Return(cats, nil).Times(1)
+	ps.EXPECT().GetProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(cats, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -174,29 +196,56 @@ func TestApi_GetProducts(t *testing.T) {
 	req = httptest.NewRequest(echo.GET, "/api/products?category=2", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	id := 2
-	ps.EXPECT().GetProducts(&id).Return(cats, nil).Times(1)
+	ps.EXPECT().GetProducts(gomock.Any(), &model.ProductFilter{Category: &id, Statuses: []string{model.ProductStatusPublished}}, gomock.Any()).Return(cats, nil).Times(1)
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	res, _ = json.Marshal(cats)
 	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), string(res))
 }
 
+func TestApi_GetProductsDrafts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 403 - no editor token
+	req := httptest.NewRequest(echo.GET, "/api/products?include=drafts", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// 403 - wrong editor token
+	req = httptest.NewRequest(echo.GET, "/api/products?include=drafts", nil)
+	req.Header.Set("X-Editor-Token", "wrong")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// 200
+	req = httptest.NewRequest(echo.GET, "/api/products?include=drafts", nil)
+	req.Header.Set("X-Editor-Token", "secret")
+	ps.EXPECT().GetProducts(gomock.Any(), &model.ProductFilter{Statuses: []string{model.ProductStatusPublished, model.ProductStatusDraft}}, gomock.Any()).Return(nil, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
 func TestApi_GetProduct(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	ps := mock.NewMockProductService(mockCtrl)
-	api := api.NewApi(conf, nil, ps)
+	api := api.NewApi(conf, api.Services{Products: ps})
 	req := httptest.NewRequest(echo.GET, "/api/products/2", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	// 404
 	rec := httptest.NewRecorder()
-	ps.EXPECT().GetProduct(2).Return(nil, nil).Times(1)
+	ps.EXPECT().GetProduct(gomock.Any(), 2, gomock.Any()).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 2}).Times(1)
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	// 200
-	cat := &model.Product{Id: 2, Name: "Name2"}
-	ps.EXPECT().GetProduct(2).Return(cat, nil).Times(1)
+	cat := &model.Product{Id: 2, Name: "Name2", Status: model.ProductStatusPublished}
+	ps.EXPECT().GetProduct(gomock.Any(), 2, gomock.Any()).Return(cat, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -209,7 +258,7 @@ func TestApi_CreateProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	ps := mock.NewMockProductService(mockCtrl)
-	api := api.NewApi(conf, nil, ps)
+	api := api.NewApi(conf, api.Services{Products: ps})
 	// 400
 	catJSON := `{"name": "test","description":"test","category":1}`
 	req := httptest.NewRequest(echo.POST, "/api/products/", strings.NewReader(catJSON))
@@ -217,12 +266,19 @@ func TestApi_CreateProduct(t *testing.T) {
 	rec := httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 400 - unpublish before publish
+	catJSON = `{"name": "test","price":101.5,"publish_at":"2030-01-02T00:00:00Z","unpublish_at":"2030-01-01T00:00:00Z"}`
+	req = httptest.NewRequest(echo.POST, "/api/products/", strings.NewReader(catJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	// 200
 	catJSON = `{"name": "test","description":"test","category":1,"price":101.5}`
 	id := 2
 	req = httptest.NewRequest(echo.POST, "/api/products/", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	ps.EXPECT().CreateProduct(gomock.Any()).Return(&id, nil).Times(1)
+	ps.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusCreated, rec.Code)
@@ -236,7 +292,7 @@ func TestApi_UpdateProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	ps := mock.NewMockProductService(mockCtrl)
-	api := api.NewApi(conf, nil, ps)
+	api := api.NewApi(conf, api.Services{Products: ps})
 	// 400
 	catJSON := `{"name": "test","description":"test","category":1}`
 	req := httptest.NewRequest(echo.PUT, "/api/products/2", strings.NewReader(catJSON))
@@ -248,7 +304,7 @@ func TestApi_UpdateProduct(t *testing.T) {
 	catJSON = `{"name": "test","description":"test","category":1,"price":101.5}`
 	req = httptest.NewRequest(echo.PUT, "/api/products/1", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	ps.EXPECT().UpdateProduct(gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	ps.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(&service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
@@ -256,7 +312,7 @@ func TestApi_UpdateProduct(t *testing.T) {
 	catJSON = `{"name": "test","description":"test","category":1,"price":101.5}`
 	req = httptest.NewRequest(echo.PUT, "/api/products/2", strings.NewReader(catJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	ps.EXPECT().UpdateProduct(gomock.Any()).Return(nil).Times(1)
+	ps.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNoContent, rec.Code)
@@ -267,18 +323,1594 @@ func TestApi_DeleteProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 	conf := &config.Config{LogLevel: 5}
 	ps := mock.NewMockProductService(mockCtrl)
-	api := api.NewApi(conf, nil, ps)
+	api := api.NewApi(conf, api.Services{Products: ps})
 	// 404
 	req := httptest.NewRequest(echo.DELETE, "/api/products/1", nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	ps.EXPECT().DeleteProduct(gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	ps.EXPECT().DeleteProduct(gomock.Any(), gomock.Any()).Return(&service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
 	rec := httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	// 201
 	req = httptest.NewRequest(echo.DELETE, "/api/products/2", nil)
-	ps.EXPECT().DeleteProduct(gomock.Any()).Return(nil).Times(1)
+	ps.EXPECT().DeleteProduct(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestApi_GetPromotions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	prs := mock.NewMockPromotionService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Promotions: prs})
+	req := httptest.NewRequest(echo.GET, "/api/promotions", nil)
+	rec := httptest.NewRecorder()
+	prs.EXPECT().GetPromotions(gomock.Any()).Return(nil, nil).Times(1)
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
+	// 404
+	req = httptest.NewRequest(echo.GET, "/api/promotions/2", nil)
+	rec = httptest.NewRecorder()
+	prs.EXPECT().GetPromotion(gomock.Any(), 2).Return(nil, &service.NotFoundError{Entity: "promotion", Field: "id", Value: 2}).Times(1)
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 200
+	promotion := &model.Promotion{Id: 2, Name: "Spring sale", Type: model.PromotionTypePercentage, Value: 10, Tags: []string{"spring"}}
+	prs.EXPECT().GetPromotion(gomock.Any(), 2).Return(promotion, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res, _ := json.Marshal(promotion)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), string(res))
+}
+
+func TestApi_CreatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	prs := mock.NewMockPromotionService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Promotions: prs})
+	for _, body := range []string{
+		// no target
+		`{"name": "test","type":"fixed","value":5}`,
+		// more than 100%
+		`{"name": "test","type":"percentage","value":150,"categories":[1]}`,
+		// unknown type
+		`{"name": "test","type":"free","value":5,"categories":[1]}`,
+		// ends before start
+		`{"name": "test","type":"fixed","value":5,"categories":[1],"starts_at":"2030-01-02T00:00:00Z","ends_at":"2030-01-01T00:00:00Z"}`,
+	} {
+		req := httptest.NewRequest(echo.POST, "/api/promotions", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	// 201
+	id := 2
+	req := httptest.NewRequest(echo.POST, "/api/promotions", strings.NewReader(`{"name": "test","type":"percentage","value":15,"tags":["sale"]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	prs.EXPECT().CreatePromotion(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var res map[string]int
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.Equal(t, res["id"], 2)
+}
+
+func TestApi_UpdatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	prs := mock.NewMockPromotionService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Promotions: prs})
+	body := `{"name": "test","type":"fixed","value":5,"products":[1]}`
+	// 404
+	req := httptest.NewRequest(echo.PUT, "/api/promotions/1", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	prs.EXPECT().UpdatePromotion(gomock.Any(), gomock.Any()).Return(&service.NotFoundError{Entity: "promotion", Field: "id", Value: 1}).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 204
+	req = httptest.NewRequest(echo.PUT, "/api/promotions/2", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	prs.EXPECT().UpdatePromotion(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestApi_DeletePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	prs := mock.NewMockPromotionService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Promotions: prs})
+	// 404
+	req := httptest.NewRequest(echo.DELETE, "/api/promotions/1", nil)
+	prs.EXPECT().DeletePromotion(gomock.Any(), 1).Return(&service.NotFoundError{Entity: "promotion", Field: "id", Value: 1}).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 204
+	req = httptest.NewRequest(echo.DELETE, "/api/promotions/2", nil)
+	prs.EXPECT().DeletePromotion(gomock.Any(), 2).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestApi_GetProductsPrices(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	for _, query := range []string{"region=DEU", "region=D1", "prices=foo", "prices=gross"} {
+		req := httptest.NewRequest(echo.GET, "/api/products?"+query, nil)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+	// 400 - no rates for the region
+	req := httptest.NewRequest(echo.GET, "/api/products/1?region=xx", nil)
+	ps.EXPECT().GetProduct(gomock.Any(), 1, &model.PriceView{Region: "XX"}).Return(nil, service.ErrUnknownRegion).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 200
+	req = httptest.NewRequest(echo.GET, "/api/products?region=de&prices=gross", nil)
+	ps.EXPECT().GetProducts(gomock.Any(), gomock.Any(), &model.PriceView{Region: "DE", Prices: model.PricesGross}).Return(nil, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestApi_CreateTaxClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	tcs := mock.NewMockTaxClassService(mockCtrl)
+	api := api.NewApi(conf, api.Services{TaxClasses: tcs})
+	for _, body := range []string{
+		`{"name": "st"}`,
+		`{"name": "standard","rates":{"de":19}}`,
+		`{"name": "standard","rates":{"DEU":19}}`,
+		`{"name": "standard","rates":{"DE":119}}`,
+	} {
+		req := httptest.NewRequest(echo.POST, "/api/tax-classes", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	// 201
+	id := 3
+	req := httptest.NewRequest(echo.POST, "/api/tax-classes", strings.NewReader(`{"name": "standard","rates":{"DE":19,"FR":20}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	tcs.EXPECT().CreateTaxClass(gomock.Any(), &model.TaxClass{Name: "standard", Rates: map[string]float64{"DE": 19, "FR": 20}}).Return(&id, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.DELETE, "/api/tax-classes/4", nil)
+	tcs.EXPECT().DeleteTaxClass(gomock.Any(), 4).Return(&service.NotFoundError{Entity: "tax class", Field: "id", Value: 4}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestApi_CreateBundle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	for _, body := range []string{
+		// no components
+		`{"name": "kit","type":"bundle","price":10}`,
+		// components of a simple product
+		`{"name": "kit","price":10,"components":[{"product":1,"quantity":1}]}`,
+		// duplicated component
+		`{"name": "kit","type":"bundle","price":10,"components":[{"product":1,"quantity":1},{"product":1,"quantity":2}]}`,
+		// zero quantity
+		`{"name": "kit","type":"bundle","price":10,"components":[{"product":1,"quantity":0}]}`,
+		// fixed price bundle without price
+		`{"name": "kit","type":"bundle","components":[{"product":1,"quantity":1}]}`,
+	} {
+		req := httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	// 400 - unknown component
+	body := `{"name": "kit","type":"bundle","bundle_pricing":"derived","components":[{"product":1,"quantity":1}]}`
+	req := httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(nil, service.ErrUnknownComponent).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 201
+	id := 5
+	req = httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// 400 - cycle
+	req = httptest.NewRequest(echo.PUT, "/api/products/1", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(service.ErrBundleCycle).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 412 - deleting a component
+	req = httptest.NewRequest(echo.DELETE, "/api/products/1", nil)
+	ps.EXPECT().DeleteProduct(gomock.Any(), 1).Return(service.ErrProductInBundle).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
+
+func TestApi_GetRelatedProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.GET, "/api/products/1/related?type=cousin-of", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.GET, "/api/products/1/related", nil)
+	ps.EXPECT().GetRelatedProducts(gomock.Any(), 1, "", gomock.Any(), gomock.Any(), []string{model.ProductStatusPublished}).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 200 []
+	req = httptest.NewRequest(echo.GET, "/api/products/1/related?type=accessory-of", nil)
+	filter := &model.ProductFilter{Statuses: []string{model.ProductStatusPublished}}
+	ps.EXPECT().GetRelatedProducts(gomock.Any(), 1, model.RelationAccessoryOf, filter, gomock.Any(), filter.Statuses).Return(nil, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
+	// 200
+	related := []*model.RelatedProduct{{Type: model.RelationAccessoryOf, Product: &model.Product{Id: 2, Name: "lens"}}}
+	ps.EXPECT().GetRelatedProducts(gomock.Any(), 1, model.RelationAccessoryOf, filter, gomock.Any(), filter.Statuses).Return(related, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res, _ := json.Marshal(related)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), string(res))
+}
+
+func TestApi_ChangeRelatedProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	body := `[{"type":"cousin-of","product":2}]`
+	req := httptest.NewRequest(echo.POST, "/api/products/1/related", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 400 - self relation
+	body = `[{"type":"up-sell","product":1}]`
+	relations := []model.ProductRelation{{Type: model.RelationUpSell, Product: 1}}
+	req = httptest.NewRequest(echo.POST, "/api/products/1/related", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().AddProductRelations(gomock.Any(), 1, relations).Return(service.ErrSelfRelation).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.PUT, "/api/products/3/related", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().ReplaceProductRelations(gomock.Any(), 3, relations).Return(&service.NotFoundError{Entity: "product", Field: "id", Value: 3}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 204
+	req = httptest.NewRequest(echo.DELETE, "/api/products/2/related", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().RemoveProductRelations(gomock.Any(), 2, relations).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestApi_GetReviews(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	rs := mock.NewMockReviewService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Reviews: rs})
+	// 403
+	req := httptest.NewRequest(echo.GET, "/api/products/1/reviews?status=pending", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// 200 - editor
+	req.Header.Set("X-Editor-Token", "secret")
+	rs.EXPECT().GetReviews(gomock.Any(), 1, model.ReviewStatusPending, nil).Return(nil, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
+	// 404
+	req = httptest.NewRequest(echo.GET, "/api/products/2/reviews", nil)
+	rs.EXPECT().GetReviews(gomock.Any(), 2, model.ReviewStatusApproved, []string{model.ProductStatusPublished}).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 2}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestApi_CreateReview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	rs := mock.NewMockReviewService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Reviews: rs})
+	// 400
+	for _, body := range []string{
+		`{"rating":6,"title":"Great","author_name":"Ann"}`,
+		`{"rating":0,"title":"Great","author_name":"Ann"}`,
+		`{"rating":5,"author_name":"Ann"}`,
+		`{"rating":5,"title":"Great"}`,
+	} {
+		req := httptest.NewRequest(echo.POST, "/api/products/1/reviews", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	body := `{"rating":5,"title":"Great","body":"Sharp pictures","author_name":"Ann"}`
+	// 404
+	req := httptest.NewRequest(echo.POST, "/api/products/1/reviews", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rs.EXPECT().CreateReview(gomock.Any(), gomock.Any()).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 201
+	id := 4
+	req = httptest.NewRequest(echo.POST, "/api/products/1/reviews", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rs.EXPECT().CreateReview(gomock.Any(), &model.Review{Product: 1, Rating: 5, Title: "Great", Body: "Sharp pictures", AuthorName: "Ann"}).Return(&id, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestApi_ModerateReview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	rs := mock.NewMockReviewService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Reviews: rs})
+	// 403
+	req := httptest.NewRequest(echo.PUT, "/api/reviews/1/moderation", strings.NewReader(`{"status":"approved"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// 400
+	req = httptest.NewRequest(echo.PUT, "/api/reviews/1/moderation", strings.NewReader(`{"status":"maybe"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.PUT, "/api/reviews/1/moderation", strings.NewReader(`{"status":"approved"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	rs.EXPECT().ModerateReview(gomock.Any(), 1, model.ReviewStatusApproved).Return(&service.NotFoundError{Entity: "review", Field: "id", Value: 1}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 204
+	req = httptest.NewRequest(echo.PUT, "/api/reviews/2/moderation", strings.NewReader(`{"status":"rejected"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	rs.EXPECT().ModerateReview(gomock.Any(), 2, model.ReviewStatusRejected).Return(nil).Times(1)
 	rec = httptest.NewRecorder()
 	api.Http.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusNoContent, rec.Code)
 }
+
+func TestApi_GetProductsSorted(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.GET, "/api/products?sort=-popularity", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 200
+	req = httptest.NewRequest(echo.GET, "/api/products?sort=-average_rating", nil)
+	ps.EXPECT().GetProducts(gomock.Any(), &model.ProductFilter{Statuses: []string{model.ProductStatusPublished}, Sort: "-average_rating"}, gomock.Any()).Return(nil, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestApi_Brands(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	bs := mock.NewMockBrandService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Brands: bs})
+	// GET 200
+	req := httptest.NewRequest(echo.GET, "/api/brands", nil)
+	bs.EXPECT().GetBrands(gomock.Any()).Return(nil, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, helpers.RemoveNewLine(rec.Body.String()), "[]")
+	// GET 404
+	req = httptest.NewRequest(echo.GET, "/api/brands/2", nil)
+	bs.EXPECT().GetBrand(gomock.Any(), 2).Return(nil, &service.NotFoundError{Entity: "brand", Field: "id", Value: 2}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// POST 400
+	req = httptest.NewRequest(echo.POST, "/api/brands", strings.NewReader(`{"name": ""}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// POST 201
+	id := 3
+	req = httptest.NewRequest(echo.POST, "/api/brands", strings.NewReader(`{"name": "LG"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	bs.EXPECT().CreateBrand(gomock.Any(), &model.Brand{Name: "LG"}).Return(&id, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// PUT 404
+	req = httptest.NewRequest(echo.PUT, "/api/brands/3", strings.NewReader(`{"name": "LG"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	bs.EXPECT().UpdateBrand(gomock.Any(), &model.Brand{Id: 3, Name: "LG"}).Return(&service.NotFoundError{Entity: "brand", Field: "id", Value: 3}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// DELETE 204
+	req = httptest.NewRequest(echo.DELETE, "/api/brands/3", nil)
+	bs.EXPECT().DeleteBrand(gomock.Any(), 3).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestApi_GetProductsBrandFacets(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.GET, "/api/products?facets=colors", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 200
+	brand := 4
+	filter := &model.ProductFilter{Brand: &brand, Statuses: []string{model.ProductStatusPublished}}
+	req = httptest.NewRequest(echo.GET, "/api/products?brand=4&facets=brands", nil)
+	ps.EXPECT().GetProducts(gomock.Any(), filter, gomock.Any()).Return(nil, nil).Times(1)
+	ps.EXPECT().GetBrandFacets(gomock.Any(), filter).Return([]*model.BrandFacet{{Brand: 4, Name: "Acme", Count: 2}}, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"products":[],"facets":{"brands":[{"brand":4,"name":"Acme","count":2}]}}`, helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_GetProductBySlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 404
+	req := httptest.NewRequest(echo.GET, "/api/products/by-slug/none", nil)
+	ps.EXPECT().GetProductBySlug(gomock.Any(), "none", gomock.Any()).Return(nil, &service.NotFoundError{Entity: "product", Field: "slug", Value: "none"}).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 200
+	product := &model.Product{Id: 2, Name: "Smart TV", Slug: "smart-tv", Status: model.ProductStatusPublished}
+	req = httptest.NewRequest(echo.GET, "/api/products/by-slug/smart-tv", nil)
+	ps.EXPECT().GetProductBySlug(gomock.Any(), "smart-tv", gomock.Any()).Return(product, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res, _ := json.Marshal(product)
+	assert.Equal(t, string(res), helpers.RemoveNewLine(rec.Body.String()))
+	// 301
+	req = httptest.NewRequest(echo.GET, "/api/products/by-slug/tv?region=de", nil)
+	ps.EXPECT().GetProductBySlug(gomock.Any(), "tv", gomock.Any()).Return(product, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/api/products/by-slug/smart-tv?region=de", rec.Header().Get(echo.HeaderLocation))
+}
+
+func TestApi_GetCategoryBySlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs})
+	req := httptest.NewRequest(echo.GET, "/api/categories/by-slug/tvs", nil)
+	cs.EXPECT().GetCategoryBySlug(gomock.Any(), "tvs").Return(&model.Category{Id: 1, Name: "Televisions", Slug: "televisions"}, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/api/categories/by-slug/televisions", rec.Header().Get(echo.HeaderLocation))
+}
+
+func TestApi_ProductSlugConflict(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(`{"name": "Smart TV", "price": 1, "slug": "Smart TV"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 409
+	req = httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(`{"name": "Smart TV", "price": 1, "slug": "smart-tv"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(nil, &service.ConflictError{Entity: "product", Field: "slug", Id: 3}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"slug already in use by product 3","instance":"/api/products","field":"slug","id":3}`,
+		helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_GetProductByBarcode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.GET, "/api/products/by-barcode/96385075", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.GET, "/api/products/by-barcode/96385074", nil)
+	ps.EXPECT().GetProductByBarcode(gomock.Any(), "96385074", gomock.Any()).Return(nil, &service.NotFoundError{Entity: "product", Field: "barcode", Value: "96385074"}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// 200
+	product := &model.Product{Id: 1, Name: "scanned", Barcode: "96385074", Status: model.ProductStatusPublished}
+	ps.EXPECT().GetProductByBarcode(gomock.Any(), "96385074", gomock.Any()).Return(product, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res, _ := json.Marshal(product)
+	assert.Equal(t, string(res), helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_ProductBarcode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(`{"name": "scanned", "price": 1, "barcode": "4006381333932"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 409
+	req = httptest.NewRequest(echo.PUT, "/api/products/2", strings.NewReader(`{"name": "scanned", "price": 1, "barcode": "4006381333931"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ps.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(&service.ConflictError{Entity: "product", Field: "barcode", Id: 1}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestApi_ProductUnits(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	for _, url := range []string{"/api/products?units=stones", "/api/products?weight_max=-1", "/api/products?height_max=tall"} {
+		req := httptest.NewRequest(echo.GET, url, nil)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, url)
+	}
+	req := httptest.NewRequest(echo.POST, "/api/products", strings.NewReader(`{"name": "parcel", "price": 1, "weight": 0}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// imperial filters are converted
+	req = httptest.NewRequest(echo.GET, "/api/products?units=imperial&weight_max=10", nil)
+	weight := 2.0
+	ps.EXPECT().GetProducts(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, filter *model.ProductFilter, view *model.PriceView) ([]*model.Product, error) {
+		assert.InDelta(t, 4.5359237, *filter.WeightMax, 1e-9)
+		return []*model.Product{{Id: 1, Weight: &weight}}, nil
+	}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var products []*model.Product
+	json.Unmarshal(rec.Body.Bytes(), &products)
+	assert.InDelta(t, 4.40924524, *products[0].Weight, 1e-6)
+	// imperial input is stored metric
+	req = httptest.NewRequest(echo.POST, "/api/products?units=imperial", strings.NewReader(`{"name": "parcel", "price": 1, "length": 100}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	id := 1
+	ps.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, product *model.Product) (*int, error) {
+		assert.InDelta(t, 2.54, *product.Length, 1e-9)
+		return &id, nil
+	}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestApi_Suppliers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	ss := mock.NewMockSupplierService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Suppliers: ss})
+	// 403
+	for _, url := range []string{"/api/suppliers", "/api/suppliers/1", "/api/products/1/suppliers"} {
+		req := httptest.NewRequest(echo.GET, url, nil)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, url)
+	}
+	// 201
+	id := 1
+	req := httptest.NewRequest(echo.POST, "/api/suppliers", strings.NewReader(`{"name": "Acme", "email": "sales@acme.test"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	ss.EXPECT().CreateSupplier(gomock.Any(), &model.Supplier{Name: "Acme", Email: "sales@acme.test"}).Return(&id, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// 400 duplicate supplier
+	body := `[{"supplier": 1, "sku": "A-1", "cost_price": 5}, {"supplier": 1, "sku": "A-2", "cost_price": 6}]`
+	req = httptest.NewRequest(echo.PUT, "/api/products/2/suppliers", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 400 unknown supplier
+	body = `[{"supplier": 9, "sku": "A-1", "cost_price": 5, "preferred": true}]`
+	req = httptest.NewRequest(echo.PUT, "/api/products/2/suppliers", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Editor-Token", "secret")
+	ss.EXPECT().SetProductSuppliers(gomock.Any(), 2, []model.ProductSupplier{{Supplier: 9, SKU: "A-1", CostPrice: 5, Preferred: true}}).Return(service.ErrUnknownSupplier).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 404
+	req = httptest.NewRequest(echo.GET, "/api/products/2/suppliers", nil)
+	req.Header.Set("X-Editor-Token", "secret")
+	ss.EXPECT().GetProductSuppliers(gomock.Any(), 2).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 2}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestApi_ProductCostHidden(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	cost := 5.0
+	ps.EXPECT().GetProduct(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, id int, view *model.PriceView) (*model.Product, error) {
+		return &model.Product{Id: 1, Price: 10, Status: model.ProductStatusPublished, CostPrice: &cost}, nil
+	}).Times(2)
+	req := httptest.NewRequest(echo.GET, "/api/products/1", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "cost_price")
+	req.Header.Set("X-Editor-Token", "secret")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `"cost_price":5`)
+}
+
+func TestApi_ProductsHidden(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	ps := mock.NewMockProductService(mockCtrl)
+	rs := mock.NewMockReviewService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps, Reviews: rs})
+	do := func(url string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, url, nil)
+		if token != "" {
+			req.Header.Set("X-Editor-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	draft := &model.Product{Id: 1, Name: "Coming soon", Slug: "coming-soon", Barcode: "96385074", Status: model.ProductStatusDraft}
+	archived := &model.Product{Id: 2, Name: "Discontinued", Slug: "discontinued", Status: model.ProductStatusArchived}
+	ps.EXPECT().GetProduct(gomock.Any(), 1, gomock.Any()).Return(draft, nil).Times(2)
+	ps.EXPECT().GetProduct(gomock.Any(), 2, gomock.Any()).Return(archived, nil).Times(1)
+	ps.EXPECT().GetProductBySlug(gomock.Any(), gomock.Any(), gomock.Any()).Return(draft, nil).Times(3)
+	ps.EXPECT().GetProductByBarcode(gomock.Any(), "96385074", gomock.Any()).Return(draft, nil).Times(2)
+	// 404 - as if unknown to viewers
+	rec := do("/api/products/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "product `id` = 1 not found")
+	assert.Equal(t, http.StatusNotFound, do("/api/products/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("/api/products/by-slug/coming-soon", "").Code)
+	// without redirecting from a former slug either
+	assert.Equal(t, http.StatusNotFound, do("/api/products/by-slug/soon", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("/api/products/by-barcode/96385074", "").Code)
+	rs.EXPECT().GetReviews(gomock.Any(), 1, model.ReviewStatusApproved, []string{model.ProductStatusPublished}).
+		Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
+	assert.Equal(t, http.StatusNotFound, do("/api/products/1/reviews", "").Code)
+	ps.EXPECT().GetRelatedProducts(gomock.Any(), 1, "", gomock.Any(), gomock.Any(), []string{model.ProductStatusPublished}).
+		Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
+	assert.Equal(t, http.StatusNotFound, do("/api/products/1/related", "").Code)
+	// 200 - editors see them all
+	assert.Equal(t, http.StatusOK, do("/api/products/1", "secret").Code)
+	assert.Equal(t, http.StatusOK, do("/api/products/by-slug/coming-soon", "secret").Code)
+	assert.Equal(t, http.StatusOK, do("/api/products/by-barcode/96385074", "secret").Code)
+	rs.EXPECT().GetReviews(gomock.Any(), 1, model.ReviewStatusApproved, nil).Return(nil, nil).Times(1)
+	assert.Equal(t, http.StatusOK, do("/api/products/1/reviews", "secret").Code)
+	ps.EXPECT().GetRelatedProducts(gomock.Any(), 1, "", gomock.Any(), gomock.Any(), nil).Return(nil, nil).Times(1)
+	assert.Equal(t, http.StatusOK, do("/api/products/1/related", "secret").Code)
+}
+
+func TestApi_GetMarginReport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	rps := mock.NewMockReportService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Reports: rps})
+	// 403
+	req := httptest.NewRequest(echo.GET, "/api/reports/margins?category=2", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// 200
+	category := 2
+	req.Header.Set("X-Editor-Token", "secret")
+	rps.EXPECT().GetMarginReport(gomock.Any(), &model.ProductFilter{Category: &category, Statuses: []string{model.ProductStatusPublished}}).
+		Return(&model.MarginReport{Products: []*model.ProductMargin{}, Categories: []*model.CategoryMargin{}}, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"products":[],"categories":[]}`, helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_ExecuteBatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	bs := mock.NewMockBatchService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Batches: bs})
+	// 400
+	req := httptest.NewRequest(echo.POST, "/api/batch", strings.NewReader(`{"operations": [{"op": "upsert", "entity": "product"}]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 200
+	batch := `{"operations": [{"op": "create", "entity": "category", "data": {"name": "Phones"}}, {"op": "delete", "entity": "product", "id": 3}]}`
+	req = httptest.NewRequest(echo.POST, "/api/batch", strings.NewReader(batch))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	id := 5
+	bs.EXPECT().ExecuteBatch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, batch *model.Batch, check func(interface{}) error) (*model.BatchResponse, error) {
+		assert.Error(t, check(&model.Category{Name: "x"}))
+		return &model.BatchResponse{Results: []model.BatchResult{{Index: 0, Id: &id}, {Index: 1, Err: service.ErrProductInBundle}}}, nil
+	}).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"committed":false,"results":[{"index":0,"status":201,"id":5},{"index":1,"status":412,"error":"product is a component of a bundle"}]}`,
+		helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_ImportProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	is := mock.NewMockImportService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Imports: is})
+	// 400
+	for _, url := range []string{"/api/import/products?dry_run=maybe", "/api/import/products?map=sku"} {
+		req := httptest.NewRequest(echo.POST, url, strings.NewReader("sku\n"))
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+	req := httptest.NewRequest(echo.POST, "/api/import/products", strings.NewReader("sku\n"))
+	is.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), map[string]string{}, false, gomock.Any()).
+		Return(nil, &service.InvalidOperationError{Err: errors.New(`column "sku" appears twice`)}).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 200
+	req = httptest.NewRequest(echo.POST, "/api/import/products?dry_run=true&map=Article%20No:sku", strings.NewReader("Article No\nA-1\n"))
+	report := &model.ImportReport{DryRun: true, Created: 1, Rows: []model.ImportRow{{Line: 2, Status: model.ImportStatusCreated}}}
+	is.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), map[string]string{"Article No": "sku"}, true, gomock.Any()).Return(report, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res, _ := json.Marshal(report)
+	assert.Equal(t, string(res), helpers.RemoveNewLine(rec.Body.String()))
+}
+
+func TestApi_ExportProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps})
+	// 400
+	req := httptest.NewRequest(echo.GET, "/api/export/products?format=pdf", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 500 before the first row
+	req = httptest.NewRequest(echo.GET, "/api/export/products", nil)
+	ps.EXPECT().ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("test")).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	// Aborted after the first rows
+	srv := httptest.NewServer(api.Http)
+	defer srv.Close()
+	ps.EXPECT().ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+			for i := 1; i <= 500; i++ {
+				if err := fn(&model.ExportedProduct{Id: i, Name: "Phone"}); err != nil {
+					return err
+				}
+			}
+			return errors.New("test")
+		}).Times(1)
+	res, err := http.Get(srv.URL + "/api/export/products")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	_, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	// 200
+	weight := 0.45359237
+	export := func(_ context.Context, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+		assert.Equal(t, []string{model.ProductStatusPublished}, filter.Statuses)
+		return fn(&model.ExportedProduct{Id: 1, Name: "Phone, black", Category: 2, CategoryName: "Phones", Price: 9.5,
+			Status: model.ProductStatusPublished, Tags: []string{"a", "b"}, Weight: &weight})
+	}
+	req = httptest.NewRequest(echo.GET, "/api/export/products?units=imperial", nil)
+	ps.EXPECT().ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=UTF-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "id,sku,name,slug,description,category,category_name,brand,barcode,price,status,tags,tax_class,stock,"+
+		"weight,length,width,height,publish_at,unpublish_at\n1,,\"Phone, black\",,,2,Phones,,,9.5,published,a|b,,0,1,,,,,\n", rec.Body.String())
+
+	// the previous export converted the weight in place
+	weight = 0.45359237
+	req = httptest.NewRequest(echo.GET, "/api/export/products?format=ndjson", nil)
+	ps.EXPECT().ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var line model.ExportedProduct
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &line))
+	assert.Equal(t, "Phones", line.CategoryName)
+
+	req = httptest.NewRequest(echo.GET, "/api/export/products?format=xlsx", nil)
+	ps.EXPECT().ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	workbook, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	assert.NoError(t, err)
+	var sheet string
+	for _, file := range workbook.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			r, _ := file.Open()
+			content, _ := io.ReadAll(r)
+			sheet = string(content)
+		}
+	}
+	assert.Contains(t, sheet, `<row><c><v>1</v></c><c t="inlineStr"><is><t xml:space="preserve"></t></is></c><c t="inlineStr"><is><t xml:space="preserve">Phone, black</t></is></c>`)
+	assert.True(t, strings.HasSuffix(sheet, "</row></sheetData></worksheet>"))
+}
+
+func TestApi_Negotiation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	tcs := mock.NewMockTaxClassService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs, TaxClasses: tcs})
+	taxClass := 3
+	cats := []*model.Category{{Id: 1, Name: "Phones, mobile", Slug: "phones", TaxClass: &taxClass}, {Id: 2, Name: "TVs <4k>"}}
+	get := func(url string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, url, nil)
+		req.Header.Set(echo.HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	// 406
+	rec := get("/api/categories", "text/html, image/png")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	cs.EXPECT().GetCategory(gomock.Any(), 1).Return(cats[0], nil).Times(2)
+	rec = get("/api/categories/1", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	// CSV is for collections, the next accepted type is used otherwise
+	rec = get("/api/categories/1", "text/csv, application/json;q=0.5")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	// writes answer 406 before writing anything when only CSV is accepted
+	write := func(method string, url string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(`{"name":"Phones"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	rec = write(echo.POST, "/api/categories", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "text/csv")
+	rec = write(echo.PUT, "/api/categories/1", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	id := 3
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	rec = write(echo.POST, "/api/categories", "text/csv, application/json;q=0.5")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+
+	cs.EXPECT().GetCategories(gomock.Any()).Return(cats, nil).Times(3)
+	rec = get("/api/categories", "application/json;q=0.5, application/xml")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, xml.Header+`<response><item><id>1</id><name>Phones, mobile</name><slug>phones</slug><tax_class>3</tax_class></item>`+
+		`<item><id>2</id><name>TVs &lt;4k&gt;</name><slug></slug><tax_class></tax_class></item></response>`, rec.Body.String())
+
+	rec = get("/api/categories", "text/csv")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,name,slug,tax_class\n1,\"Phones, mobile\",phones,3\n2,TVs <4k>,,\n", rec.Body.String())
+
+	rec = get("/api/categories", "application/msgpack")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var decoded []*model.Category
+	decoder := msgpack.NewDecoder(rec.Body)
+	decoder.SetCustomStructTag("json")
+	assert.NoError(t, decoder.Decode(&decoded))
+	assert.Equal(t, cats, decoded)
+
+	// map keys aren't element names
+	tcs.EXPECT().GetTaxClass(gomock.Any(), 3).Return(&model.TaxClass{Id: 3, Name: "standard", Rates: map[string]float64{"DE": 19, "1 <x>": 5}}, nil).Times(1)
+	rec = get("/api/tax-classes/3", "application/xml")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, xml.Header+`<response><id>3</id><name>standard</name><rates><entry key="1 &lt;x&gt;">5</entry><entry key="DE">19</entry></rates></response>`,
+		rec.Body.String())
+}
+
+func TestApi_BindFormats(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	ps := mock.NewMockProductService(mockCtrl)
+	tcs := mock.NewMockTaxClassService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs, Products: ps, TaxClasses: tcs})
+	id, taxClass := 2, 3
+	expected := &model.Category{Name: "Phones", TaxClass: &taxClass}
+	// XML
+	req := httptest.NewRequest(echo.POST, "/api/categories", strings.NewReader(`<category><name>Phones</name><tax_class>3</tax_class></category>`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXML)
+	cs.EXPECT().CreateCategory(gomock.Any(), expected).Return(&id, nil).Times(1)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// map entries
+	req = httptest.NewRequest(echo.POST, "/api/tax-classes", strings.NewReader(`<tax_class><name>standard</name><rates><entry key="DE">19</entry><FR>20</FR></rates></tax_class>`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXML)
+	tcs.EXPECT().CreateTaxClass(gomock.Any(), &model.TaxClass{Name: "standard", Rates: map[string]float64{"DE": 19, "FR": 20}}).Return(&id, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// MessagePack
+	var body bytes.Buffer
+	encoder := msgpack.NewEncoder(&body)
+	encoder.SetCustomStructTag("json")
+	encoder.Encode(expected)
+	req = httptest.NewRequest(echo.POST, "/api/categories", &body)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationMsgpack)
+	cs.EXPECT().CreateCategory(gomock.Any(), expected).Return(&id, nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	// CSV for collections
+	req = httptest.NewRequest(echo.PUT, "/api/products/1/related", strings.NewReader("type,product\naccessory-of,2\nup-sell,3\n"))
+	req.Header.Set(echo.HeaderContentType, "text/csv")
+	ps.EXPECT().ReplaceProductRelations(gomock.Any(), 1, []model.ProductRelation{{Type: "accessory-of", Product: 2}, {Type: "up-sell", Product: 3}}).Return(nil).Times(1)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	// 400
+	req = httptest.NewRequest(echo.POST, "/api/categories", strings.NewReader(`<category><tax_class>three</tax_class></category>`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXML)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// 415
+	req = httptest.NewRequest(echo.POST, "/api/categories", strings.NewReader(`name: Phones`))
+	req.Header.Set(echo.HeaderContentType, "text/yaml")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestApi_OpenAPI(t *testing.T) {
+	conf := &config.Config{LogLevel: 5}
+	api := api.NewApi(conf, api.Services{})
+	req := httptest.NewRequest(echo.GET, "/openapi.json", nil)
+	req.Header.Set(echo.HeaderAccept, "application/xml")
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]interface{}   `json:"responses"`
+			Security  []map[string]interface{} `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	// Every route is documented
+	for _, route := range api.Http.Routes() {
+		path := route.Path
+		for _, param := range []string{"id", "slug", "code"} {
+			path = strings.Replace(path, ":"+param, "{"+param+"}", 1)
+		}
+		operation, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		if assert.True(t, ok, route.Method+" "+route.Path) {
+			assert.NotEmpty(t, operation.Summary, route.Method+" "+route.Path)
+		}
+	}
+	operation := spec.Paths["/api/products/{id}"]["put"]
+	assert.Equal(t, "id", operation.Parameters[0].Name)
+	assert.Equal(t, "path", operation.Parameters[0].In)
+	assert.Equal(t, "#/components/schemas/Product", operation.RequestBody.Content["application/json"].Schema["$ref"])
+	assert.Contains(t, operation.Responses, "204")
+	assert.Contains(t, operation.Responses, "409")
+	assert.Contains(t, spec.Paths["/api/products"]["post"].Responses, "201")
+	assert.Len(t, spec.Paths["/api/suppliers"]["get"].Security, 1)
+	assert.Empty(t, spec.Paths["/api/brands"]["get"].Security)
+	assert.Contains(t, spec.Paths["/api/brands"]["get"].Responses, "200")
+
+	// Schemas follow the json and validate tags
+	product := spec.Components.Schemas["Product"]
+	assert.ElementsMatch(t, []string{"name"}, product.Required)
+	assert.Equal(t, "number", product.Properties["price"]["type"])
+	assert.Equal(t, float64(0), product.Properties["price"]["minimum"])
+	assert.Equal(t, float64(3), product.Properties["name"]["minLength"])
+	assert.Equal(t, []interface{}{"draft", "published", "archived"}, product.Properties["status"]["enum"])
+	assert.Equal(t, true, product.Properties["brand"]["nullable"])
+	assert.Equal(t, "date-time", product.Properties["publish_at"]["format"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "maxLength": float64(50)}, product.Properties["tags"]["items"])
+	assert.Equal(t, true, product.Properties["weight"]["exclusiveMinimum"])
+	assert.NotContains(t, spec.Components.Schemas["BatchResult"].Properties, "Err")
+	review := spec.Components.Schemas["Review"]
+	assert.ElementsMatch(t, []string{"rating", "title", "author_name"}, review.Required)
+	assert.Equal(t, float64(5), review.Properties["rating"]["maximum"])
+	rates := spec.Components.Schemas["TaxClass"].Properties["rates"]["additionalProperties"]
+	assert.Equal(t, map[string]interface{}{"type": "number", "minimum": float64(0), "maximum": float64(100)}, rates)
+
+	// The explorer reads the document
+	req = httptest.NewRequest(echo.GET, "/", nil)
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMETextHTMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `fetch("/openapi.json")`)
+}
+
+func TestApi_Problems(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	ps := mock.NewMockProductService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs, Products: ps})
+	type problem struct {
+		Type     string               `json:"type"`
+		Title    string               `json:"title"`
+		Status   int                  `json:"status"`
+		Detail   string               `json:"detail"`
+		Instance string               `json:"instance"`
+		Errors   []service.FieldError `json:"errors"`
+	}
+	do := func(method string, url string, body string) (*httptest.ResponseRecorder, *problem) {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+		p := &problem{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), p))
+		assert.Equal(t, rec.Code, p.Status)
+		return rec, p
+	}
+	// Invalid fields are named like in the request
+	rec, p := do(echo.POST, "/api/products", `{"price": -1, "status": "sold", "components": [{"quantity": 1}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "Bad Request", p.Title)
+	assert.Equal(t, "/api/products", p.Instance)
+	assert.Equal(t, []service.FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "price", Rule: "gte", Message: "must be at least 0"},
+		{Field: "status", Rule: "oneof", Message: "must be one of draft, published, archived"},
+		{Field: "components[0].product", Rule: "required", Message: "is required"},
+		{Field: "components", Rule: "excluded_unless", Message: "is only allowed for type bundle"},
+	}, p.Errors)
+	// so are the ones found by services
+	ps.EXPECT().GetProduct(gomock.Any(), 1, &model.PriceView{Region: "XX"}).Return(nil, service.ErrUnknownRegion).Times(1)
+	rec, p = do(echo.GET, "/api/products/1?region=xx", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, []service.FieldError{{Field: "region", Message: "unknown tax region"}}, p.Errors)
+	rec, p = do(echo.POST, "/api/products", `{"name": 3}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, p.Detail, "field=name")
+	// 404
+	cs.EXPECT().GetCategory(gomock.Any(), 9).Return(nil, &service.NotFoundError{Entity: "category", Field: "id", Value: 9}).Times(1)
+	rec, p = do(echo.GET, "/api/categories/9", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "category `id` = 9 not found", p.Detail)
+	rec, p = do(echo.GET, "/api/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "Not Found", p.Detail)
+	// Unexpected errors keep their details to the logs
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, errors.New("database is locked")).Times(1)
+	rec, p = do(echo.GET, "/api/categories", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "Internal Server Error", p.Title)
+	assert.Empty(t, p.Detail)
+	assert.NotContains(t, rec.Body.String(), "database")
+}
+
+func TestApi_Authentication(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.EditorToken = "secret"
+	cs := mock.NewMockCategoryService(mockCtrl)
+	ss := mock.NewMockSupplierService(mockCtrl)
+	aks := mock.NewMockApiKeyService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs, Suppliers: ss, ApiKeys: aks})
+	assert.Contains(t, api.GetApiInfo().MW, "Authentication")
+	assert.NotContains(t, api.GetApiInfo().MW, "EditorToken")
+	reader := &model.ApiKey{Id: 1, Scopes: []string{model.ScopeRead}}
+	writer := &model.ApiKey{Id: 2, Scopes: []string{model.ScopeWrite}}
+	admin := &model.ApiKey{Id: 3, Scopes: []string{model.ScopeAdmin}}
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_reader").Return(reader, nil).AnyTimes()
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_writer").Return(writer, nil).AnyTimes()
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_admin").Return(admin, nil).AnyTimes()
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_revoked").Return(nil, &service.AuthenticationError{Reason: "API key revoked"}).AnyTimes()
+	do := func(method string, url string, body string, header string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if header != "" {
+			req.Header.Set(header, key)
+		}
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	// The documentation is public
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/", "", "", "").Code)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/openapi.json", "", "", "").Code)
+	// 401
+	rec := do(echo.GET, "/api/categories", "", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+	assert.Contains(t, rec.Body.String(), `"detail":"credentials required"`)
+	rec = do(echo.DELETE, "/api/categories/1", "", "X-API-Key", "pk_revoked")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"API key revoked"`)
+	// The editor token is retired
+	assert.Equal(t, http.StatusUnauthorized, do(echo.GET, "/api/suppliers", "", "X-Editor-Token", "secret").Code)
+	assert.NotContains(t, do(echo.GET, "/openapi.json", "", "", "").Body.String(), "X-Editor-Token")
+	// Reading takes the read scope, through either header
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(2)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/categories", "", "X-API-Key", "pk_reader").Code)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/categories", "", "Authorization", "Bearer pk_reader").Code)
+	// writing the write scope
+	rec = do(echo.DELETE, "/api/categories/1", "", "X-API-Key", "pk_reader")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Requires the editor role")
+	cs.EXPECT().DeleteCategory(gomock.Any(), 1).Return(nil).Times(1)
+	assert.Equal(t, http.StatusNoContent, do(echo.DELETE, "/api/categories/1", "", "Authorization", "bearer pk_writer").Code)
+	// Write keys are editors
+	assert.Equal(t, http.StatusForbidden, do(echo.GET, "/api/suppliers", "", "X-API-Key", "pk_reader").Code)
+	ss.EXPECT().GetSuppliers(gomock.Any()).Return(nil, nil).Times(1)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/suppliers", "", "X-API-Key", "pk_writer").Code)
+	// Managing keys takes the admin scope
+	assert.Equal(t, http.StatusForbidden, do(echo.GET, "/api/keys", "", "X-API-Key", "pk_writer").Code)
+	aks.EXPECT().GetApiKeys(gomock.Any()).Return([]*model.ApiKey{reader}, nil).Times(1)
+	rec = do(echo.GET, "/api/keys", "", "X-API-Key", "pk_admin")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "hash")
+	rec = do(echo.POST, "/api/keys", `{"name": "shop", "scopes": ["read", "owner"]}`, "X-API-Key", "pk_admin")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"field":"scopes[1]"`)
+	issued := &model.IssuedApiKey{ApiKey: model.ApiKey{Id: 4, Name: "shop", Prefix: "pk_12345678", Scopes: []string{model.ScopeRead}},
+		Key: "pk_12345678abc"}
+	aks.EXPECT().CreateApiKey(gomock.Any(), &model.ApiKey{Name: "shop", Scopes: []string{model.ScopeRead}}).Return(issued, nil).Times(1)
+	rec = do(echo.POST, "/api/keys", `{"name": "shop", "scopes": ["read"]}`, "X-API-Key", "pk_admin")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"key":"pk_12345678abc"`)
+	aks.EXPECT().RotateApiKey(gomock.Any(), 4).Return(issued, nil).Times(1)
+	assert.Equal(t, http.StatusOK, do(echo.POST, "/api/keys/4/rotate", "", "X-API-Key", "pk_admin").Code)
+	aks.EXPECT().RevokeApiKey(gomock.Any(), 5).Return(&service.NotFoundError{Entity: "API key", Field: "id", Value: 5}).Times(1)
+	assert.Equal(t, http.StatusNotFound, do(echo.DELETE, "/api/keys/5", "", "X-API-Key", "pk_admin").Code)
+	// The document asks for keys everywhere but on the public routes
+	spec := map[string]interface{}{}
+	json.Unmarshal(do(echo.GET, "/openapi.json", "", "", "").Body.Bytes(), &spec)
+	assert.Equal(t, []interface{}{map[string]interface{}{"apiKey": []interface{}{}}, map[string]interface{}{"bearerKey": []interface{}{}}},
+		spec["security"])
+	paths := spec["paths"].(map[string]interface{})
+	assert.Equal(t, []interface{}{}, paths["/openapi.json"].(map[string]interface{})["get"].(map[string]interface{})["security"])
+	responses := paths["/api/categories"].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})
+	assert.Contains(t, responses, "401")
+}
+
+func TestApi_Roles(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	ps := mock.NewMockProductService(mockCtrl)
+	rs := mock.NewMockReviewService(mockCtrl)
+	aks := mock.NewMockApiKeyService(mockCtrl)
+	ts := mock.NewMockTokenService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Products: ps, Reviews: rs, ApiKeys: aks, Tokens: ts})
+	ts.EXPECT().Verify("viewer.token.jwt").Return(&model.Principal{Subject: "v", Role: model.RoleViewer}, nil).AnyTimes()
+	ts.EXPECT().Verify("editor.token.jwt").Return(&model.Principal{Subject: "e", Role: model.RoleEditor}, nil).AnyTimes()
+	ts.EXPECT().Verify("admin.token.jwt").Return(&model.Principal{Subject: "a", Role: model.RoleAdmin}, nil).AnyTimes()
+	ts.EXPECT().Verify("none.token.jwt").Return(&model.Principal{Subject: "n"}, nil).AnyTimes()
+	ts.EXPECT().Verify("expired.token.jwt").Return(nil, &service.AuthenticationError{Reason: "invalid token: Token is expired"}).AnyTimes()
+	do := func(method string, url string, body string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Equal(t, http.StatusUnauthorized, do(echo.GET, "/api/products/1", "", "expired.token.jwt").Code)
+	// Viewers read and send reviews
+	ps.EXPECT().GetProduct(gomock.Any(), 1, gomock.Any()).Return(&model.Product{Id: 1, Status: model.ProductStatusPublished}, nil).Times(2)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/products/1", "", "viewer.token.jwt").Code)
+	rs.EXPECT().CreateReview(gomock.Any(), gomock.Any()).Return(nil, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}).Times(1)
+	assert.Equal(t, http.StatusNotFound, do(echo.POST, "/api/products/1/reviews",
+		`{"rating": 5, "title": "Great", "body": "Really great", "author_name": "Jo"}`, "viewer.token.jwt").Code)
+	rec := do(echo.DELETE, "/api/products/1", "", "viewer.token.jwt")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Requires the editor role")
+	assert.Equal(t, http.StatusForbidden, do(echo.GET, "/api/products/1", "", "none.token.jwt").Code)
+	// editors write
+	ps.EXPECT().DeleteProduct(gomock.Any(), 1).Return(nil).Times(2)
+	assert.Equal(t, http.StatusNoContent, do(echo.DELETE, "/api/products/1", "", "editor.token.jwt").Code)
+	assert.Equal(t, http.StatusNoContent, do(echo.DELETE, "/api/products/1", "", "admin.token.jwt").Code)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/products/1", "", "admin.token.jwt").Code)
+	// admins manage keys
+	assert.Equal(t, http.StatusForbidden, do(echo.GET, "/api/keys", "", "editor.token.jwt").Code)
+	aks.EXPECT().GetApiKeys(gomock.Any()).Return(nil, nil).Times(1)
+	assert.Equal(t, http.StatusOK, do(echo.GET, "/api/keys", "", "admin.token.jwt").Code)
+	// Bearer API keys still work along with tokens
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_reader").Return(&model.ApiKey{Name: "shop", Scopes: []string{model.ScopeRead}}, nil).Times(1)
+	assert.Equal(t, http.StatusForbidden, do(echo.DELETE, "/api/products/1", "", "pk_reader").Code)
+}
+
+func TestApi_RateLimit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.RateLimit.Enabled = true
+	conf.Api.RateLimit.ReadRate, conf.Api.RateLimit.ReadBurst = 0.01, 2
+	conf.Api.RateLimit.WriteRate, conf.Api.RateLimit.WriteBurst = 0.5, 1
+	cs := mock.NewMockCategoryService(mockCtrl)
+	aks := mock.NewMockApiKeyService(mockCtrl)
+	do := func(api *api.Api, method string, url string, ip string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req.RemoteAddr = ip + ":40000"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	open := api.NewApi(conf, api.Services{Categories: cs})
+	assert.Contains(t, open.GetApiInfo().MW, "RateLimit")
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(3)
+	rec := do(open, echo.GET, "/api/categories", "10.0.0.1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "100", rec.Header().Get("RateLimit-Reset"))
+	rec = do(open, echo.GET, "/api/categories", "10.0.0.1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	// 429
+	rec = do(open, echo.GET, "/api/categories", "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "100", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+	// Writing has its own budget
+	cs.EXPECT().DeleteCategory(gomock.Any(), 1).Return(nil).Times(1)
+	rec = do(open, echo.DELETE, "/api/categories/1", "10.0.0.1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	rec = do(open, echo.DELETE, "/api/categories/1", "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	// and so has another IP
+	assert.Equal(t, http.StatusOK, do(open, echo.GET, "/api/categories", "10.0.0.2", "").Code)
+	// but not a forwarded IP made up by the client
+	forwarded := func(api *api.Api, proxy string, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, "/api/categories", nil)
+		req.RemoteAddr = proxy + ":40000"
+		req.Header.Set(echo.HeaderXForwardedFor, ip)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Equal(t, http.StatusTooManyRequests, forwarded(open, "10.0.0.1", "10.0.0.9").Code)
+	// unless it comes from a trusted proxy
+	proxied := *conf
+	proxied.Api.TrustedProxies = []string{"192.168.0.0/24"}
+	behind := api.NewApi(&proxied, api.Services{Categories: cs})
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(2)
+	assert.Equal(t, http.StatusOK, forwarded(behind, "192.168.0.1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, forwarded(behind, "192.168.0.1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, forwarded(behind, "192.168.0.1", "10.0.0.1").Code)
+	// other peers are clients themselves
+	assert.Equal(t, http.StatusTooManyRequests, forwarded(behind, "10.0.0.1", "10.0.0.9").Code)
+
+	// Authenticated clients are limited by key, wherever they call from
+	authenticated := api.NewApi(conf, api.Services{Categories: cs, ApiKeys: aks})
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_shop").Return(&model.ApiKey{Id: 7, Scopes: []string{model.ScopeRead}}, nil).Times(3)
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_other").Return(&model.ApiKey{Id: 8, Scopes: []string{model.ScopeRead}}, nil).Times(1)
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(3)
+	assert.Equal(t, http.StatusOK, do(authenticated, echo.GET, "/api/categories", "10.0.0.3", "pk_shop").Code)
+	assert.Equal(t, http.StatusOK, do(authenticated, echo.GET, "/api/categories", "10.0.0.4", "pk_shop").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(authenticated, echo.GET, "/api/categories", "10.0.0.5", "pk_shop").Code)
+	assert.Equal(t, http.StatusOK, do(authenticated, echo.GET, "/api/categories", "10.0.0.5", "pk_other").Code)
+
+	// Failed authentications are limited by IP, before the credentials are checked
+	guarded := *conf
+	guarded.Api.RateLimit.FailureRate, guarded.Api.RateLimit.FailureBurst = 0.01, 3
+	authenticated = api.NewApi(&guarded, api.Services{Categories: cs, ApiKeys: aks})
+	assert.Equal(t, []string{"Tracing", "RequestID", "Negotiation", "AuthenticationLimit", "Authentication", "RateLimit"},
+		authenticated.GetApiInfo().MW)
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_guess").Return(nil, &service.AuthenticationError{Reason: "unknown API key"}).Times(3)
+	rec = do(authenticated, echo.GET, "/api/categories", "10.0.0.8", "pk_guess")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusUnauthorized, do(authenticated, echo.GET, "/api/categories", "10.0.0.8", "pk_guess").Code)
+	rec = do(authenticated, echo.GET, "/api/categories", "10.0.0.8", "pk_guess")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	rec = do(authenticated, echo.GET, "/api/categories", "10.0.0.8", "pk_guess")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "100", rec.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, do(authenticated, echo.GET, "/api/categories", "10.0.0.8", "pk_other").Code)
+	// Requests without credentials fail as well
+	assert.Equal(t, http.StatusUnauthorized, do(authenticated, echo.GET, "/api/categories", "10.0.0.9", "").Code)
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_other").Return(&model.ApiKey{Id: 8, Scopes: []string{model.ScopeRead}}, nil).Times(1)
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(1)
+	rec = do(authenticated, echo.GET, "/api/categories", "10.0.0.9", "pk_other")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// The headers of a successful request are those of the client
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	spec := do(authenticated, echo.GET, "/openapi.json", "10.0.0.6", "").Body.String()
+	assert.Contains(t, spec, `"429"`)
+	assert.Contains(t, spec, `"RateLimit-Remaining"`)
+}
+
+func TestApi_Idempotency(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs, Idempotency: service.NewIdempotencyService(st, time.Hour, time.Minute)})
+	// Keys outlive the runs in the test database
+	prefix := strconv.FormatInt(time.Now().UnixNano(), 36) + "-"
+	ip, contentType := "10.0.0.1", echo.MIMEApplicationJSON
+	do := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.POST, "/api/categories/", strings.NewReader(body))
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set(echo.HeaderContentType, contentType)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", prefix+key)
+		}
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	id := 2
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	rec := do("create", `{"name": "test"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	// Replayed
+	retry := do("create", `{"name": "test"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, rec.Body.String(), retry.Body.String())
+	assert.Equal(t, rec.Header().Get(echo.HeaderContentType), retry.Header().Get(echo.HeaderContentType))
+	// The params of the media type don't make another request
+	contentType = echo.MIMEApplicationJSONCharsetUTF8
+	retry = do("create", `{"name": "test"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	contentType = echo.MIMEApplicationJSON
+	// 422, another payload
+	rec = do("create", `{"name": "other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	// Validation errors are replayed as well
+	rec = do("invalid", `{"name": "te"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do("invalid", `{"name": "te"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	// 409, the retry comes while the first request runs
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *model.Category) (*int, error) {
+		assert.Equal(t, http.StatusConflict, do("concurrent", `{"name": "test"}`).Code)
+		return &id, nil
+	}).Times(1)
+	assert.Equal(t, http.StatusCreated, do("concurrent", `{"name": "test"}`).Code)
+	// Server errors run again
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	assert.Equal(t, http.StatusInternalServerError, do("failed", `{"name": "test"}`).Code)
+	rec = do("failed", `{"name": "test"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	// Without key every request runs
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(2)
+	assert.Equal(t, http.StatusCreated, do("", `{"name": "test"}`).Code)
+	assert.Equal(t, http.StatusCreated, do("", `{"name": "test"}`).Code)
+	// 400, key too long
+	assert.Equal(t, http.StatusBadRequest, do(strings.Repeat("k", 256), `{"name": "test"}`).Code)
+	// Anonymous clients have keys of their own
+	ip = "10.0.0.2"
+	cs.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&id, nil).Times(1)
+	rec = do("create", `{"name": "other"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+}
+
+func TestApi_RequestId(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	conf.Api.Logging = true
+	cs := mock.NewMockCategoryService(mockCtrl)
+	api := api.NewApi(conf, api.Services{Categories: cs})
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFormatter(&log.TextFormatter{})
+	do := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, "/api/categories", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	// The service logs through the logger of the request
+	cs.EXPECT().GetCategories(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]*model.Category, error) {
+		assert.Equal(t, "req-1", logging.Entry(ctx).Data["request_id"])
+		return nil, nil
+	}).Times(1)
+	rec := do("req-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "Request handled", line["msg"])
+	assert.Equal(t, "GET", line["method"])
+	assert.Equal(t, "/api/categories", line["route"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+	// Generated
+	cs.EXPECT().GetCategories(gomock.Any()).Return(nil, nil).Times(4)
+	for _, id := range []string{"", "with space", strings.Repeat("x", 129)} {
+		rec = do(id)
+		assert.Regexp(t, "^[0-9a-f]{32}$", rec.Header().Get("X-Request-ID"), id)
+	}
+	assert.NotEqual(t, do("").Header().Get("X-Request-ID"), rec.Header().Get("X-Request-ID"))
+	// Errors are logged with their status
+	out.Reset()
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(echo.GET, "/api/unknown", nil)
+	req.Header.Set("X-Request-ID", "req-3")
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "req-3", rec.Header().Get("X-Request-ID"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &line))
+	assert.Equal(t, "req-3", line["request_id"])
+	assert.Equal(t, float64(http.StatusNotFound), line["status"])
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidGTIN(t *testing.T) {
+	for _, code := range []string{"96385074", "036000291452", "4006381333931", "10012345678902"} {
+		assert.True(t, model.ValidGTIN(code), code)
+	}
+	for _, code := range []string{"", "96385075", "03600029145", "4006381333932", "400638133393a", "123456789012345"} {
+		assert.False(t, model.ValidGTIN(code), code)
+	}
+	assert.Equal(t, "00000096385074", model.GTIN14("96385074"))
+}
+
+func TestProductService_GetProductByBarcode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetProductByBarcode(gomock.Any(), nil, "96385074").Return(nil, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetProductByBarcode(ctx, "96385074", nil)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "barcode", Value: "96385074"}, e)
+	assert.Nil(t, r)
+
+	mockStore.EXPECT().GetProductByBarcode(gomock.Any(), nil, "036000291452").Return(&model.Product{Id: 1, Price: 10}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).Times(1)
+	r, e = ps.GetProductByBarcode(ctx, "036000291452", nil)
+	assert.Nil(t, e)
+	assert.Equal(t, 10.0, r.EffectivePrice)
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func accept(interface{}) error {
+	return nil
+}
+
+func TestBatchService_ExecuteBatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// later operations use the ids created earlier
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	category, product := 7, 9
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityCategory, "phones").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateCategory(gomock.Any(), tx, &model.Category{Name: "Phones", Slug: "phones"}).Return(&category, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "phone-x").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, p *model.Product) (*int, error) {
+		assert.Equal(t, category, p.Category)
+		return &product, nil
+	}).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	bs := service.NewBatchService(mockStore)
+	r, e := bs.ExecuteBatch(ctx, &model.Batch{Operations: []model.BatchOperation{
+		{Op: model.BatchOpCreate, Entity: model.BatchEntityCategory, Ref: "phones", Data: json.RawMessage(`{"name": "Phones"}`)},
+		{Op: model.BatchOpCreate, Entity: model.BatchEntityProduct, Data: json.RawMessage(`{"name": "Phone X", "price": 1, "category": {"$ref": "phones"}}`)},
+	}}, accept)
+	assert.Nil(t, e)
+	assert.True(t, r.Committed)
+	assert.Equal(t, &category, r.Results[0].Id)
+	assert.Equal(t, &product, r.Results[1].Id)
+	assert.Nil(t, r.Results[1].Err)
+
+	// atomic batches stop at the first failure
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBatchService(mockStore)
+	r, e = bs.ExecuteBatch(ctx, &model.Batch{Operations: []model.BatchOperation{
+		{Op: model.BatchOpCreate, Entity: model.BatchEntityCategory, Data: json.RawMessage(`{"name": "x"}`)},
+		{Op: model.BatchOpDelete, Entity: model.BatchEntityCategory, Id: json.RawMessage(`1`)},
+	}}, func(interface{}) error { return errors.New("too short") })
+	assert.Nil(t, e)
+	assert.False(t, r.Committed)
+	assert.IsType(t, &service.InvalidOperationError{}, r.Results[0].Err)
+	assert.Equal(t, service.ErrNotExecuted, r.Results[1].Err)
+
+	// others skip failed operations
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Savepoint(gomock.Any(), tx, gomock.Any()).Return(nil).Times(3)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "tv").Return(nil, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, gomock.Any()).Return(sql.ErrNoRows).Times(1)
+	mockStore.EXPECT().RollbackTo(gomock.Any(), tx, gomock.Any()).Return(nil).Times(2)
+	mockStore.EXPECT().DeleteCategory(gomock.Any(), tx, 4).Return(nil).Times(1)
+	mockStore.EXPECT().Release(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBatchService(mockStore)
+	r, e = bs.ExecuteBatch(ctx, &model.Batch{Mode: model.BatchModeContinue, Operations: []model.BatchOperation{
+		{Op: model.BatchOpUpdate, Entity: model.BatchEntityProduct, Id: json.RawMessage(`3`), Data: json.RawMessage(`{"name": "TV", "slug": "tv", "price": 1}`)},
+		{Op: model.BatchOpDelete, Entity: model.BatchEntityCategory, Id: json.RawMessage(`{"$ref": "missing"}`)},
+		{Op: model.BatchOpDelete, Entity: model.BatchEntityCategory, Id: json.RawMessage(`4`)},
+	}}, accept)
+	assert.Nil(t, e)
+	assert.True(t, r.Committed)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 3}, r.Results[0].Err)
+	assert.IsType(t, &service.InvalidOperationError{}, r.Results[1].Err)
+	assert.Nil(t, r.Results[2].Err)
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrandService_GetBrand(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetBrand(gomock.Any(), nil, 1).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetBrand(gomock.Any(), nil, 2).Return(&model.Brand{Id: 2, Name: "test"}, nil).Times(1)
+	mockStore.EXPECT().GetBrand(gomock.Any(), nil, 3).Return(nil, nil).Times(1)
+	bs := service.NewBrandService(mockStore)
+	r, e := bs.GetBrand(ctx, 1)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	r, e = bs.GetBrand(ctx, 2)
+	assert.Nil(t, e)
+	assert.Equal(t, &model.Brand{Id: 2, Name: "test"}, r)
+	r, e = bs.GetBrand(ctx, 3)
+	assert.Equal(t, &service.NotFoundError{Entity: "brand", Field: "id", Value: 3}, e)
+	assert.Nil(t, r)
+}
+
+func TestBrandService_GetBrands(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetBrands(gomock.Any(), nil).Return(nil, errors.New("test")).Times(1)
+	bs := service.NewBrandService(mockStore)
+	r, e := bs.GetBrands(ctx)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	mockStore.EXPECT().GetBrands(gomock.Any(), nil).Return([]*model.Brand{}, nil).Times(1)
+	r, e = bs.GetBrands(ctx)
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+}
+
+func TestBrandService_CreateBrand(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	bs := service.NewBrandService(mockStore)
+	r, e := bs.CreateBrand(ctx, &model.Brand{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateBrand(gomock.Any(), tx, &model.Brand{Name: "Test"}).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	r, e = bs.CreateBrand(ctx, &model.Brand{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	var id = 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateBrand(gomock.Any(), tx, &model.Brand{Name: "Test"}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	r, e = bs.CreateBrand(ctx, &model.Brand{Name: "Test"})
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+
+	// names are unique
+	mockStore = mock.NewMockStore(mockCtrl)
+	conflict := &service.ConflictError{Entity: "brand", Field: "name", Id: 1}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateBrand(gomock.Any(), tx, &model.Brand{Name: "test"}).Return(nil, conflict).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	r, e = bs.CreateBrand(ctx, &model.Brand{Name: "test"})
+	assert.Equal(t, conflict, e)
+	assert.Nil(t, r)
+}
+
+func TestBrandService_UpdateBrand(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	bs := service.NewBrandService(mockStore)
+	e := bs.UpdateBrand(ctx, nil)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	cat := &model.Brand{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateBrand(gomock.Any(), tx, cat).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	e = bs.UpdateBrand(ctx, cat)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	cat = &model.Brand{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateBrand(gomock.Any(), tx, cat).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	e = bs.UpdateBrand(ctx, cat)
+	assert.Nil(t, e)
+	mockStore = mock.NewMockStore(mockCtrl)
+	conflict := &service.ConflictError{Entity: "brand", Field: "name", Id: 2}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateBrand(gomock.Any(), tx, cat).Return(conflict).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	assert.Equal(t, conflict, bs.UpdateBrand(ctx, cat))
+}
+
+func TestBrandService_DeleteBrand(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	bs := service.NewBrandService(mockStore)
+	e := bs.DeleteBrand(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteBrand(gomock.Any(), tx, 1).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	e = bs.DeleteBrand(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteBrand(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	bs = service.NewBrandService(mockStore)
+	e = bs.DeleteBrand(ctx, 1)
+	assert.Nil(t, e)
+}
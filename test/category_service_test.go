@@ -17,13 +17,13 @@ func TestCategoryService_GetCategory(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().GetCategory(nil, 1).Return(nil, errors.New("test")).Times(1)
-	mockStore.EXPECT().GetCategory(nil, 2).Return(&model.Category{Id: 2, Name: "test"}, nil).Times(1)
+	mockStore.EXPECT().GetCategory(gomock.Any(), nil, 1).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetCategory(gomock.Any(), nil, 2).Return(&model.Category{Id: 2, Name: "test"}, nil).Times(1)
 	cs := service.NewCategoryService(mockStore)
-	r, e := cs.GetCategory(1)
+	r, e := cs.GetCategory(ctx, 1)
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
-	r, e = cs.GetCategory(2)
+	r, e = cs.GetCategory(ctx, 2)
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
 }
@@ -32,13 +32,13 @@ func TestCategoryService_GetCategories(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().GetCategories(nil).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetCategories(gomock.Any(), nil).Return(nil, errors.New("test")).Times(1)
 	cs := service.NewCategoryService(mockStore)
-	r, e := cs.GetCategories()
+	r, e := cs.GetCategories(ctx)
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
-	mockStore.EXPECT().GetCategories(nil).Return([]*model.Category{}, nil).Times(1)
-	r, e = cs.GetCategories()
+	mockStore.EXPECT().GetCategories(gomock.Any(), nil).Return([]*model.Category{}, nil).Times(1)
+	r, e = cs.GetCategories(ctx)
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
 }
@@ -48,32 +48,44 @@ func TestCategoryService_CreateCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	cs := service.NewCategoryService(mockStore)
-	r, e := cs.CreateCategory(&model.Category{Name: "Test"})
+	r, e := cs.CreateCategory(ctx, &model.Category{Name: "Test"})
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().CreateCategory(tx, &model.Category{Name: "Test"}).Return(nil, errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityCategory, "test").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateCategory(gomock.Any(), tx, &model.Category{Name: "Test", Slug: "test"}).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	r, e = cs.CreateCategory(&model.Category{Name: "Test"})
+	r, e = cs.CreateCategory(ctx, &model.Category{Name: "Test"})
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
 	var id = 1
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().CreateCategory(tx, &model.Category{Name: "Test"}).Return(&id, nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityCategory, "test").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateCategory(gomock.Any(), tx, &model.Category{Name: "Test", Slug: "test"}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	r, e = cs.CreateCategory(&model.Category{Name: "Test"})
+	r, e = cs.CreateCategory(ctx, &model.Category{Name: "Test"})
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
+	// taxed by a missing tax class
+	taxClass := 9
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), tx, 9).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	cs = service.NewCategoryService(mockStore)
+	r, e = cs.CreateCategory(ctx, &model.Category{Name: "Test", TaxClass: &taxClass})
+	assert.Equal(t, service.ErrUnknownTaxClass, e)
+	assert.Nil(t, r)
 }
 
 func TestCategoryService_UpdateCategory(t *testing.T) {
@@ -81,29 +93,29 @@ func TestCategoryService_UpdateCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	cs := service.NewCategoryService(mockStore)
-	e := cs.UpdateCategory(nil)
+	e := cs.UpdateCategory(ctx, nil)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
 	cat := &model.Category{Id: 1, Name: "test"}
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().UpdateCategory(tx, cat).Return(errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateCategory(gomock.Any(), tx, cat).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	e = cs.UpdateCategory(cat)
+	e = cs.UpdateCategory(ctx, cat)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
 	cat = &model.Category{Id: 1, Name: "test"}
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().UpdateCategory(tx, cat).Return(nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateCategory(gomock.Any(), tx, cat).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	e = cs.UpdateCategory(cat)
+	e = cs.UpdateCategory(ctx, cat)
 	assert.Nil(t, e)
 }
 
@@ -112,26 +124,26 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	cs := service.NewCategoryService(mockStore)
-	e := cs.DeleteCategory(1)
+	e := cs.DeleteCategory(ctx, 1)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().DeleteCategory(tx, 1).Return(errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteCategory(gomock.Any(), tx, 1).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	e = cs.DeleteCategory(1)
+	e = cs.DeleteCategory(ctx, 1)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().DeleteCategory(tx, 1).Return(nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteCategory(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	cs = service.NewCategoryService(mockStore)
-	e = cs.DeleteCategory(1)
+	e = cs.DeleteCategory(ctx, 1)
 	assert.Nil(t, e)
 }
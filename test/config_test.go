@@ -1,6 +1,8 @@
 package test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mrlightwood/golang-products-api/config"
@@ -13,3 +15,22 @@ func TestConfig_NewConfig(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, c)
 }
+
+func TestConfig_Invalid(t *testing.T) {
+	for _, invalid := range []string{
+		"scheduler:\n  interval: 0\n",
+		"scheduler:\n  interval: -5\n",
+		"api:\n  trustedproxies: [\"10.0.0.1\"]\n",
+	} {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		assert.Nil(t, os.WriteFile(file, []byte("store:\n  dbpath: \"\\\\db\\\\store_test.db\"\n"+invalid), 0644))
+		c, err := config.NewConfig(file)
+		assert.NotNil(t, err, invalid)
+		assert.Nil(t, c)
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte("store:\n  dbpath: \"\\\\db\\\\store_test.db\"\napi:\n  trustedproxies: [\"10.0.0.0/8\"]\n"), 0644))
+	c, err := config.NewConfig(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8"}, c.Api.TrustedProxies)
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	is := service.NewIdempotencyService(mockStore, time.Hour, time.Minute)
+	tx := new(sql.Tx)
+	begin := func(created bool, existing *model.IdempotencyKey) {
+		mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+		mockStore.EXPECT().DeleteIdempotencyKeys(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Second)
+			return 0, nil
+		}).Times(1)
+		mockStore.EXPECT().CreateIdempotencyKey(gomock.Any(), tx, gomock.Any()).Return(created, nil).Times(1)
+		if !created {
+			mockStore.EXPECT().GetIdempotencyKey(gomock.Any(), tx, "k").Return(existing, nil).Times(1)
+		}
+	}
+	// first request
+	begin(true, nil)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e := is.Begin(ctx, "k", "f")
+	assert.Nil(t, e)
+	assert.Nil(t, r)
+	// another request
+	begin(false, &model.IdempotencyKey{Key: "k", Fingerprint: "other"})
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	_, e = is.Begin(ctx, "k", "f")
+	assert.Equal(t, service.ErrIdempotencyKeyReused, e)
+	// retry while the first request runs
+	relock := func(relocked bool) {
+		mockStore.EXPECT().RelockIdempotencyKey(gomock.Any(), tx, "k", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, tx *sql.Tx, key string, before time.Time, at time.Time) (bool, error) {
+				assert.WithinDuration(t, time.Now().Add(-time.Minute), before, time.Second)
+				assert.WithinDuration(t, time.Now(), at, time.Second)
+				return relocked, nil
+			}).Times(1)
+	}
+	begin(false, &model.IdempotencyKey{Key: "k", Fingerprint: "f"})
+	relock(false)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	_, e = is.Begin(ctx, "k", "f")
+	assert.Equal(t, service.ErrRequestInProgress, e)
+	// retry once the first request is taken for dead
+	begin(false, &model.IdempotencyKey{Key: "k", Fingerprint: "f"})
+	relock(true)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e = is.Begin(ctx, "k", "f")
+	assert.Nil(t, e)
+	assert.Nil(t, r)
+	// retry once it's done
+	response := &model.IdempotentResponse{Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	begin(false, &model.IdempotencyKey{Key: "k", Fingerprint: "f", Response: response})
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	r, e = is.Begin(ctx, "k", "f")
+	assert.Nil(t, e)
+	assert.Equal(t, response, r)
+}
+
+func TestIdempotencyService_CompleteRelease(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	is := service.NewIdempotencyService(mockStore, time.Hour, time.Minute)
+	tx := new(sql.Tx)
+	response := &model.IdempotentResponse{Status: 204}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().SetIdempotentResponse(gomock.Any(), tx, "k", response).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	assert.NotNil(t, is.Complete(ctx, "k", response))
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteIdempotencyKey(gomock.Any(), tx, "k").Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	assert.Nil(t, is.Release(ctx, "k"))
+}
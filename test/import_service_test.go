@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportService_ImportProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// unknown mapping targets are refused before anything is read
+	is := service.NewImportService(mock.NewMockStore(mockCtrl))
+	r, e := is.ImportProducts(ctx, strings.NewReader("a\n"), map[string]string{"a": "colour"}, false, accept)
+	assert.IsType(t, &service.InvalidOperationError{}, e)
+	assert.Nil(t, r)
+
+	// a dry run reports every row and writes nothing
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	created := 8
+	phone := &model.Product{Id: 3, SKU: "A-1", Name: "Phone", Slug: "phone", Price: 10, Tags: []string{}}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Savepoint(gomock.Any(), tx, gomock.Any()).Return(nil).Times(3)
+	mockStore.EXPECT().GetProductBySKU(gomock.Any(), tx, "A-1").Return(phone, nil).Times(1)
+	mockStore.EXPECT().GetProductBySKU(gomock.Any(), tx, "A-2").Return(nil, nil).Times(1)
+	mockStore.EXPECT().GetProductBySKU(gomock.Any(), tx, "A-3").Return(nil, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "watch").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, p *model.Product) (*int, error) {
+		assert.Equal(t, []string{"smart", "wear"}, p.Tags)
+		return &created, nil
+	}).Times(1)
+	mockStore.EXPECT().Release(gomock.Any(), tx, gomock.Any()).Return(nil).Times(2)
+	mockStore.EXPECT().RollbackTo(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	is = service.NewImportService(mockStore)
+	csv := "Article No,name,price,tags,colour\nA-1,Phone,10,,red\nA-2,Tablet,abc,,\nA-3,Watch,5,smart|wear,\n"
+	r, e = is.ImportProducts(ctx, strings.NewReader(csv), map[string]string{"Article No": "sku"}, true, accept)
+	assert.Nil(t, e)
+	assert.Equal(t, &model.ImportReport{DryRun: true, Created: 1, Unchanged: 1, Failed: 1, Rows: []model.ImportRow{
+		{Line: 2, Status: model.ImportStatusUnchanged, Id: &phone.Id},
+		{Line: 3, Status: model.ImportStatusError, Message: "price: not a number"},
+		{Line: 4, Status: model.ImportStatusCreated},
+	}}, r)
+
+	// rows with an id update that product
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Savepoint(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 3).Return(phone, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "phone").Return(&phone.Id, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, tx *sql.Tx, p *model.Product) error {
+		assert.Equal(t, 12.0, p.Price)
+		assert.Equal(t, "Phone", p.Name)
+		return nil
+	}).Times(1)
+	mockStore.EXPECT().Release(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	is = service.NewImportService(mockStore)
+	r, e = is.ImportProducts(ctx, strings.NewReader("id,price\n3,12\n"), nil, false, accept)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, r.Updated)
+	assert.Equal(t, 10.0, phone.Price)
+	// the columns of the row are compared as imported, the other fields are left out
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	weight := 0.5
+	publishAt := time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)
+	watch := &model.Product{Id: 4, SKU: "A-4", Name: "Watch", Slug: "watch", Price: 5, Weight: &weight, PublishAt: &publishAt,
+		Status: model.ProductStatusPublished, Type: model.ProductTypeSimple, ReviewCount: 2}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Savepoint(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().GetProductBySKU(gomock.Any(), tx, "A-4").Return(watch, nil).Times(1)
+	mockStore.EXPECT().Release(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	is = service.NewImportService(mockStore)
+	csv = "sku,name,price,tags,weight,publish_at,status\nA-4,Watch,5.0,,0.5,2021-03-01T10:00:00+02:00,published\n"
+	r, e = is.ImportProducts(ctx, strings.NewReader(csv), nil, false, accept)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, r.Unchanged)
+	// rows of missing brands are reported
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Savepoint(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().GetProductBySKU(gomock.Any(), tx, "A-5").Return(nil, nil).Times(1)
+	mockStore.EXPECT().GetBrand(gomock.Any(), tx, 7).Return(nil, nil).Times(1)
+	mockStore.EXPECT().RollbackTo(gomock.Any(), tx, gomock.Any()).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	is = service.NewImportService(mockStore)
+	r, e = is.ImportProducts(ctx, strings.NewReader("sku,name,price,brand\nA-5,Radio,5,7\n"), nil, false, accept)
+	assert.Nil(t, e)
+	assert.Equal(t, []model.ImportRow{{Line: 2, Status: model.ImportStatusError, Message: "brand: brand not found"}}, r.Rows)
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/api"
+	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// sample returns the value of the metric `name` with `labels`, the sample
+// count of histograms, -1 when there is none
+func sample(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) float64 {
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			switch {
+			case metric.Counter != nil:
+				return metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				return metric.Gauge.GetValue()
+			case metric.Histogram != nil:
+				return float64(metric.Histogram.GetSampleCount())
+			}
+		}
+	}
+	return -1
+}
+
+func TestStore_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ms, err := db.Measure(st, reg)
+	assert.NoError(t, err)
+	categories, err := ms.CountCategories(ctx, nil)
+	assert.NoError(t, err)
+	products, err := ms.CountProducts(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, sample(t, reg, "store_transactions_total", map[string]string{"outcome": "rollback"}))
+	tx, _ := ms.Begin(ctx)
+	ms.GetCategories(ctx, tx)
+	ms.GetCategory(ctx, tx, -1)
+	ms.GetCategory(ctx, tx, -2)
+	assert.NoError(t, ms.Rollback(ctx, tx))
+	assert.Equal(t, 2.0, sample(t, reg, "store_method_duration_seconds", map[string]string{"method": "GetCategory"}))
+	assert.Equal(t, 1.0, sample(t, reg, "store_method_duration_seconds", map[string]string{"method": "GetCategories"}))
+	assert.Equal(t, 1.0, sample(t, reg, "store_transactions_total", map[string]string{"outcome": "rollback"}))
+	assert.Equal(t, 0.0, sample(t, reg, "store_transactions_total", map[string]string{"outcome": "commit"}))
+	tx, _ = ms.Begin(ctx)
+	assert.NoError(t, ms.Commit(ctx, tx))
+	assert.Equal(t, 1.0, sample(t, reg, "store_transactions_total", map[string]string{"outcome": "commit"}))
+	// Pool and catalog, read at each scrape
+	assert.GreaterOrEqual(t, sample(t, reg, "store_open_connections", nil), 1.0)
+	assert.Equal(t, 0.0, sample(t, reg, "store_in_use_connections", nil))
+	assert.Equal(t, float64(categories), sample(t, reg, "catalog_categories", nil))
+	for _, status := range []string{model.ProductStatusDraft, model.ProductStatusPublished, model.ProductStatusArchived} {
+		assert.Equal(t, float64(products[status]), sample(t, reg, "catalog_products", map[string]string{"status": status}), status)
+	}
+	// Scrapes aren't store calls
+	assert.Equal(t, 1.0, sample(t, reg, "store_method_duration_seconds", map[string]string{"method": "CountCategories"}))
+	tx, _ = st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	st.CreateCategory(ctx, tx, &model.Category{Name: "metrics test"})
+	count, _ := st.CountCategories(ctx, tx)
+	assert.Equal(t, categories+1, count)
+}
+
+func TestApi_Metrics(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	conf := &config.Config{LogLevel: 5}
+	cs := mock.NewMockCategoryService(mockCtrl)
+	aks := mock.NewMockApiKeyService(mockCtrl)
+	reg := prometheus.NewRegistry()
+	api := api.NewApi(conf, api.Services{Categories: cs, ApiKeys: aks, Metrics: reg})
+	assert.Equal(t, "Metrics", api.GetApiInfo().MW[0])
+	do := func(method string, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req.Header.Set("X-API-Key", "pk_test")
+		rec := httptest.NewRecorder()
+		api.Http.ServeHTTP(rec, req)
+		return rec
+	}
+	aks.EXPECT().Authenticate(gomock.Any(), "pk_test").Return(&model.ApiKey{Id: 1, Scopes: []string{model.ScopeRead}}, nil).AnyTimes()
+	cs.EXPECT().GetCategory(gomock.Any(), gomock.Any()).Return(&model.Category{Id: 1, Name: "test"}, nil).Times(2)
+	do(echo.GET, "/api/categories/1")
+	do(echo.GET, "/api/categories/2")
+	do(echo.DELETE, "/api/categories/2")
+	do(echo.GET, "/api/unknown/1")
+	assert.Equal(t, 2.0, sample(t, reg, "http_requests_total", map[string]string{"method": "GET", "route": "/api/categories/:id", "status": "200"}))
+	assert.Equal(t, 2.0, sample(t, reg, "http_request_duration_seconds", map[string]string{"method": "GET", "route": "/api/categories/:id", "status": "200"}))
+	assert.Equal(t, 1.0, sample(t, reg, "http_requests_total", map[string]string{"method": "DELETE", "route": "/api/categories/:id", "status": "403"}))
+	assert.Equal(t, 1.0, sample(t, reg, "http_requests_total", map[string]string{"method": "GET", "route": "unmatched", "status": "404"}))
+	assert.Equal(t, 0.0, sample(t, reg, "http_requests_in_flight", nil))
+	// Scraped with a read key, not anonymously
+	req := httptest.NewRequest(echo.GET, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	req = httptest.NewRequest(echo.GET, "/metrics", nil)
+	req.Header.Set(echo.HeaderAccept, "text/plain")
+	req.Header.Set(echo.HeaderAuthorization, "Bearer pk_test")
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "text/plain"))
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/api/categories/:id",status="200"} 2`)
+	assert.Contains(t, rec.Body.String(), "http_requests_in_flight 1")
+	assert.Contains(t, do(echo.GET, "/openapi.json").Body.String(), `"/metrics"`)
+}
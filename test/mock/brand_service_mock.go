@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: brand_service.go
+
+// Package mock_service is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/mrlightwood/golang-products-api/model"
+)
+
+// MockBrandService is a mock of BrandService interface.
+type MockBrandService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBrandServiceMockRecorder
+}
+
+// MockBrandServiceMockRecorder is the mock recorder for MockBrandService.
+type MockBrandServiceMockRecorder struct {
+	mock *MockBrandService
+}
+
+// NewMockBrandService creates a new mock instance.
+func NewMockBrandService(ctrl *gomock.Controller) *MockBrandService {
+	mock := &MockBrandService{ctrl: ctrl}
+	mock.recorder = &MockBrandServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBrandService) EXPECT() *MockBrandServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateBrand mocks base method.
+func (m *MockBrandService) CreateBrand(ctx context.Context, brand *model.Brand) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBrand", ctx, brand)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBrand indicates an expected call of CreateBrand.
+func (mr *MockBrandServiceMockRecorder) CreateBrand(ctx, brand interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBrand", reflect.TypeOf((*MockBrandService)(nil).CreateBrand), ctx, brand)
+}
+
+// DeleteBrand mocks base method.
+func (m *MockBrandService) DeleteBrand(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBrand", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBrand indicates an expected call of DeleteBrand.
+func (mr *MockBrandServiceMockRecorder) DeleteBrand(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBrand", reflect.TypeOf((*MockBrandService)(nil).DeleteBrand), ctx, id)
+}
+
+// GetBrand mocks base method.
+func (m *MockBrandService) GetBrand(ctx context.Context, id int) (*model.Brand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrand", ctx, id)
+	ret0, _ := ret[0].(*model.Brand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrand indicates an expected call of GetBrand.
+func (mr *MockBrandServiceMockRecorder) GetBrand(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrand", reflect.TypeOf((*MockBrandService)(nil).GetBrand), ctx, id)
+}
+
+// GetBrands mocks base method.
+func (m *MockBrandService) GetBrands(ctx context.Context) ([]*model.Brand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrands", ctx)
+	ret0, _ := ret[0].([]*model.Brand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrands indicates an expected call of GetBrands.
+func (mr *MockBrandServiceMockRecorder) GetBrands(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrands", reflect.TypeOf((*MockBrandService)(nil).GetBrands), ctx)
+}
+
+// UpdateBrand mocks base method.
+func (m *MockBrandService) UpdateBrand(ctx context.Context, brand *model.Brand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBrand", ctx, brand)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBrand indicates an expected call of UpdateBrand.
+func (mr *MockBrandServiceMockRecorder) UpdateBrand(ctx, brand interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBrand", reflect.TypeOf((*MockBrandService)(nil).UpdateBrand), ctx, brand)
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,75 +35,191 @@ func (m *MockProductService) EXPECT() *MockProductServiceMockRecorder {
 	return m.recorder
 }
 
+// AddProductRelations mocks base method.
+func (m *MockProductService) AddProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddProductRelations", ctx, id, relations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddProductRelations indicates an expected call of AddProductRelations.
+func (mr *MockProductServiceMockRecorder) AddProductRelations(ctx, id, relations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProductRelations", reflect.TypeOf((*MockProductService)(nil).AddProductRelations), ctx, id, relations)
+}
+
 // CreateProduct mocks base method.
-func (m *MockProductService) CreateProduct(product *model.Product) (*int, error) {
+func (m *MockProductService) CreateProduct(ctx context.Context, product *model.Product) (*int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateProduct", product)
+	ret := m.ctrl.Call(m, "CreateProduct", ctx, product)
 	ret0, _ := ret[0].(*int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateProduct indicates an expected call of CreateProduct.
-func (mr *MockProductServiceMockRecorder) CreateProduct(product interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) CreateProduct(ctx, product interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockProductService)(nil).CreateProduct), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockProductService)(nil).CreateProduct), ctx, product)
 }
 
 // DeleteProduct mocks base method.
-func (m *MockProductService) DeleteProduct(id int) error {
+func (m *MockProductService) DeleteProduct(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProduct", id)
+	ret := m.ctrl.Call(m, "DeleteProduct", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteProduct indicates an expected call of DeleteProduct.
-func (mr *MockProductServiceMockRecorder) DeleteProduct(id interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) DeleteProduct(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockProductService)(nil).DeleteProduct), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockProductService)(nil).DeleteProduct), ctx, id)
+}
+
+// ExportProducts mocks base method.
+func (m *MockProductService) ExportProducts(ctx context.Context, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportProducts", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportProducts indicates an expected call of ExportProducts.
+func (mr *MockProductServiceMockRecorder) ExportProducts(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportProducts", reflect.TypeOf((*MockProductService)(nil).ExportProducts), ctx, filter, fn)
+}
+
+// GetBrandFacets mocks base method.
+func (m *MockProductService) GetBrandFacets(ctx context.Context, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrandFacets", ctx, filter)
+	ret0, _ := ret[0].([]*model.BrandFacet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrandFacets indicates an expected call of GetBrandFacets.
+func (mr *MockProductServiceMockRecorder) GetBrandFacets(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrandFacets", reflect.TypeOf((*MockProductService)(nil).GetBrandFacets), ctx, filter)
 }
 
 // GetProduct mocks base method.
-func (m *MockProductService) GetProduct(id int) (*model.Product, error) {
+func (m *MockProductService) GetProduct(ctx context.Context, id int, view *model.PriceView) (*model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProduct", id)
+	ret := m.ctrl.Call(m, "GetProduct", ctx, id, view)
 	ret0, _ := ret[0].(*model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProduct indicates an expected call of GetProduct.
-func (mr *MockProductServiceMockRecorder) GetProduct(id interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) GetProduct(ctx, id, view interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductService)(nil).GetProduct), ctx, id, view)
+}
+
+// GetProductByBarcode mocks base method.
+func (m *MockProductService) GetProductByBarcode(ctx context.Context, code string, view *model.PriceView) (*model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductByBarcode", ctx, code, view)
+	ret0, _ := ret[0].(*model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductByBarcode indicates an expected call of GetProductByBarcode.
+func (mr *MockProductServiceMockRecorder) GetProductByBarcode(ctx, code, view interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductService)(nil).GetProduct), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByBarcode", reflect.TypeOf((*MockProductService)(nil).GetProductByBarcode), ctx, code, view)
+}
+
+// GetProductBySlug mocks base method.
+func (m *MockProductService) GetProductBySlug(ctx context.Context, slug string, view *model.PriceView) (*model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductBySlug", ctx, slug, view)
+	ret0, _ := ret[0].(*model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductBySlug indicates an expected call of GetProductBySlug.
+func (mr *MockProductServiceMockRecorder) GetProductBySlug(ctx, slug, view interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductBySlug", reflect.TypeOf((*MockProductService)(nil).GetProductBySlug), ctx, slug, view)
 }
 
 // GetProducts mocks base method.
-func (m *MockProductService) GetProducts(category *int) ([]*model.Product, error) {
+func (m *MockProductService) GetProducts(ctx context.Context, filter *model.ProductFilter, view *model.PriceView) ([]*model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProducts", category)
+	ret := m.ctrl.Call(m, "GetProducts", ctx, filter, view)
 	ret0, _ := ret[0].([]*model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProducts indicates an expected call of GetProducts.
-func (mr *MockProductServiceMockRecorder) GetProducts(category interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) GetProducts(ctx, filter, view interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockProductService)(nil).GetProducts), ctx, filter, view)
+}
+
+// GetRelatedProducts mocks base method.
+func (m *MockProductService) GetRelatedProducts(ctx context.Context, id int, relationType string, filter *model.ProductFilter, view *model.PriceView, productStatuses []string) ([]*model.RelatedProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelatedProducts", ctx, id, relationType, filter, view, productStatuses)
+	ret0, _ := ret[0].([]*model.RelatedProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelatedProducts indicates an expected call of GetRelatedProducts.
+func (mr *MockProductServiceMockRecorder) GetRelatedProducts(ctx, id, relationType, filter, view, productStatuses interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelatedProducts", reflect.TypeOf((*MockProductService)(nil).GetRelatedProducts), ctx, id, relationType, filter, view, productStatuses)
+}
+
+// RemoveProductRelations mocks base method.
+func (m *MockProductService) RemoveProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveProductRelations", ctx, id, relations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProductRelations indicates an expected call of RemoveProductRelations.
+func (mr *MockProductServiceMockRecorder) RemoveProductRelations(ctx, id, relations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProductRelations", reflect.TypeOf((*MockProductService)(nil).RemoveProductRelations), ctx, id, relations)
+}
+
+// ReplaceProductRelations mocks base method.
+func (m *MockProductService) ReplaceProductRelations(ctx context.Context, id int, relations []model.ProductRelation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceProductRelations", ctx, id, relations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceProductRelations indicates an expected call of ReplaceProductRelations.
+func (mr *MockProductServiceMockRecorder) ReplaceProductRelations(ctx, id, relations interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockProductService)(nil).GetProducts), category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceProductRelations", reflect.TypeOf((*MockProductService)(nil).ReplaceProductRelations), ctx, id, relations)
 }
 
 // UpdateProduct mocks base method.
-func (m *MockProductService) UpdateProduct(product *model.Product) error {
+func (m *MockProductService) UpdateProduct(ctx context.Context, product *model.Product) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProduct", product)
+	ret := m.ctrl.Call(m, "UpdateProduct", ctx, product)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateProduct indicates an expected call of UpdateProduct.
-func (mr *MockProductServiceMockRecorder) UpdateProduct(product interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) UpdateProduct(ctx, product interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductService)(nil).UpdateProduct), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductService)(nil).UpdateProduct), ctx, product)
 }
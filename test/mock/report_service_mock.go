@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: report_service.go
+
+// Package mock_service is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/mrlightwood/golang-products-api/model"
+)
+
+// MockReportService is a mock of ReportService interface.
+type MockReportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportServiceMockRecorder
+}
+
+// MockReportServiceMockRecorder is the mock recorder for MockReportService.
+type MockReportServiceMockRecorder struct {
+	mock *MockReportService
+}
+
+// NewMockReportService creates a new mock instance.
+func NewMockReportService(ctrl *gomock.Controller) *MockReportService {
+	mock := &MockReportService{ctrl: ctrl}
+	mock.recorder = &MockReportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportService) EXPECT() *MockReportServiceMockRecorder {
+	return m.recorder
+}
+
+// GetMarginReport mocks base method.
+func (m *MockReportService) GetMarginReport(ctx context.Context, filter *model.ProductFilter) (*model.MarginReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMarginReport", ctx, filter)
+	ret0, _ := ret[0].(*model.MarginReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMarginReport indicates an expected call of GetMarginReport.
+func (mr *MockReportServiceMockRecorder) GetMarginReport(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarginReport", reflect.TypeOf((*MockReportService)(nil).GetMarginReport), ctx, filter)
+}
@@ -5,8 +5,10 @@
 package mock
 
 import (
+	context "context"
 	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/mrlightwood/golang-products-api/model"
@@ -35,19 +37,63 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AddProductRelations mocks base method.
+func (m *MockStore) AddProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddProductRelations", ctx, tx, id, relations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddProductRelations indicates an expected call of AddProductRelations.
+func (mr *MockStoreMockRecorder) AddProductRelations(ctx, tx, id, relations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProductRelations", reflect.TypeOf((*MockStore)(nil).AddProductRelations), ctx, tx, id, relations)
+}
+
+// ApplyPublicationSchedule mocks base method.
+func (m *MockStore) ApplyPublicationSchedule(ctx context.Context, tx *sql.Tx, now time.Time) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyPublicationSchedule", ctx, tx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ApplyPublicationSchedule indicates an expected call of ApplyPublicationSchedule.
+func (mr *MockStoreMockRecorder) ApplyPublicationSchedule(ctx, tx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyPublicationSchedule", reflect.TypeOf((*MockStore)(nil).ApplyPublicationSchedule), ctx, tx, now)
+}
+
 // Begin mocks base method.
-func (m *MockStore) Begin() (*sql.Tx, error) {
+func (m *MockStore) Begin(ctx context.Context) (*sql.Tx, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Begin")
+	ret := m.ctrl.Call(m, "Begin", ctx)
 	ret0, _ := ret[0].(*sql.Tx)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Begin indicates an expected call of Begin.
-func (mr *MockStoreMockRecorder) Begin() *gomock.Call {
+func (mr *MockStoreMockRecorder) Begin(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockStore)(nil).Begin), ctx)
+}
+
+// ClearProductRelations mocks base method.
+func (m *MockStore) ClearProductRelations(ctx context.Context, tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearProductRelations", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearProductRelations indicates an expected call of ClearProductRelations.
+func (mr *MockStoreMockRecorder) ClearProductRelations(ctx, tx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockStore)(nil).Begin))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearProductRelations", reflect.TypeOf((*MockStore)(nil).ClearProductRelations), ctx, tx, id)
 }
 
 // Close mocks base method.
@@ -65,175 +111,1184 @@ func (mr *MockStoreMockRecorder) Close() *gomock.Call {
 }
 
 // Commit mocks base method.
-func (m *MockStore) Commit(tx *sql.Tx) error {
+func (m *MockStore) Commit(ctx context.Context, tx *sql.Tx) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Commit", tx)
+	ret := m.ctrl.Call(m, "Commit", ctx, tx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Commit indicates an expected call of Commit.
-func (mr *MockStoreMockRecorder) Commit(tx interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) Commit(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockStore)(nil).Commit), ctx, tx)
+}
+
+// CountApiKeys mocks base method.
+func (m *MockStore) CountApiKeys(ctx context.Context, tx *sql.Tx) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApiKeys", ctx, tx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApiKeys indicates an expected call of CountApiKeys.
+func (mr *MockStoreMockRecorder) CountApiKeys(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApiKeys", reflect.TypeOf((*MockStore)(nil).CountApiKeys), ctx, tx)
+}
+
+// CountCategories mocks base method.
+func (m *MockStore) CountCategories(ctx context.Context, tx *sql.Tx) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCategories", ctx, tx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCategories indicates an expected call of CountCategories.
+func (mr *MockStoreMockRecorder) CountCategories(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCategories", reflect.TypeOf((*MockStore)(nil).CountCategories), ctx, tx)
+}
+
+// CountProducts mocks base method.
+func (m *MockStore) CountProducts(ctx context.Context, tx *sql.Tx) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountProducts", ctx, tx)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountProducts indicates an expected call of CountProducts.
+func (mr *MockStoreMockRecorder) CountProducts(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountProducts", reflect.TypeOf((*MockStore)(nil).CountProducts), ctx, tx)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockStore) CreateApiKey(ctx context.Context, tx *sql.Tx, key *model.ApiKey) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, tx, key)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockStoreMockRecorder) CreateApiKey(ctx, tx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockStore)(nil).CreateApiKey), ctx, tx, key)
+}
+
+// CreateBrand mocks base method.
+func (m *MockStore) CreateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBrand", ctx, tx, brand)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBrand indicates an expected call of CreateBrand.
+func (mr *MockStoreMockRecorder) CreateBrand(ctx, tx, brand interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockStore)(nil).Commit), tx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBrand", reflect.TypeOf((*MockStore)(nil).CreateBrand), ctx, tx, brand)
 }
 
 // CreateCategory mocks base method.
-func (m *MockStore) CreateCategory(tx *sql.Tx, category *model.Category) (*int, error) {
+func (m *MockStore) CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) (*int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateCategory", tx, category)
+	ret := m.ctrl.Call(m, "CreateCategory", ctx, tx, category)
 	ret0, _ := ret[0].(*int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateCategory indicates an expected call of CreateCategory.
-func (mr *MockStoreMockRecorder) CreateCategory(tx, category interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) CreateCategory(ctx, tx, category interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockStore)(nil).CreateCategory), tx, category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockStore)(nil).CreateCategory), ctx, tx, category)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(ctx context.Context, tx *sql.Tx, key *model.IdempotencyKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", ctx, tx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(ctx, tx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), ctx, tx, key)
 }
 
 // CreateProduct mocks base method.
-func (m *MockStore) CreateProduct(tx *sql.Tx, product *model.Product) (*int, error) {
+func (m *MockStore) CreateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) (*int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateProduct", tx, product)
+	ret := m.ctrl.Call(m, "CreateProduct", ctx, tx, product)
 	ret0, _ := ret[0].(*int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateProduct indicates an expected call of CreateProduct.
-func (mr *MockStoreMockRecorder) CreateProduct(tx, product interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) CreateProduct(ctx, tx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockStore)(nil).CreateProduct), ctx, tx, product)
+}
+
+// CreatePromotion mocks base method.
+func (m *MockStore) CreatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", ctx, tx, promotion)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockStoreMockRecorder) CreatePromotion(ctx, tx, promotion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockStore)(nil).CreatePromotion), ctx, tx, promotion)
+}
+
+// CreateReview mocks base method.
+func (m *MockStore) CreateReview(ctx context.Context, tx *sql.Tx, review *model.Review) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReview", ctx, tx, review)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReview indicates an expected call of CreateReview.
+func (mr *MockStoreMockRecorder) CreateReview(ctx, tx, review interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReview", reflect.TypeOf((*MockStore)(nil).CreateReview), ctx, tx, review)
+}
+
+// CreateSupplier mocks base method.
+func (m *MockStore) CreateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSupplier", ctx, tx, supplier)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSupplier indicates an expected call of CreateSupplier.
+func (mr *MockStoreMockRecorder) CreateSupplier(ctx, tx, supplier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupplier", reflect.TypeOf((*MockStore)(nil).CreateSupplier), ctx, tx, supplier)
+}
+
+// CreateTaxClass mocks base method.
+func (m *MockStore) CreateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTaxClass", ctx, tx, taxClass)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTaxClass indicates an expected call of CreateTaxClass.
+func (mr *MockStoreMockRecorder) CreateTaxClass(ctx, tx, taxClass interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTaxClass", reflect.TypeOf((*MockStore)(nil).CreateTaxClass), ctx, tx, taxClass)
+}
+
+// DeleteBrand mocks base method.
+func (m *MockStore) DeleteBrand(ctx context.Context, tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBrand", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBrand indicates an expected call of DeleteBrand.
+func (mr *MockStoreMockRecorder) DeleteBrand(ctx, tx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockStore)(nil).CreateProduct), tx, product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBrand", reflect.TypeOf((*MockStore)(nil).DeleteBrand), ctx, tx, id)
 }
 
 // DeleteCategory mocks base method.
-func (m *MockStore) DeleteCategory(tx *sql.Tx, id int) error {
+func (m *MockStore) DeleteCategory(ctx context.Context, tx *sql.Tx, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCategory", tx, id)
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, tx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteCategory indicates an expected call of DeleteCategory.
-func (mr *MockStoreMockRecorder) DeleteCategory(tx, id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) DeleteCategory(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockStore)(nil).DeleteCategory), ctx, tx, id)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStore) DeleteIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, tx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStoreMockRecorder) DeleteIdempotencyKey(ctx, tx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStore)(nil).DeleteIdempotencyKey), ctx, tx, key)
+}
+
+// DeleteIdempotencyKeys mocks base method.
+func (m *MockStore) DeleteIdempotencyKeys(ctx context.Context, tx *sql.Tx, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKeys", ctx, tx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdempotencyKeys indicates an expected call of DeleteIdempotencyKeys.
+func (mr *MockStoreMockRecorder) DeleteIdempotencyKeys(ctx, tx, before interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockStore)(nil).DeleteCategory), tx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKeys", reflect.TypeOf((*MockStore)(nil).DeleteIdempotencyKeys), ctx, tx, before)
 }
 
 // DeleteProduct mocks base method.
-func (m *MockStore) DeleteProduct(tx *sql.Tx, id int) error {
+func (m *MockStore) DeleteProduct(ctx context.Context, tx *sql.Tx, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProduct", tx, id)
+	ret := m.ctrl.Call(m, "DeleteProduct", ctx, tx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteProduct indicates an expected call of DeleteProduct.
-func (mr *MockStoreMockRecorder) DeleteProduct(tx, id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) DeleteProduct(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockStore)(nil).DeleteProduct), ctx, tx, id)
+}
+
+// DeletePromotion mocks base method.
+func (m *MockStore) DeletePromotion(ctx context.Context, tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePromotion", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePromotion indicates an expected call of DeletePromotion.
+func (mr *MockStoreMockRecorder) DeletePromotion(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePromotion", reflect.TypeOf((*MockStore)(nil).DeletePromotion), ctx, tx, id)
+}
+
+// DeleteSupplier mocks base method.
+func (m *MockStore) DeleteSupplier(ctx context.Context, tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSupplier", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSupplier indicates an expected call of DeleteSupplier.
+func (mr *MockStoreMockRecorder) DeleteSupplier(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSupplier", reflect.TypeOf((*MockStore)(nil).DeleteSupplier), ctx, tx, id)
+}
+
+// DeleteTaxClass mocks base method.
+func (m *MockStore) DeleteTaxClass(ctx context.Context, tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaxClass", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTaxClass indicates an expected call of DeleteTaxClass.
+func (mr *MockStoreMockRecorder) DeleteTaxClass(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaxClass", reflect.TypeOf((*MockStore)(nil).DeleteTaxClass), ctx, tx, id)
+}
+
+// ExportProducts mocks base method.
+func (m *MockStore) ExportProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportProducts", ctx, tx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportProducts indicates an expected call of ExportProducts.
+func (mr *MockStoreMockRecorder) ExportProducts(ctx, tx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportProducts", reflect.TypeOf((*MockStore)(nil).ExportProducts), ctx, tx, filter, fn)
+}
+
+// GetActivePromotions mocks base method.
+func (m *MockStore) GetActivePromotions(ctx context.Context, tx *sql.Tx, now time.Time) ([]*model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivePromotions", ctx, tx, now)
+	ret0, _ := ret[0].([]*model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivePromotions indicates an expected call of GetActivePromotions.
+func (mr *MockStoreMockRecorder) GetActivePromotions(ctx, tx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivePromotions", reflect.TypeOf((*MockStore)(nil).GetActivePromotions), ctx, tx, now)
+}
+
+// GetApiKey mocks base method.
+func (m *MockStore) GetApiKey(ctx context.Context, tx *sql.Tx, id int) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKey", ctx, tx, id)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKey indicates an expected call of GetApiKey.
+func (mr *MockStoreMockRecorder) GetApiKey(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKey", reflect.TypeOf((*MockStore)(nil).GetApiKey), ctx, tx, id)
+}
+
+// GetApiKeyByHash mocks base method.
+func (m *MockStore) GetApiKeyByHash(ctx context.Context, tx *sql.Tx, hash string) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeyByHash", ctx, tx, hash)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeyByHash indicates an expected call of GetApiKeyByHash.
+func (mr *MockStoreMockRecorder) GetApiKeyByHash(ctx, tx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeyByHash", reflect.TypeOf((*MockStore)(nil).GetApiKeyByHash), ctx, tx, hash)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockStore) GetApiKeys(ctx context.Context, tx *sql.Tx) ([]*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", ctx, tx)
+	ret0, _ := ret[0].([]*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockStoreMockRecorder) GetApiKeys(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockStore)(nil).GetApiKeys), ctx, tx)
+}
+
+// GetBrand mocks base method.
+func (m *MockStore) GetBrand(ctx context.Context, tx *sql.Tx, id int) (*model.Brand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrand", ctx, tx, id)
+	ret0, _ := ret[0].(*model.Brand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrand indicates an expected call of GetBrand.
+func (mr *MockStoreMockRecorder) GetBrand(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrand", reflect.TypeOf((*MockStore)(nil).GetBrand), ctx, tx, id)
+}
+
+// GetBrandFacets mocks base method.
+func (m *MockStore) GetBrandFacets(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.BrandFacet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrandFacets", ctx, tx, filter)
+	ret0, _ := ret[0].([]*model.BrandFacet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrandFacets indicates an expected call of GetBrandFacets.
+func (mr *MockStoreMockRecorder) GetBrandFacets(ctx, tx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrandFacets", reflect.TypeOf((*MockStore)(nil).GetBrandFacets), ctx, tx, filter)
+}
+
+// GetBrands mocks base method.
+func (m *MockStore) GetBrands(ctx context.Context, tx *sql.Tx) ([]*model.Brand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrands", ctx, tx)
+	ret0, _ := ret[0].([]*model.Brand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrands indicates an expected call of GetBrands.
+func (mr *MockStoreMockRecorder) GetBrands(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrands", reflect.TypeOf((*MockStore)(nil).GetBrands), ctx, tx)
+}
+
+// GetBundlesContaining mocks base method.
+func (m *MockStore) GetBundlesContaining(ctx context.Context, tx *sql.Tx, id int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBundlesContaining", ctx, tx, id)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBundlesContaining indicates an expected call of GetBundlesContaining.
+func (mr *MockStoreMockRecorder) GetBundlesContaining(ctx, tx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockStore)(nil).DeleteProduct), tx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBundlesContaining", reflect.TypeOf((*MockStore)(nil).GetBundlesContaining), ctx, tx, id)
 }
 
 // GetCategories mocks base method.
-func (m *MockStore) GetCategories(tx *sql.Tx) ([]*model.Category, error) {
+func (m *MockStore) GetCategories(ctx context.Context, tx *sql.Tx) ([]*model.Category, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCategories", tx)
+	ret := m.ctrl.Call(m, "GetCategories", ctx, tx)
 	ret0, _ := ret[0].([]*model.Category)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCategories indicates an expected call of GetCategories.
-func (mr *MockStoreMockRecorder) GetCategories(tx interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetCategories(ctx, tx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockStore)(nil).GetCategories), tx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockStore)(nil).GetCategories), ctx, tx)
 }
 
 // GetCategory mocks base method.
-func (m *MockStore) GetCategory(tx *sql.Tx, id int) (*model.Category, error) {
+func (m *MockStore) GetCategory(ctx context.Context, tx *sql.Tx, id int) (*model.Category, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCategory", tx, id)
+	ret := m.ctrl.Call(m, "GetCategory", ctx, tx, id)
 	ret0, _ := ret[0].(*model.Category)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCategory indicates an expected call of GetCategory.
-func (mr *MockStoreMockRecorder) GetCategory(tx, id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetCategory(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockStore)(nil).GetCategory), ctx, tx, id)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(ctx context.Context, tx *sql.Tx, key string) (*model.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, tx, key)
+	ret0, _ := ret[0].(*model.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(ctx, tx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockStore)(nil).GetCategory), tx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), ctx, tx, key)
 }
 
 // GetProduct mocks base method.
-func (m *MockStore) GetProduct(tx *sql.Tx, id int) (*model.Product, error) {
+func (m *MockStore) GetProduct(ctx context.Context, tx *sql.Tx, id int) (*model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProduct", tx, id)
+	ret := m.ctrl.Call(m, "GetProduct", ctx, tx, id)
 	ret0, _ := ret[0].(*model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProduct indicates an expected call of GetProduct.
-func (mr *MockStoreMockRecorder) GetProduct(tx, id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetProduct(ctx, tx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockStore)(nil).GetProduct), tx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockStore)(nil).GetProduct), ctx, tx, id)
 }
 
-// GetProducts mocks base method.
-func (m *MockStore) GetProducts(tx *sql.Tx, category *int) ([]*model.Product, error) {
+// GetProductByBarcode mocks base method.
+func (m *MockStore) GetProductByBarcode(ctx context.Context, tx *sql.Tx, code string) (*model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProducts", tx, category)
-	ret0, _ := ret[0].([]*model.Product)
+	ret := m.ctrl.Call(m, "GetProductByBarcode", ctx, tx, code)
+	ret0, _ := ret[0].(*model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetProducts indicates an expected call of GetProducts.
-func (mr *MockStoreMockRecorder) GetProducts(tx, category interface{}) *gomock.Call {
+// GetProductByBarcode indicates an expected call of GetProductByBarcode.
+func (mr *MockStoreMockRecorder) GetProductByBarcode(ctx, tx, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockStore)(nil).GetProducts), tx, category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByBarcode", reflect.TypeOf((*MockStore)(nil).GetProductByBarcode), ctx, tx, code)
 }
 
-// Rollback mocks base method.
-func (m *MockStore) Rollback(tx *sql.Tx) error {
+// GetProductBySKU mocks base method.
+func (m *MockStore) GetProductBySKU(ctx context.Context, tx *sql.Tx, sku string) (*model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Rollback", tx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetProductBySKU", ctx, tx, sku)
+	ret0, _ := ret[0].(*model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Rollback indicates an expected call of Rollback.
-func (mr *MockStoreMockRecorder) Rollback(tx interface{}) *gomock.Call {
+// GetProductBySKU indicates an expected call of GetProductBySKU.
+func (mr *MockStoreMockRecorder) GetProductBySKU(ctx, tx, sku interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockStore)(nil).Rollback), tx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductBySKU", reflect.TypeOf((*MockStore)(nil).GetProductBySKU), ctx, tx, sku)
 }
 
-// UpdateCategory mocks base method.
-func (m *MockStore) UpdateCategory(tx *sql.Tx, category *model.Category) error {
+// GetProductRelations mocks base method.
+func (m *MockStore) GetProductRelations(ctx context.Context, tx *sql.Tx, id int, relationType string) ([]model.ProductRelation, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateCategory", tx, category)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetProductRelations", ctx, tx, id, relationType)
+	ret0, _ := ret[0].([]model.ProductRelation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// UpdateCategory indicates an expected call of UpdateCategory.
-func (mr *MockStoreMockRecorder) UpdateCategory(tx, category interface{}) *gomock.Call {
+// GetProductRelations indicates an expected call of GetProductRelations.
+func (mr *MockStoreMockRecorder) GetProductRelations(ctx, tx, id, relationType interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockStore)(nil).UpdateCategory), tx, category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductRelations", reflect.TypeOf((*MockStore)(nil).GetProductRelations), ctx, tx, id, relationType)
 }
 
-// UpdateProduct mocks base method.
-func (m *MockStore) UpdateProduct(tx *sql.Tx, product *model.Product) error {
+// GetProductSuppliers mocks base method.
+func (m *MockStore) GetProductSuppliers(ctx context.Context, tx *sql.Tx, id int) ([]model.ProductSupplier, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProduct", tx, product)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetProductSuppliers", ctx, tx, id)
+	ret0, _ := ret[0].([]model.ProductSupplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductSuppliers indicates an expected call of GetProductSuppliers.
+func (mr *MockStoreMockRecorder) GetProductSuppliers(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductSuppliers", reflect.TypeOf((*MockStore)(nil).GetProductSuppliers), ctx, tx, id)
+}
+
+// GetProducts mocks base method.
+func (m *MockStore) GetProducts(ctx context.Context, tx *sql.Tx, filter *model.ProductFilter) ([]*model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProducts", ctx, tx, filter)
+	ret0, _ := ret[0].([]*model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProducts indicates an expected call of GetProducts.
+func (mr *MockStoreMockRecorder) GetProducts(ctx, tx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockStore)(nil).GetProducts), ctx, tx, filter)
+}
+
+// GetPromotion mocks base method.
+func (m *MockStore) GetPromotion(ctx context.Context, tx *sql.Tx, id int) (*model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotion", ctx, tx, id)
+	ret0, _ := ret[0].(*model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotion indicates an expected call of GetPromotion.
+func (mr *MockStoreMockRecorder) GetPromotion(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotion", reflect.TypeOf((*MockStore)(nil).GetPromotion), ctx, tx, id)
+}
+
+// GetPromotions mocks base method.
+func (m *MockStore) GetPromotions(ctx context.Context, tx *sql.Tx) ([]*model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions", ctx, tx)
+	ret0, _ := ret[0].([]*model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockStoreMockRecorder) GetPromotions(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockStore)(nil).GetPromotions), ctx, tx)
+}
+
+// GetReviews mocks base method.
+func (m *MockStore) GetReviews(ctx context.Context, tx *sql.Tx, product int, status string) ([]*model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReviews", ctx, tx, product, status)
+	ret0, _ := ret[0].([]*model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReviews indicates an expected call of GetReviews.
+func (mr *MockStoreMockRecorder) GetReviews(ctx, tx, product, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReviews", reflect.TypeOf((*MockStore)(nil).GetReviews), ctx, tx, product, status)
+}
+
+// GetSupplier mocks base method.
+func (m *MockStore) GetSupplier(ctx context.Context, tx *sql.Tx, id int) (*model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSupplier", ctx, tx, id)
+	ret0, _ := ret[0].(*model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSupplier indicates an expected call of GetSupplier.
+func (mr *MockStoreMockRecorder) GetSupplier(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSupplier", reflect.TypeOf((*MockStore)(nil).GetSupplier), ctx, tx, id)
+}
+
+// GetSuppliers mocks base method.
+func (m *MockStore) GetSuppliers(ctx context.Context, tx *sql.Tx) ([]*model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSuppliers", ctx, tx)
+	ret0, _ := ret[0].([]*model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSuppliers indicates an expected call of GetSuppliers.
+func (mr *MockStoreMockRecorder) GetSuppliers(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSuppliers", reflect.TypeOf((*MockStore)(nil).GetSuppliers), ctx, tx)
+}
+
+// GetTaxClass mocks base method.
+func (m *MockStore) GetTaxClass(ctx context.Context, tx *sql.Tx, id int) (*model.TaxClass, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxClass", ctx, tx, id)
+	ret0, _ := ret[0].(*model.TaxClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxClass indicates an expected call of GetTaxClass.
+func (mr *MockStoreMockRecorder) GetTaxClass(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxClass", reflect.TypeOf((*MockStore)(nil).GetTaxClass), ctx, tx, id)
+}
+
+// GetTaxClasses mocks base method.
+func (m *MockStore) GetTaxClasses(ctx context.Context, tx *sql.Tx) ([]*model.TaxClass, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxClasses", ctx, tx)
+	ret0, _ := ret[0].([]*model.TaxClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxClasses indicates an expected call of GetTaxClasses.
+func (mr *MockStoreMockRecorder) GetTaxClasses(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxClasses", reflect.TypeOf((*MockStore)(nil).GetTaxClasses), ctx, tx)
+}
+
+// GetTaxRates mocks base method.
+func (m *MockStore) GetTaxRates(ctx context.Context, tx *sql.Tx, region string) (map[int]float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxRates", ctx, tx, region)
+	ret0, _ := ret[0].(map[int]float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxRates indicates an expected call of GetTaxRates.
+func (mr *MockStoreMockRecorder) GetTaxRates(ctx, tx, region interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxRates", reflect.TypeOf((*MockStore)(nil).GetTaxRates), ctx, tx, region)
+}
+
+// Release mocks base method.
+func (m *MockStore) Release(ctx context.Context, tx *sql.Tx, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, tx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockStoreMockRecorder) Release(ctx, tx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockStore)(nil).Release), ctx, tx, name)
+}
+
+// RelockIdempotencyKey mocks base method.
+func (m *MockStore) RelockIdempotencyKey(ctx context.Context, tx *sql.Tx, key string, before, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelockIdempotencyKey", ctx, tx, key, before, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelockIdempotencyKey indicates an expected call of RelockIdempotencyKey.
+func (mr *MockStoreMockRecorder) RelockIdempotencyKey(ctx, tx, key, before, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelockIdempotencyKey", reflect.TypeOf((*MockStore)(nil).RelockIdempotencyKey), ctx, tx, key, before, at)
+}
+
+// RemoveProductRelations mocks base method.
+func (m *MockStore) RemoveProductRelations(ctx context.Context, tx *sql.Tx, id int, relations []model.ProductRelation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveProductRelations", ctx, tx, id, relations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProductRelations indicates an expected call of RemoveProductRelations.
+func (mr *MockStoreMockRecorder) RemoveProductRelations(ctx, tx, id, relations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProductRelations", reflect.TypeOf((*MockStore)(nil).RemoveProductRelations), ctx, tx, id, relations)
+}
+
+// ResolveSlug mocks base method.
+func (m *MockStore) ResolveSlug(ctx context.Context, tx *sql.Tx, entity, slug string) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveSlug", ctx, tx, entity, slug)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveSlug indicates an expected call of ResolveSlug.
+func (mr *MockStoreMockRecorder) ResolveSlug(ctx, tx, entity, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveSlug", reflect.TypeOf((*MockStore)(nil).ResolveSlug), ctx, tx, entity, slug)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockStore) RevokeApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", ctx, tx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockStoreMockRecorder) RevokeApiKey(ctx, tx, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockStore)(nil).RevokeApiKey), ctx, tx, id, at)
+}
+
+// Rollback mocks base method.
+func (m *MockStore) Rollback(ctx context.Context, tx *sql.Tx) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", ctx, tx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockStoreMockRecorder) Rollback(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockStore)(nil).Rollback), ctx, tx)
+}
+
+// RollbackTo mocks base method.
+func (m *MockStore) RollbackTo(ctx context.Context, tx *sql.Tx, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackTo", ctx, tx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackTo indicates an expected call of RollbackTo.
+func (mr *MockStoreMockRecorder) RollbackTo(ctx, tx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackTo", reflect.TypeOf((*MockStore)(nil).RollbackTo), ctx, tx, name)
+}
+
+// Savepoint mocks base method.
+func (m *MockStore) Savepoint(ctx context.Context, tx *sql.Tx, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Savepoint", ctx, tx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Savepoint indicates an expected call of Savepoint.
+func (mr *MockStoreMockRecorder) Savepoint(ctx, tx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Savepoint", reflect.TypeOf((*MockStore)(nil).Savepoint), ctx, tx, name)
+}
+
+// SetApiKeyHash mocks base method.
+func (m *MockStore) SetApiKeyHash(ctx context.Context, tx *sql.Tx, id int, prefix, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetApiKeyHash", ctx, tx, id, prefix, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetApiKeyHash indicates an expected call of SetApiKeyHash.
+func (mr *MockStoreMockRecorder) SetApiKeyHash(ctx, tx, id, prefix, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApiKeyHash", reflect.TypeOf((*MockStore)(nil).SetApiKeyHash), ctx, tx, id, prefix, hash)
+}
+
+// SetIdempotentResponse mocks base method.
+func (m *MockStore) SetIdempotentResponse(ctx context.Context, tx *sql.Tx, key string, response *model.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdempotentResponse", ctx, tx, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetIdempotentResponse indicates an expected call of SetIdempotentResponse.
+func (mr *MockStoreMockRecorder) SetIdempotentResponse(ctx, tx, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdempotentResponse", reflect.TypeOf((*MockStore)(nil).SetIdempotentResponse), ctx, tx, key, response)
+}
+
+// SetProductSuppliers mocks base method.
+func (m *MockStore) SetProductSuppliers(ctx context.Context, tx *sql.Tx, id int, suppliers []model.ProductSupplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductSuppliers", ctx, tx, id, suppliers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductSuppliers indicates an expected call of SetProductSuppliers.
+func (mr *MockStoreMockRecorder) SetProductSuppliers(ctx, tx, id, suppliers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductSuppliers", reflect.TypeOf((*MockStore)(nil).SetProductSuppliers), ctx, tx, id, suppliers)
+}
+
+// SetReviewStatus mocks base method.
+func (m *MockStore) SetReviewStatus(ctx context.Context, tx *sql.Tx, id int, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReviewStatus", ctx, tx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReviewStatus indicates an expected call of SetReviewStatus.
+func (mr *MockStoreMockRecorder) SetReviewStatus(ctx, tx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReviewStatus", reflect.TypeOf((*MockStore)(nil).SetReviewStatus), ctx, tx, id, status)
+}
+
+// Stats mocks base method.
+func (m *MockStore) Stats() sql.DBStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(sql.DBStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockStoreMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockStore)(nil).Stats))
+}
+
+// TouchApiKey mocks base method.
+func (m *MockStore) TouchApiKey(ctx context.Context, tx *sql.Tx, id int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchApiKey", ctx, tx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchApiKey indicates an expected call of TouchApiKey.
+func (mr *MockStoreMockRecorder) TouchApiKey(ctx, tx, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchApiKey", reflect.TypeOf((*MockStore)(nil).TouchApiKey), ctx, tx, id, at)
+}
+
+// UpdateBrand mocks base method.
+func (m *MockStore) UpdateBrand(ctx context.Context, tx *sql.Tx, brand *model.Brand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBrand", ctx, tx, brand)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBrand indicates an expected call of UpdateBrand.
+func (mr *MockStoreMockRecorder) UpdateBrand(ctx, tx, brand interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBrand", reflect.TypeOf((*MockStore)(nil).UpdateBrand), ctx, tx, brand)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockStore) UpdateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, tx, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockStoreMockRecorder) UpdateCategory(ctx, tx, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockStore)(nil).UpdateCategory), ctx, tx, category)
+}
+
+// UpdateProduct mocks base method.
+func (m *MockStore) UpdateProduct(ctx context.Context, tx *sql.Tx, product *model.Product) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProduct", ctx, tx, product)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // UpdateProduct indicates an expected call of UpdateProduct.
-func (mr *MockStoreMockRecorder) UpdateProduct(tx, product interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) UpdateProduct(ctx, tx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockStore)(nil).UpdateProduct), ctx, tx, product)
+}
+
+// UpdatePromotion mocks base method.
+func (m *MockStore) UpdatePromotion(ctx context.Context, tx *sql.Tx, promotion *model.Promotion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePromotion", ctx, tx, promotion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePromotion indicates an expected call of UpdatePromotion.
+func (mr *MockStoreMockRecorder) UpdatePromotion(ctx, tx, promotion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePromotion", reflect.TypeOf((*MockStore)(nil).UpdatePromotion), ctx, tx, promotion)
+}
+
+// UpdateSupplier mocks base method.
+func (m *MockStore) UpdateSupplier(ctx context.Context, tx *sql.Tx, supplier *model.Supplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSupplier", ctx, tx, supplier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSupplier indicates an expected call of UpdateSupplier.
+func (mr *MockStoreMockRecorder) UpdateSupplier(ctx, tx, supplier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSupplier", reflect.TypeOf((*MockStore)(nil).UpdateSupplier), ctx, tx, supplier)
+}
+
+// UpdateTaxClass mocks base method.
+func (m *MockStore) UpdateTaxClass(ctx context.Context, tx *sql.Tx, taxClass *model.TaxClass) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaxClass", ctx, tx, taxClass)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTaxClass indicates an expected call of UpdateTaxClass.
+func (mr *MockStoreMockRecorder) UpdateTaxClass(ctx, tx, taxClass interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaxClass", reflect.TypeOf((*MockStore)(nil).UpdateTaxClass), ctx, tx, taxClass)
+}
+
+// Mockexecutor is a mock of executor interface.
+type Mockexecutor struct {
+	ctrl     *gomock.Controller
+	recorder *MockexecutorMockRecorder
+}
+
+// MockexecutorMockRecorder is the mock recorder for Mockexecutor.
+type MockexecutorMockRecorder struct {
+	mock *Mockexecutor
+}
+
+// NewMockexecutor creates a new mock instance.
+func NewMockexecutor(ctrl *gomock.Controller) *Mockexecutor {
+	mock := &Mockexecutor{ctrl: ctrl}
+	mock.recorder = &MockexecutorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockexecutor) EXPECT() *MockexecutorMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *Mockexecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockexecutorMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*Mockexecutor)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *Mockexecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(*sql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockexecutorMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*Mockexecutor)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *Mockexecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(*sql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockexecutorMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*Mockexecutor)(nil).QueryRowContext), varargs...)
+}
+
+// Mockquerier is a mock of querier interface.
+type Mockquerier struct {
+	ctrl     *gomock.Controller
+	recorder *MockquerierMockRecorder
+}
+
+// MockquerierMockRecorder is the mock recorder for Mockquerier.
+type MockquerierMockRecorder struct {
+	mock *Mockquerier
+}
+
+// NewMockquerier creates a new mock instance.
+func NewMockquerier(ctrl *gomock.Controller) *Mockquerier {
+	mock := &Mockquerier{ctrl: ctrl}
+	mock.recorder = &MockquerierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockquerier) EXPECT() *MockquerierMockRecorder {
+	return m.recorder
+}
+
+// Exec mocks base method.
+func (m *Mockquerier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockquerierMockRecorder) Exec(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*Mockquerier)(nil).Exec), varargs...)
+}
+
+// Query mocks base method.
+func (m *Mockquerier) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(*sql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockquerierMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*Mockquerier)(nil).Query), varargs...)
+}
+
+// QueryRow mocks base method.
+func (m *Mockquerier) QueryRow(query string, args ...interface{}) *sql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(*sql.Row)
+	return ret0
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockquerierMockRecorder) QueryRow(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*Mockquerier)(nil).QueryRow), varargs...)
+}
+
+// Mockscanner is a mock of scanner interface.
+type Mockscanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockscannerMockRecorder
+}
+
+// MockscannerMockRecorder is the mock recorder for Mockscanner.
+type MockscannerMockRecorder struct {
+	mock *Mockscanner
+}
+
+// NewMockscanner creates a new mock instance.
+func NewMockscanner(ctrl *gomock.Controller) *Mockscanner {
+	mock := &Mockscanner{ctrl: ctrl}
+	mock.recorder = &MockscannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockscanner) EXPECT() *MockscannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *Mockscanner) Scan(dest ...interface{}) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockscannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockStore)(nil).UpdateProduct), tx, product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*Mockscanner)(nil).Scan), dest...)
 }
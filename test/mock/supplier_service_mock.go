@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: supplier_service.go
+
+// Package mock_service is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/mrlightwood/golang-products-api/model"
+)
+
+// MockSupplierService is a mock of SupplierService interface.
+type MockSupplierService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSupplierServiceMockRecorder
+}
+
+// MockSupplierServiceMockRecorder is the mock recorder for MockSupplierService.
+type MockSupplierServiceMockRecorder struct {
+	mock *MockSupplierService
+}
+
+// NewMockSupplierService creates a new mock instance.
+func NewMockSupplierService(ctrl *gomock.Controller) *MockSupplierService {
+	mock := &MockSupplierService{ctrl: ctrl}
+	mock.recorder = &MockSupplierServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSupplierService) EXPECT() *MockSupplierServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateSupplier mocks base method.
+func (m *MockSupplierService) CreateSupplier(ctx context.Context, supplier *model.Supplier) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSupplier", ctx, supplier)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSupplier indicates an expected call of CreateSupplier.
+func (mr *MockSupplierServiceMockRecorder) CreateSupplier(ctx, supplier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupplier", reflect.TypeOf((*MockSupplierService)(nil).CreateSupplier), ctx, supplier)
+}
+
+// DeleteSupplier mocks base method.
+func (m *MockSupplierService) DeleteSupplier(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSupplier", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSupplier indicates an expected call of DeleteSupplier.
+func (mr *MockSupplierServiceMockRecorder) DeleteSupplier(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSupplier", reflect.TypeOf((*MockSupplierService)(nil).DeleteSupplier), ctx, id)
+}
+
+// GetProductSuppliers mocks base method.
+func (m *MockSupplierService) GetProductSuppliers(ctx context.Context, id int) ([]model.ProductSupplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductSuppliers", ctx, id)
+	ret0, _ := ret[0].([]model.ProductSupplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductSuppliers indicates an expected call of GetProductSuppliers.
+func (mr *MockSupplierServiceMockRecorder) GetProductSuppliers(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductSuppliers", reflect.TypeOf((*MockSupplierService)(nil).GetProductSuppliers), ctx, id)
+}
+
+// GetSupplier mocks base method.
+func (m *MockSupplierService) GetSupplier(ctx context.Context, id int) (*model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSupplier", ctx, id)
+	ret0, _ := ret[0].(*model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSupplier indicates an expected call of GetSupplier.
+func (mr *MockSupplierServiceMockRecorder) GetSupplier(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSupplier", reflect.TypeOf((*MockSupplierService)(nil).GetSupplier), ctx, id)
+}
+
+// GetSuppliers mocks base method.
+func (m *MockSupplierService) GetSuppliers(ctx context.Context) ([]*model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSuppliers", ctx)
+	ret0, _ := ret[0].([]*model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSuppliers indicates an expected call of GetSuppliers.
+func (mr *MockSupplierServiceMockRecorder) GetSuppliers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSuppliers", reflect.TypeOf((*MockSupplierService)(nil).GetSuppliers), ctx)
+}
+
+// SetProductSuppliers mocks base method.
+func (m *MockSupplierService) SetProductSuppliers(ctx context.Context, id int, suppliers []model.ProductSupplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductSuppliers", ctx, id, suppliers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductSuppliers indicates an expected call of SetProductSuppliers.
+func (mr *MockSupplierServiceMockRecorder) SetProductSuppliers(ctx, id, suppliers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductSuppliers", reflect.TypeOf((*MockSupplierService)(nil).SetProductSuppliers), ctx, id, suppliers)
+}
+
+// UpdateSupplier mocks base method.
+func (m *MockSupplierService) UpdateSupplier(ctx context.Context, supplier *model.Supplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSupplier", ctx, supplier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSupplier indicates an expected call of UpdateSupplier.
+func (mr *MockSupplierServiceMockRecorder) UpdateSupplier(ctx, supplier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSupplier", reflect.TypeOf((*MockSupplierService)(nil).UpdateSupplier), ctx, supplier)
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: tax_class_service.go
+
+// Package mock_service is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/mrlightwood/golang-products-api/model"
+)
+
+// MockTaxClassService is a mock of TaxClassService interface.
+type MockTaxClassService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaxClassServiceMockRecorder
+}
+
+// MockTaxClassServiceMockRecorder is the mock recorder for MockTaxClassService.
+type MockTaxClassServiceMockRecorder struct {
+	mock *MockTaxClassService
+}
+
+// NewMockTaxClassService creates a new mock instance.
+func NewMockTaxClassService(ctrl *gomock.Controller) *MockTaxClassService {
+	mock := &MockTaxClassService{ctrl: ctrl}
+	mock.recorder = &MockTaxClassServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaxClassService) EXPECT() *MockTaxClassServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateTaxClass mocks base method.
+func (m *MockTaxClassService) CreateTaxClass(ctx context.Context, taxClass *model.TaxClass) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTaxClass", ctx, taxClass)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTaxClass indicates an expected call of CreateTaxClass.
+func (mr *MockTaxClassServiceMockRecorder) CreateTaxClass(ctx, taxClass interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTaxClass", reflect.TypeOf((*MockTaxClassService)(nil).CreateTaxClass), ctx, taxClass)
+}
+
+// DeleteTaxClass mocks base method.
+func (m *MockTaxClassService) DeleteTaxClass(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaxClass", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTaxClass indicates an expected call of DeleteTaxClass.
+func (mr *MockTaxClassServiceMockRecorder) DeleteTaxClass(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaxClass", reflect.TypeOf((*MockTaxClassService)(nil).DeleteTaxClass), ctx, id)
+}
+
+// GetTaxClass mocks base method.
+func (m *MockTaxClassService) GetTaxClass(ctx context.Context, id int) (*model.TaxClass, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxClass", ctx, id)
+	ret0, _ := ret[0].(*model.TaxClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxClass indicates an expected call of GetTaxClass.
+func (mr *MockTaxClassServiceMockRecorder) GetTaxClass(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxClass", reflect.TypeOf((*MockTaxClassService)(nil).GetTaxClass), ctx, id)
+}
+
+// GetTaxClasses mocks base method.
+func (m *MockTaxClassService) GetTaxClasses(ctx context.Context) ([]*model.TaxClass, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxClasses", ctx)
+	ret0, _ := ret[0].([]*model.TaxClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxClasses indicates an expected call of GetTaxClasses.
+func (mr *MockTaxClassServiceMockRecorder) GetTaxClasses(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxClasses", reflect.TypeOf((*MockTaxClassService)(nil).GetTaxClasses), ctx)
+}
+
+// UpdateTaxClass mocks base method.
+func (m *MockTaxClassService) UpdateTaxClass(ctx context.Context, taxClass *model.TaxClass) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaxClass", ctx, taxClass)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTaxClass indicates an expected call of UpdateTaxClass.
+func (mr *MockTaxClassServiceMockRecorder) UpdateTaxClass(ctx, taxClass interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaxClass", reflect.TypeOf((*MockTaxClassService)(nil).UpdateTaxClass), ctx, taxClass)
+}
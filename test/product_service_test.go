@@ -1,9 +1,11 @@
 package test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/mrlightwood/golang-products-api/model"
@@ -16,30 +18,75 @@ func TestProductService_GetProduct(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().GetProduct(nil, 1).Return(nil, errors.New("test")).Times(1)
-	mockStore.EXPECT().GetProduct(nil, 2).Return(&model.Product{Id: 2, Name: "test"}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Name: "test"}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).Times(1)
 	ps := service.NewProductService(mockStore)
-	r, e := ps.GetProduct(1)
+	r, e := ps.GetProduct(ctx, 1, nil)
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
-	r, e = ps.GetProduct(2)
+	r, e = ps.GetProduct(ctx, 2, nil)
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 3).Return(nil, nil).Times(1)
+	r, e = ps.GetProduct(ctx, 3, nil)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 3}, e)
+	assert.Nil(t, r)
 }
 
 func TestProductService_GetProducts(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().GetProducts(nil, nil).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return(nil, errors.New("test")).Times(1)
 	ps := service.NewProductService(mockStore)
-	r, e := ps.GetProducts(nil)
+	r, e := ps.GetProducts(ctx, nil, nil)
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
-	mockStore.EXPECT().GetProducts(nil, nil).Return([]*model.Product{}, nil).Times(1)
-	r, e = ps.GetProducts(nil)
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return([]*model.Product{}, nil).Times(1)
+	r, e = ps.GetProducts(ctx, nil, nil)
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return([]*model.Product{{Id: 1, Price: 10}}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	r, e = ps.GetProducts(ctx, nil, nil)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+}
+
+func TestProductService_EffectivePrice(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	products := []*model.Product{
+		{Id: 1, Category: 1, Price: 100},
+		{Id: 2, Category: 2, Price: 100, Tags: []string{"sale"}},
+		{Id: 3, Category: 3, Price: 100},
+		{Id: 4, Category: 4, Price: 10},
+	}
+	promotions := []*model.Promotion{
+		{Id: 10, Type: model.PromotionTypePercentage, Value: 10, Categories: []int{1, 2}, Priority: 3, Stackable: true},
+		{Id: 11, Type: model.PromotionTypeFixed, Value: 5, Tags: []string{"sale"}, Priority: 2, Stackable: true},
+		{Id: 12, Type: model.PromotionTypeFixed, Value: 50, Products: []int{2, 3}, Priority: 1},
+		{Id: 13, Type: model.PromotionTypeFixed, Value: 15, Categories: []int{4}},
+	}
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return(products, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(promotions, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetProducts(ctx, nil, nil)
+	assert.Nil(t, e)
+	// 10% off
+	assert.Equal(t, 90.0, r[0].EffectivePrice)
+	assert.Equal(t, []int{10}, r[0].AppliedPromotions)
+	// 10% off stacked with 5 off, the exclusive promotion is skipped
+	assert.Equal(t, 85.0, r[1].EffectivePrice)
+	assert.Equal(t, []int{10, 11}, r[1].AppliedPromotions)
+	// exclusive promotion alone
+	assert.Equal(t, 50.0, r[2].EffectivePrice)
+	assert.Equal(t, []int{12}, r[2].AppliedPromotions)
+	// never below zero
+	assert.Equal(t, 0.0, r[3].EffectivePrice)
+	assert.Equal(t, []int{13}, r[3].AppliedPromotions)
 }
 
 func TestProductService_CreateProduct(t *testing.T) {
@@ -47,32 +94,79 @@ func TestProductService_CreateProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	ps := service.NewProductService(mockStore)
-	r, e := ps.CreateProduct(&model.Product{Name: "test"})
+	r, e := ps.CreateProduct(ctx, &model.Product{Name: "test"})
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().CreateProduct(tx, &model.Product{Name: "test"}).Return(nil, errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "test").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, &model.Product{Name: "test", Slug: "test", Status: model.ProductStatusDraft, Type: model.ProductTypeSimple}).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	r, e = ps.CreateProduct(&model.Product{Name: "test"})
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "test"})
 	assert.NotNil(t, e)
 	assert.Nil(t, r)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
 	var id = 1
-	mockStore.EXPECT().CreateProduct(tx, &model.Product{Name: "test"}).Return(&id, nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "test").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, &model.Product{Name: "test", Slug: "test", Status: model.ProductStatusDraft, Type: model.ProductTypeSimple}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "test"})
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "test").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, &model.Product{Name: "test", Slug: "test", Status: model.ProductStatusPublished, Type: model.ProductTypeSimple}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	r, e = ps.CreateProduct(&model.Product{Name: "test"})
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "test", Status: model.ProductStatusPublished})
 	assert.Nil(t, e)
 	assert.NotNil(t, r)
+
+	// published later, by the scheduler
+	later, earlier := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	for publishAt, status := range map[*time.Time]string{&later: model.ProductStatusDraft, &earlier: model.ProductStatusPublished} {
+		mockStore = mock.NewMockStore(mockCtrl)
+		mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+		mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "test").Return(nil, nil).Times(1)
+		mockStore.EXPECT().CreateProduct(gomock.Any(), tx, &model.Product{Name: "test", Slug: "test", Status: status, PublishAt: publishAt, Type: model.ProductTypeSimple}).Return(&id, nil).Times(1)
+		mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+		ps = service.NewProductService(mockStore)
+		_, e = ps.CreateProduct(ctx, &model.Product{Name: "test", Status: model.ProductStatusPublished, PublishAt: publishAt})
+		assert.Nil(t, e)
+	}
+	// taxed by a missing tax class
+	taxClass := 9
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), tx, 9).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "test", TaxClass: &taxClass})
+	assert.Equal(t, service.ErrUnknownTaxClass, e)
+	assert.Nil(t, r)
+	// made by a missing brand
+	brand := 4
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), tx, 9).Return(&model.TaxClass{Id: 9, Name: "standard"}, nil).Times(1)
+	mockStore.EXPECT().GetBrand(gomock.Any(), tx, 4).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "test", TaxClass: &taxClass, Brand: &brand})
+	assert.Equal(t, service.ErrUnknownBrand, e)
+	assert.Nil(t, r)
 }
 
 func TestProductService_UpdateProduct(t *testing.T) {
@@ -80,30 +174,46 @@ func TestProductService_UpdateProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	ps := service.NewProductService(mockStore)
-	e := ps.UpdateProduct(nil)
+	e := ps.UpdateProduct(ctx, nil)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
 	prod := &model.Product{Id: 1, Name: "test"}
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().UpdateProduct(tx, prod).Return(errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, prod).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	e = ps.UpdateProduct(prod)
+	e = ps.UpdateProduct(ctx, prod)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
 	prod = &model.Product{Id: 1, Name: "test"}
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().UpdateProduct(tx, prod).Return(nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, prod).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	e = ps.UpdateProduct(prod)
+	e = ps.UpdateProduct(ctx, prod)
 	assert.Nil(t, e)
+	taxClass := 2
+	mockStore = mock.NewMockStore(mockCtrl)
+	prod = &model.Product{Id: 1, Name: "test", TaxClass: &taxClass}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), tx, 2).Return(&model.TaxClass{Id: 2, Name: "reduced"}, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, prod).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Nil(t, ps.UpdateProduct(ctx, prod))
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), tx, 2).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Equal(t, service.ErrUnknownTaxClass, ps.UpdateProduct(ctx, prod))
 }
 
 func TestProductService_DeleteProduct(t *testing.T) {
@@ -111,26 +221,283 @@ func TestProductService_DeleteProduct(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mock.NewMockStore(mockCtrl)
-	mockStore.EXPECT().Begin().Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
 	ps := service.NewProductService(mockStore)
-	e := ps.DeleteProduct(1)
+	e := ps.DeleteProduct(ctx, 1)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx := new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().DeleteProduct(tx, 1).Return(errors.New("test")).Times(1)
-	mockStore.EXPECT().Rollback(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetBundlesContaining(gomock.Any(), tx, 1).Return([]int{5}, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	e = ps.DeleteProduct(1)
+	e = ps.DeleteProduct(ctx, 1)
+	assert.Equal(t, service.ErrProductInBundle, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetBundlesContaining(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().DeleteProduct(gomock.Any(), tx, 1).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	e = ps.DeleteProduct(ctx, 1)
 	assert.NotNil(t, e)
 
 	mockStore = mock.NewMockStore(mockCtrl)
 	tx = new(sql.Tx)
-	mockStore.EXPECT().Begin().Return(tx, nil).Times(1)
-	mockStore.EXPECT().DeleteProduct(tx, 1).Return(nil).Times(1)
-	mockStore.EXPECT().Commit(tx).Return(nil).Times(1)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetBundlesContaining(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().DeleteProduct(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
 	ps = service.NewProductService(mockStore)
-	e = ps.DeleteProduct(1)
+	e = ps.DeleteProduct(ctx, 1)
+	assert.Nil(t, e)
+}
+
+func TestProductService_TaxPrices(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	standard, reduced := 1, 2
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).AnyTimes()
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return([]*model.Product{
+		{Id: 1, Category: 1, Price: 19.99, TaxClass: &standard},
+		{Id: 2, Category: 1, Price: 10.05},
+		{Id: 3, Category: 2, Price: 5},
+	}, nil).Times(1)
+	mockStore.EXPECT().GetTaxRates(gomock.Any(), nil, "DE").Return(map[int]float64{standard: 19, reduced: 7}, nil).Times(1)
+	mockStore.EXPECT().GetCategories(gomock.Any(), nil).Return([]*model.Category{{Id: 1, TaxClass: &reduced}, {Id: 2}}, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetProducts(ctx, nil, &model.PriceView{Region: "DE", Prices: model.PricesGross})
 	assert.Nil(t, e)
+	// own tax class
+	assert.Equal(t, &model.TaxBreakdown{Region: "DE", Rate: 19, NetPrice: 19.99, TaxAmount: 3.8, GrossPrice: 23.79}, r[0].Tax)
+	assert.Equal(t, 23.79, r[0].Price)
+	assert.Equal(t, 23.79, r[0].EffectivePrice)
+	// inherited from the category, 0.7035 rounds to cents
+	assert.Equal(t, &model.TaxBreakdown{Region: "DE", Rate: 7, NetPrice: 10.05, TaxAmount: 0.7, GrossPrice: 10.75}, r[1].Tax)
+	// no tax class at all
+	assert.Equal(t, &model.TaxBreakdown{Region: "DE", Rate: 0, NetPrice: 5, TaxAmount: 0, GrossPrice: 5}, r[2].Tax)
+
+	// net prices keep the stored values
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(&model.Product{Id: 1, Category: 1, Price: 19.99, TaxClass: &standard}, nil).Times(1)
+	mockStore.EXPECT().GetTaxRates(gomock.Any(), nil, "DE").Return(map[int]float64{standard: 19}, nil).Times(1)
+	mockStore.EXPECT().GetCategories(gomock.Any(), nil).Return(nil, nil).Times(1)
+	p, e := ps.GetProduct(ctx, 1, &model.PriceView{Region: "DE", Prices: model.PricesNet})
+	assert.Nil(t, e)
+	assert.Equal(t, 19.99, p.Price)
+	assert.Equal(t, 23.79, p.Tax.GrossPrice)
+
+	// unknown region
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(&model.Product{Id: 1, Price: 1}, nil).Times(1)
+	mockStore.EXPECT().GetTaxRates(gomock.Any(), nil, "XX").Return(nil, nil).Times(1)
+	p, e = ps.GetProduct(ctx, 1, &model.PriceView{Region: "XX"})
+	assert.Equal(t, service.ErrUnknownRegion, e)
+	assert.Nil(t, p)
+
+	// the tax class has no rate in the region, rather than a 0% one
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(&model.Product{Id: 1, Category: 1, Price: 1, TaxClass: &reduced}, nil).Times(1)
+	mockStore.EXPECT().GetTaxRates(gomock.Any(), nil, "DE").Return(map[int]float64{standard: 19}, nil).Times(1)
+	mockStore.EXPECT().GetCategories(gomock.Any(), nil).Return(nil, nil).Times(1)
+	p, e = ps.GetProduct(ctx, 1, &model.PriceView{Region: "DE"})
+	var preconditionFailed *service.PreconditionFailedError
+	assert.ErrorAs(t, e, &preconditionFailed)
+	assert.Nil(t, p)
+}
+
+func TestProductService_CreateBundle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// unknown component
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	bundle := &model.Product{Name: "kit", Type: model.ProductTypeBundle, Components: []model.BundleComponent{{Product: 1, Quantity: 1}}}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.CreateProduct(ctx, bundle)
+	assert.Equal(t, service.ErrUnknownComponent, e)
+	assert.Nil(t, r)
+
+	// ok
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	id := 3
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "kit").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, bundle).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	r, e = ps.CreateProduct(ctx, bundle)
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+}
+
+func TestProductService_UpdateBundleCycle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// directly
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	e := ps.UpdateProduct(ctx, &model.Product{Id: 1, Type: model.ProductTypeBundle, Components: []model.BundleComponent{{Product: 1, Quantity: 1}}})
+	assert.Equal(t, service.ErrBundleCycle, e)
+
+	// through another bundle
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 2).Return(&model.Product{Id: 2, Type: model.ProductTypeBundle, Components: []model.BundleComponent{{Product: 3, Quantity: 1}}}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 3).Return(&model.Product{Id: 3, Type: model.ProductTypeBundle, Components: []model.BundleComponent{{Product: 1, Quantity: 1}}}, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	e = ps.UpdateProduct(ctx, &model.Product{Id: 1, Type: model.ProductTypeBundle, Components: []model.BundleComponent{{Product: 2, Quantity: 1}}})
+	assert.Equal(t, service.ErrBundleCycle, e)
+}
+
+func TestProductService_BundleStockAndPrice(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	camera := &model.Product{Id: 1, Price: 500, Stock: 10}
+	bundle := &model.Product{Id: 10, Type: model.ProductTypeBundle, BundlePricing: model.BundlePricingDerived, BundleDiscount: 10,
+		Components: []model.BundleComponent{{Product: 1, Quantity: 1}, {Product: 2, Quantity: 2}, {Product: 3, Quantity: 1}}}
+	fixed := &model.Product{Id: 11, Type: model.ProductTypeBundle, BundlePricing: model.BundlePricingFixed, Price: 99, Stock: 1000,
+		Components: []model.BundleComponent{{Product: 10, Quantity: 1}}}
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, nil).Return([]*model.Product{camera, bundle, fixed}, nil).Times(1)
+	// lens and bag are loaded once, even though two bundles need them
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Price: 200, Stock: 7}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 3).Return(&model.Product{Id: 3, Price: 50, Stock: 20}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetProducts(ctx, nil, nil)
+	assert.Nil(t, e)
+	// 7 lenses make 3 bundles of two
+	assert.Equal(t, 3, r[1].Stock)
+	assert.Equal(t, 855.0, r[1].Price)
+	assert.Equal(t, 855.0, r[1].EffectivePrice)
+	// nested bundle, fixed price
+	assert.Equal(t, 3, r[2].Stock)
+	assert.Equal(t, 99.0, r[2].Price)
+}
+
+func TestProductService_ExportBundlePrice(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().ExportProducts(gomock.Any(), nil, nil, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *sql.Tx, _ *model.ProductFilter, fn func(*model.ExportedProduct) error) error {
+			for _, p := range []*model.ExportedProduct{
+				{Id: 1, Price: 500},
+				{Id: 10, Price: 1, BundlePricing: model.BundlePricingDerived},
+				{Id: 11, Price: 99, BundlePricing: model.BundlePricingFixed},
+			} {
+				if err := fn(p); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Times(1)
+	// only the derived bundle and its components are loaded
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 10).Return(&model.Product{Id: 10, Type: model.ProductTypeBundle,
+		BundlePricing: model.BundlePricingDerived, BundleDiscount: 10, Price: 1,
+		Components: []model.BundleComponent{{Product: 1, Quantity: 1}, {Product: 2, Quantity: 2}}}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(&model.Product{Id: 1, Price: 500}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Price: 200}, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	var prices []float64
+	e := ps.ExportProducts(ctx, nil, func(p *model.ExportedProduct) error {
+		prices = append(prices, p.Price)
+		return nil
+	})
+	assert.Nil(t, e)
+	assert.Equal(t, []float64{500, 810, 99}, prices)
+}
+
+func TestProductService_GetRelatedProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(nil, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetRelatedProducts(ctx, 1, "", nil, nil, nil)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, e)
+	assert.Nil(t, r)
+
+	// drafts are hidden as if missing
+	published := []string{model.ProductStatusPublished}
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 6).Return(&model.Product{Id: 6, Status: model.ProductStatusDraft}, nil).Times(1)
+	r, e = ps.GetRelatedProducts(ctx, 6, "", &model.ProductFilter{Statuses: published}, nil, published)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 6}, e)
+	assert.Nil(t, r)
+
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Status: model.ProductStatusPublished}, nil).Times(1)
+	mockStore.EXPECT().GetProductRelations(gomock.Any(), nil, 2, "").Return([]model.ProductRelation{
+		{Type: model.RelationAccessoryOf, Product: 3},
+		{Type: model.RelationReplacedBy, Product: 4},
+		{Type: model.RelationUpSell, Product: 5},
+	}, nil).Times(1)
+	// the draft 5 is filtered out by the store
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, &model.ProductFilter{Ids: []int{3, 4, 5}, Statuses: published}).
+		Return([]*model.Product{{Id: 4, Price: 2}, {Id: 3, Price: 1}}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).Times(1)
+	r, e = ps.GetRelatedProducts(ctx, 2, "", &model.ProductFilter{Statuses: published}, nil, published)
+	assert.Nil(t, e)
+	assert.Len(t, r, 2)
+	assert.Equal(t, model.RelationAccessoryOf, r[0].Type)
+	assert.Equal(t, 3, r[0].Product.Id)
+	assert.Equal(t, 1.0, r[0].Product.EffectivePrice)
+	assert.Equal(t, model.RelationReplacedBy, r[1].Type)
+	assert.Equal(t, 4, r[1].Product.Id)
+}
+
+func TestProductService_ChangeProductRelations(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	relations := []model.ProductRelation{{Type: model.RelationAccessoryOf, Product: 2}}
+
+	// unknown product
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, ps.AddProductRelations(ctx, 1, relations))
+
+	// self relation
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 2).Return(&model.Product{Id: 2}, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Equal(t, service.ErrSelfRelation, ps.AddProductRelations(ctx, 2, relations))
+
+	// unknown related product
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 2).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Equal(t, service.ErrUnknownRelated, ps.RemoveProductRelations(ctx, 1, relations))
+
+	// replace
+	mockStore = mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 2).Return(&model.Product{Id: 2}, nil).Times(1)
+	mockStore.EXPECT().ClearProductRelations(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().AddProductRelations(gomock.Any(), tx, 1, relations).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Nil(t, ps.ReplaceProductRelations(ctx, 1, relations))
 }
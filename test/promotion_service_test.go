@@ -0,0 +1,137 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromotionService_GetPromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetPromotion(gomock.Any(), nil, 1).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetPromotion(gomock.Any(), nil, 2).Return(&model.Promotion{Id: 2, Name: "test"}, nil).Times(1)
+	prs := service.NewPromotionService(mockStore)
+	r, e := prs.GetPromotion(ctx, 1)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	r, e = prs.GetPromotion(ctx, 2)
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+}
+
+func TestPromotionService_GetPromotions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetPromotions(gomock.Any(), nil).Return(nil, errors.New("test")).Times(1)
+	prs := service.NewPromotionService(mockStore)
+	r, e := prs.GetPromotions(ctx)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	mockStore.EXPECT().GetPromotions(gomock.Any(), nil).Return([]*model.Promotion{}, nil).Times(1)
+	r, e = prs.GetPromotions(ctx)
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+}
+
+func TestPromotionService_CreatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	prs := service.NewPromotionService(mockStore)
+	r, e := prs.CreatePromotion(ctx, &model.Promotion{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreatePromotion(gomock.Any(), tx, &model.Promotion{Name: "Test"}).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	r, e = prs.CreatePromotion(ctx, &model.Promotion{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	var id = 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreatePromotion(gomock.Any(), tx, &model.Promotion{Name: "Test"}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	r, e = prs.CreatePromotion(ctx, &model.Promotion{Name: "Test"})
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+}
+
+func TestPromotionService_UpdatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	prs := service.NewPromotionService(mockStore)
+	e := prs.UpdatePromotion(ctx, nil)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	promotion := &model.Promotion{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdatePromotion(gomock.Any(), tx, promotion).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	e = prs.UpdatePromotion(ctx, promotion)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	promotion = &model.Promotion{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdatePromotion(gomock.Any(), tx, promotion).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	e = prs.UpdatePromotion(ctx, promotion)
+	assert.Nil(t, e)
+}
+
+func TestPromotionService_DeletePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	prs := service.NewPromotionService(mockStore)
+	e := prs.DeletePromotion(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeletePromotion(gomock.Any(), tx, 1).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	e = prs.DeletePromotion(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeletePromotion(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	prs = service.NewPromotionService(mockStore)
+	e = prs.DeletePromotion(ctx, 1)
+	assert.Nil(t, e)
+}
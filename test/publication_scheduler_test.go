@@ -0,0 +1,57 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicationScheduler_RunOnce(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	now := time.Now()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ApplyPublicationSchedule(gomock.Any(), tx, now).Return(int64(0), int64(0), errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	s := service.NewPublicationScheduler(mockStore, time.Minute)
+	assert.NotNil(t, s.RunOnce(now))
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ApplyPublicationSchedule(gomock.Any(), tx, now).Return(int64(2), int64(1), nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	s = service.NewPublicationScheduler(mockStore, time.Minute)
+	assert.Nil(t, s.RunOnce(now))
+}
+
+func TestPublicationScheduler_StartStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).MinTimes(1)
+	mockStore.EXPECT().ApplyPublicationSchedule(gomock.Any(), tx, gomock.Any()).Return(int64(0), int64(0), nil).MinTimes(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).MinTimes(1)
+	s := service.NewPublicationScheduler(mockStore, time.Hour)
+	s.Start()
+	s.Stop()
+}
+
+func TestPublicationScheduler_Disabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	// no run at all without an interval
+	s := service.NewPublicationScheduler(mock.NewMockStore(mockCtrl), 0)
+	s.Start()
+	s.Stop()
+}
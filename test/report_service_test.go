@@ -0,0 +1,57 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_GetMarginReport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	filter := &model.ProductFilter{}
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, filter).Return(nil, errors.New("test")).Times(1)
+	rs := service.NewReportService(mockStore)
+	r, e := rs.GetMarginReport(ctx, filter)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	cost := func(v float64) *float64 { return &v }
+	percent := func(v float64) *float64 { return &v }
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, filter).Return([]*model.Product{
+		{Id: 1, Name: "tv", Category: 2, Price: 100, CostPrice: cost(75)},
+		{Id: 2, Name: "radio", Category: 2, Price: 50, CostPrice: cost(40)},
+		{Id: 3, Name: "cable", Category: 1, Price: 0, CostPrice: cost(0)},
+		{Id: 4, Name: "unsupplied", Category: 1, Price: 10},
+	}, nil).Times(1)
+	r, e = rs.GetMarginReport(ctx, filter)
+	assert.Nil(t, e)
+	assert.Equal(t, []*model.ProductMargin{
+		{Product: 1, Name: "tv", Category: 2, Price: 100, Cost: 75, Margin: 25, MarginPercent: percent(25), MarkupPercent: percent(33.33)},
+		{Product: 2, Name: "radio", Category: 2, Price: 50, Cost: 40, Margin: 10, MarginPercent: percent(20), MarkupPercent: percent(25)},
+		{Product: 3, Name: "cable", Category: 1},
+	}, r.Products)
+	assert.Equal(t, []*model.CategoryMargin{
+		{Category: 1, Products: 1},
+		{Category: 2, Products: 2, Price: 150, Cost: 115, Margin: 35, MarginPercent: percent(23.33), MarkupPercent: percent(30.43)},
+	}, r.Categories)
+	// Derived bundle prices, as listed
+	filter = &model.ProductFilter{Statuses: []string{model.ProductStatusPublished}}
+	mockStore.EXPECT().GetProducts(gomock.Any(), nil, filter).Return([]*model.Product{
+		{Id: 5, Name: "home cinema", Category: 2, Type: model.ProductTypeBundle, BundlePricing: model.BundlePricingDerived, BundleDiscount: 10,
+			Components: []model.BundleComponent{{Product: 1, Quantity: 1}, {Product: 2, Quantity: 2}}, CostPrice: cost(150)},
+	}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(&model.Product{Id: 1, Price: 100}, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Price: 50}, nil).Times(1)
+	r, e = rs.GetMarginReport(ctx, filter)
+	assert.Nil(t, e)
+	assert.Equal(t, []*model.ProductMargin{
+		{Product: 5, Name: "home cinema", Category: 2, Price: 180, Cost: 150, Margin: 30, MarginPercent: percent(16.67), MarkupPercent: percent(20)},
+	}, r.Products)
+}
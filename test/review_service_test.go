@@ -0,0 +1,106 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewService_GetReviews(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(nil, nil).Times(1)
+	rs := service.NewReviewService(mockStore)
+	r, e := rs.GetReviews(ctx, 1, model.ReviewStatusApproved, nil)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, e)
+	assert.Nil(t, r)
+	published := []string{model.ProductStatusPublished}
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Status: model.ProductStatusPublished}, nil).Times(1)
+	mockStore.EXPECT().GetReviews(gomock.Any(), nil, 2, model.ReviewStatusApproved).Return([]*model.Review{{Id: 1}}, nil).Times(1)
+	r, e = rs.GetReviews(ctx, 2, model.ReviewStatusApproved, published)
+	assert.Nil(t, e)
+	assert.Len(t, r, 1)
+	// products of other statuses are hidden
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 3).Return(&model.Product{Id: 3, Status: model.ProductStatusDraft}, nil).Times(2)
+	r, e = rs.GetReviews(ctx, 3, model.ReviewStatusApproved, published)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 3}, e)
+	assert.Nil(t, r)
+	mockStore.EXPECT().GetReviews(gomock.Any(), nil, 3, model.ReviewStatusPending).Return(nil, nil).Times(1)
+	_, e = rs.GetReviews(ctx, 3, model.ReviewStatusPending, nil)
+	assert.Nil(t, e)
+}
+
+func TestReviewService_CreateReview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	rs := service.NewReviewService(mockStore)
+	r, e := rs.CreateReview(ctx, &model.Review{Product: 1})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	rs = service.NewReviewService(mockStore)
+	r, e = rs.CreateReview(ctx, &model.Review{Product: 1})
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, e)
+	assert.Nil(t, r)
+	// unpublished products can't be reviewed
+	for _, status := range []string{model.ProductStatusDraft, model.ProductStatusArchived} {
+		mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+		mockStore.EXPECT().GetProduct(gomock.Any(), tx, 2).Return(&model.Product{Id: 2, Status: status}, nil).Times(1)
+		mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+		r, e = rs.CreateReview(ctx, &model.Review{Product: 2})
+		assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 2}, e)
+		assert.Nil(t, r)
+	}
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	id := 7
+	review := &model.Review{Product: 1, Status: model.ReviewStatusApproved}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1, Status: model.ProductStatusPublished}, nil).Times(1)
+	mockStore.EXPECT().CreateReview(gomock.Any(), tx, review).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	rs = service.NewReviewService(mockStore)
+	r, e = rs.CreateReview(ctx, review)
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+	// new reviews always wait for moderation
+	assert.Equal(t, model.ReviewStatusPending, review.Status)
+	assert.False(t, review.CreatedAt.IsZero())
+}
+
+func TestReviewService_ModerateReview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().SetReviewStatus(gomock.Any(), tx, 1, model.ReviewStatusApproved).Return(sql.ErrNoRows).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	rs := service.NewReviewService(mockStore)
+	assert.Equal(t, &service.NotFoundError{Entity: "review", Field: "id", Value: 1}, rs.ModerateReview(ctx, 1, model.ReviewStatusApproved))
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().SetReviewStatus(gomock.Any(), tx, 2, model.ReviewStatusRejected).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	rs = service.NewReviewService(mockStore)
+	assert.Nil(t, rs.ModerateReview(ctx, 2, model.ReviewStatusRejected))
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "ultra-hd-tv-55", model.Slugify("  Ultra HD TV, 55\" "))
+	assert.Equal(t, "caf-cr-me", model.Slugify("Café crème"))
+	assert.Equal(t, "", model.Slugify("!!!"))
+	assert.Len(t, model.Slugify(strings.Repeat("ab ", 100)), model.SlugMaxLength)
+}
+
+func TestProductService_CreateProductSlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// generated slugs are numbered until free
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	id, other := 5, 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "smart-tv").Return(&other, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "smart-tv-2").Return(&other, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "smart-tv-3").Return(nil, nil).Times(1)
+	mockStore.EXPECT().CreateProduct(gomock.Any(), tx, gomock.Any()).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	product := &model.Product{Name: "Smart TV"}
+	r, e := ps.CreateProduct(ctx, product)
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+	assert.Equal(t, "smart-tv-3", product.Slug)
+
+	// given slugs must be free
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "tv").Return(&other, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	r, e = ps.CreateProduct(ctx, &model.Product{Name: "Smart TV", Slug: "tv"})
+	assert.Equal(t, &service.ConflictError{Entity: model.SlugEntityProduct, Field: "slug", Id: other}, e)
+	assert.Nil(t, r)
+}
+
+func TestProductService_UpdateProductSlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// a product may keep its own slug
+	mockStore := mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	id := 2
+	product := &model.Product{Id: 2, Name: "Smart TV", Slug: "tv"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "tv").Return(&id, nil).Times(1)
+	mockStore.EXPECT().UpdateProduct(gomock.Any(), tx, product).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	assert.Nil(t, ps.UpdateProduct(ctx, product))
+
+	// but not take the one of another
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	other := 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), tx, model.SlugEntityProduct, "tv").Return(&other, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ps = service.NewProductService(mockStore)
+	assert.Equal(t, &service.ConflictError{Entity: model.SlugEntityProduct, Field: "slug", Id: other}, ps.UpdateProduct(ctx, product))
+}
+
+func TestProductService_GetProductBySlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), nil, model.SlugEntityProduct, "none").Return(nil, nil).Times(1)
+	ps := service.NewProductService(mockStore)
+	r, e := ps.GetProductBySlug(ctx, "none", nil)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "slug", Value: "none"}, e)
+	assert.Nil(t, r)
+
+	id := 2
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), nil, model.SlugEntityProduct, "old-tv").Return(&id, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2, Slug: "tv"}, nil).Times(1)
+	mockStore.EXPECT().GetActivePromotions(gomock.Any(), nil, gomock.Any()).Return(nil, nil).Times(1)
+	r, e = ps.GetProductBySlug(ctx, "old-tv", nil)
+	assert.Nil(t, e)
+	assert.Equal(t, "tv", r.Slug)
+}
+
+func TestCategoryService_GetCategoryBySlug(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	id := 3
+	mockStore.EXPECT().ResolveSlug(gomock.Any(), nil, model.SlugEntityCategory, "tvs").Return(&id, nil).Times(1)
+	mockStore.EXPECT().GetCategory(gomock.Any(), nil, 3).Return(&model.Category{Id: 3, Slug: "tvs"}, nil).Times(1)
+	cs := service.NewCategoryService(mockStore)
+	r, e := cs.GetCategoryBySlug(ctx, "tvs")
+	assert.Nil(t, e)
+	assert.Equal(t, 3, r.Id)
+}
@@ -1,78 +1,91 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mrlightwood/golang-products-api/config"
 	"github.com/mrlightwood/golang-products-api/db"
+	"github.com/mrlightwood/golang-products-api/logging"
 	"github.com/mrlightwood/golang-products-api/model"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 var st db.Store
 
+// ctx is the context of the calls made outside of requests
+var ctx = context.Background()
+
 func init() {
 	conf, _ := config.NewConfig("../config/config_test.yaml")
 	st, _ = db.NewStore(conf)
 }
 
 func TestStore_CreateCategory(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	id, err := st.CreateCategory(tx, &model.Category{Name: "test"})
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
 	assert.NoError(t, err)
 	assert.NotNil(t, id)
 }
 
 func TestStore_GetCategory(t *testing.T) {
-	c, err := st.GetCategory(nil, -1)
+	c, err := st.GetCategory(ctx, nil, -1)
 	assert.Nil(t, err)
 	assert.Nil(t, c)
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	id, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	cat, _ := st.GetCategory(tx, *id)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	cat, _ := st.GetCategory(ctx, tx, *id)
 	assert.Equal(t, *id, cat.Id)
 }
 
 func TestStore_GetCategories(t *testing.T) {
-	_, err := st.GetCategories(nil)
+	_, err := st.GetCategories(ctx, nil)
 	assert.NoError(t, err)
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	st.CreateCategory(tx, &model.Category{Name: "test"})
-	res, _ := st.GetCategories(tx)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	res, _ := st.GetCategories(ctx, tx)
 	assert.NotEmpty(t, res)
 }
 
 func TestStore_UpdateCategory(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	id, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	cat, _ := st.GetCategory(tx, *id)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	cat, _ := st.GetCategory(ctx, tx, *id)
 	cat.Name = "test2"
-	st.UpdateCategory(tx, cat)
-	cat2, _ := st.GetCategory(tx, cat.Id)
+	st.UpdateCategory(ctx, tx, cat)
+	cat2, _ := st.GetCategory(ctx, tx, cat.Id)
 	assert.Equal(t, cat2.Name, "test2")
 }
 
 func TestStore_DeleteCategory(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	id, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	st.DeleteCategory(tx, *id)
-	cat2, _ := st.GetCategory(tx, *id)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	st.DeleteCategory(ctx, tx, *id)
+	cat2, _ := st.GetCategory(ctx, tx, *id)
 	assert.Nil(t, cat2)
 }
 
 func TestStore_CreateProduct(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	category, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	p, err := st.CreateProduct(tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	p, err := st.CreateProduct(ctx, tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
 	assert.NoError(t, err)
-	product, err := st.GetProduct(tx, *p)
+	product, err := st.GetProduct(ctx, tx, *p)
 	assert.NoError(t, err)
 	assert.NotNil(t, product)
 	assert.Equal(t, product.Name, "test_name")
@@ -82,58 +95,784 @@ func TestStore_CreateProduct(t *testing.T) {
 }
 
 func TestStore_GetProduct(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	category, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	p, _ := st.CreateProduct(tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
-	ps, err := st.GetProduct(tx, *p)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	p, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
+	ps, err := st.GetProduct(ctx, tx, *p)
 	assert.Nil(t, err)
 	assert.NotNil(t, ps)
-	ps, err = st.GetProduct(tx, -1)
+	ps, err = st.GetProduct(ctx, tx, -1)
 	assert.Nil(t, err)
 	assert.Nil(t, ps)
 }
 
 func TestStore_GetProducts(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	category, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	st.CreateProduct(tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
-	st.CreateProduct(tx, &model.Product{Name: "test_name2", Description: "test_description2", Category: *category, Price: 102.52})
-	ps, err := st.GetProducts(tx, nil)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "test_name2", Description: "test_description2", Category: *category, Price: 102.52})
+	ps, err := st.GetProducts(ctx, tx, nil)
 	assert.Nil(t, err)
 	assert.NotEmpty(t, ps)
-	ps, _ = st.GetProducts(tx, category)
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category})
+	assert.Len(t, ps, 2)
+}
+
+func TestStore_GetProductsByStatus(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "draft", Category: *category, Price: 1, Status: model.ProductStatusDraft})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "published", Category: *category, Price: 1, Status: model.ProductStatusPublished})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "archived", Category: *category, Price: 1, Status: model.ProductStatusArchived})
+	ps, err := st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Statuses: []string{model.ProductStatusPublished}})
+	assert.Nil(t, err)
+	assert.Len(t, ps, 1)
+	assert.Equal(t, ps[0].Name, "published")
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Statuses: []string{model.ProductStatusPublished, model.ProductStatusDraft}})
 	assert.Len(t, ps, 2)
 }
 
 func TestStore_UpdateProduct(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	category, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	category2, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	id, _ := st.CreateProduct(tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5})
-	p, _ := st.GetProduct(tx, *id)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	category2, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test2"})
+	id, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "test_name", Description: "test_description", Category: *category, Price: 102.5, Status: model.ProductStatusPublished})
+	p, _ := st.GetProduct(ctx, tx, *id)
 	p.Name = "test_name2"
 	p.Description = "test_description2"
 	p.Category = *category2
 	p.Price = 102.7
-	err := st.UpdateProduct(tx, p)
+	p.Status = ""
+	err := st.UpdateProduct(ctx, tx, p)
 	assert.Nil(t, err)
-	p2, _ := st.GetProduct(tx, p.Id)
+	p2, _ := st.GetProduct(ctx, tx, p.Id)
 	assert.Equal(t, p2.Name, "test_name2")
 	assert.Equal(t, p2.Description, "test_description2")
 	assert.Equal(t, p2.Category, *category2)
 	assert.Equal(t, p2.Price, 102.7)
+	assert.Equal(t, p2.Status, model.ProductStatusPublished)
 }
 
 func TestStore_DeleteProduct(t *testing.T) {
-	tx, _ := st.Begin()
-	defer st.Rollback(tx)
-	id, _ := st.CreateCategory(tx, &model.Category{Name: "test"})
-	product, _ := st.CreateProduct(tx, &model.Product{Name: "test_name", Description: "test_description", Category: *id, Price: 102.5})
-	err := st.DeleteProduct(tx, *product)
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	product, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "test_name", Description: "test_description", Category: *id, Price: 102.5})
+	promotion, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "test", Type: model.PromotionTypeFixed, Value: 1,
+		Products: []int{*product}, Categories: []int{*id}})
+	err := st.DeleteProduct(ctx, tx, *product)
+	assert.Nil(t, err)
+	p, _ := st.GetProduct(ctx, tx, *product)
+	assert.Nil(t, p)
+	// promotions don't target the product anymore, nor a later one of the same id
+	assert.Nil(t, st.DeleteCategory(ctx, tx, *id))
+	pr, _ := st.GetPromotion(ctx, tx, *promotion)
+	assert.Empty(t, pr.Products)
+	assert.Empty(t, pr.Categories)
+}
+
+func TestStore_ApplyPublicationSchedule(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test"})
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	due, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "due", Category: *category, Price: 1, Status: model.ProductStatusDraft, PublishAt: &past})
+	later, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "later", Category: *category, Price: 1, Status: model.ProductStatusDraft, PublishAt: &future})
+	expired, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "expired", Category: *category, Price: 1, Status: model.ProductStatusPublished, UnpublishAt: &past})
+	published, archived, err := st.ApplyPublicationSchedule(ctx, tx, now)
 	assert.Nil(t, err)
-	p, _ := st.GetProduct(tx, *product)
+	assert.GreaterOrEqual(t, published, int64(1))
+	assert.GreaterOrEqual(t, archived, int64(1))
+	p, _ := st.GetProduct(ctx, tx, *due)
+	assert.Equal(t, p.Status, model.ProductStatusPublished)
+	assert.Nil(t, p.PublishAt)
+	p, _ = st.GetProduct(ctx, tx, *later)
+	assert.Equal(t, p.Status, model.ProductStatusDraft)
+	assert.WithinDuration(t, future, *p.PublishAt, time.Second)
+	p, _ = st.GetProduct(ctx, tx, *expired)
+	assert.Equal(t, p.Status, model.ProductStatusArchived)
+}
+
+func TestStore_ProductTags(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "tagged", Price: 1, Tags: []string{"sale", "new"}})
+	p, _ := st.GetProduct(ctx, tx, *id)
+	assert.ElementsMatch(t, p.Tags, []string{"sale", "new"})
+	p.Tags = []string{"clearance"}
+	st.UpdateProduct(ctx, tx, p)
+	p, _ = st.GetProduct(ctx, tx, *id)
+	assert.Equal(t, p.Tags, []string{"clearance"})
+}
+
+func TestStore_CreatePromotion(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "test", Type: model.PromotionTypePercentage, Value: 10,
+		Products: []int{1, 2}, Categories: []int{3}, Tags: []string{"sale"}, Priority: 5, Stackable: true})
+	assert.NoError(t, err)
+	p, err := st.GetPromotion(ctx, tx, *id)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Name, "test")
+	assert.Equal(t, p.Products, []int{1, 2})
+	assert.Equal(t, p.Categories, []int{3})
+	assert.Equal(t, p.Tags, []string{"sale"})
+	assert.Equal(t, p.Priority, 5)
+	assert.True(t, p.Stackable)
+	other, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "other", Type: model.PromotionTypeFixed, Value: 1, Products: []int{4}})
+	promotions, err := st.GetPromotions(ctx, tx)
+	assert.NoError(t, err)
+	for _, promotion := range promotions {
+		switch promotion.Id {
+		case *id:
+			assert.Equal(t, []int{1, 2}, promotion.Products)
+		case *other:
+			assert.Equal(t, []int{4}, promotion.Products)
+			assert.Empty(t, promotion.Tags)
+		}
+	}
+	p, err = st.GetPromotion(ctx, tx, -1)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+}
+
+func TestStore_UpdatePromotion(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "test", Type: model.PromotionTypeFixed, Value: 10, Products: []int{1}})
+	p, _ := st.GetPromotion(ctx, tx, *id)
+	p.Value = 20
+	p.Products = nil
+	p.Tags = []string{"new"}
+	assert.Nil(t, st.UpdatePromotion(ctx, tx, p))
+	p, _ = st.GetPromotion(ctx, tx, *id)
+	assert.Equal(t, p.Value, 20.0)
+	assert.Empty(t, p.Products)
+	assert.Equal(t, p.Tags, []string{"new"})
+	assert.Equal(t, st.UpdatePromotion(ctx, tx, &model.Promotion{Id: -1}), sql.ErrNoRows)
+}
+
+func TestStore_DeletePromotion(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "test", Type: model.PromotionTypeFixed, Value: 10, Products: []int{1}})
+	assert.Nil(t, st.DeletePromotion(ctx, tx, *id))
+	p, _ := st.GetPromotion(ctx, tx, *id)
+	assert.Nil(t, p)
+	assert.Equal(t, st.DeletePromotion(ctx, tx, *id), sql.ErrNoRows)
+}
+
+func TestStore_GetActivePromotions(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	active, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "active", Type: model.PromotionTypeFixed, Value: 1, Tags: []string{"x"}, StartsAt: &past, EndsAt: &future, Priority: 1000})
+	expired, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "expired", Type: model.PromotionTypeFixed, Value: 1, Tags: []string{"x"}, EndsAt: &past})
+	upcoming, _ := st.CreatePromotion(ctx, tx, &model.Promotion{Name: "upcoming", Type: model.PromotionTypeFixed, Value: 1, Tags: []string{"x"}, StartsAt: &future})
+	ps, err := st.GetActivePromotions(ctx, tx, now)
+	assert.Nil(t, err)
+	ids := map[int]bool{}
+	for _, p := range ps {
+		ids[p.Id] = true
+	}
+	assert.True(t, ids[*active])
+	assert.False(t, ids[*expired])
+	assert.False(t, ids[*upcoming])
+	assert.Equal(t, ps[0].Id, *active)
+}
+
+func TestStore_TaxClasses(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreateTaxClass(ctx, tx, &model.TaxClass{Name: "standard", Rates: map[string]float64{"DE": 19, "FR": 20}})
+	assert.NoError(t, err)
+	tc, err := st.GetTaxClass(ctx, tx, *id)
+	assert.NoError(t, err)
+	assert.Equal(t, tc.Rates, map[string]float64{"DE": 19, "FR": 20})
+	tc.Rates = map[string]float64{"DE": 16}
+	assert.Nil(t, st.UpdateTaxClass(ctx, tx, tc))
+	rates, err := st.GetTaxRates(ctx, tx, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, rates[*id], 16.0)
+	rates, _ = st.GetTaxRates(ctx, tx, "FR")
+	_, ok := rates[*id]
+	assert.False(t, ok)
+	rates, _ = st.GetTaxRates(ctx, tx, "XX")
+	assert.Nil(t, rates)
+	tcs, _ := st.GetTaxClasses(ctx, tx)
+	assert.NotEmpty(t, tcs)
+	tc, _ = st.GetTaxClass(ctx, tx, -1)
+	assert.Nil(t, tc)
+	// names are unique regardless of case
+	_, err = st.CreateTaxClass(ctx, tx, &model.TaxClass{Name: "Standard"})
+	assert.Equal(t, &db.ConflictError{Entity: "tax_class", Field: "name", Id: *id}, err)
+	other, _ := st.CreateTaxClass(ctx, tx, &model.TaxClass{Name: "reduced", Rates: map[string]float64{"DE": 7}})
+	err = st.UpdateTaxClass(ctx, tx, &model.TaxClass{Id: *other, Name: "STANDARD"})
+	assert.Equal(t, &db.ConflictError{Entity: "tax_class", Field: "name", Id: *id}, err)
+	for _, tc := range tcs {
+		if tc.Id == *id {
+			assert.Equal(t, map[string]float64{"DE": 16}, tc.Rates)
+		}
+	}
+}
+
+func TestStore_DeleteTaxClass(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateTaxClass(ctx, tx, &model.TaxClass{Name: "reduced", Rates: map[string]float64{"DE": 7}})
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "test", TaxClass: id})
+	product, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "test", Category: *category, Price: 1, TaxClass: id})
+	c, _ := st.GetCategory(ctx, tx, *category)
+	assert.Equal(t, c.TaxClass, id)
+	assert.Nil(t, st.DeleteTaxClass(ctx, tx, *id))
+	c, _ = st.GetCategory(ctx, tx, *category)
+	assert.Nil(t, c.TaxClass)
+	p, _ := st.GetProduct(ctx, tx, *product)
+	assert.Nil(t, p.TaxClass)
+	assert.Equal(t, st.DeleteTaxClass(ctx, tx, *id), sql.ErrNoRows)
+}
+
+func TestStore_BundleComponents(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	camera, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "camera", Price: 500, Stock: 4, Type: model.ProductTypeSimple})
+	lens, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "lens", Price: 200, Stock: 2, Type: model.ProductTypeSimple})
+	components := []model.BundleComponent{{Product: *camera, Quantity: 1}, {Product: *lens, Quantity: 2}}
+	kit, err := st.CreateProduct(ctx, tx, &model.Product{Name: "kit", Type: model.ProductTypeBundle, BundlePricing: model.BundlePricingDerived,
+		BundleDiscount: 5, Components: components})
+	assert.NoError(t, err)
+	p, _ := st.GetProduct(ctx, tx, *kit)
+	assert.True(t, p.IsBundle())
+	assert.ElementsMatch(t, p.Components, components)
+	assert.Equal(t, p.BundlePricing, model.BundlePricingDerived)
+	assert.Equal(t, p.BundleDiscount, 5.0)
+	p, _ = st.GetProduct(ctx, tx, *camera)
+	assert.Nil(t, p.Components)
+	assert.Equal(t, p.Stock, 4)
+	bundles, err := st.GetBundlesContaining(ctx, tx, *lens)
+	assert.NoError(t, err)
+	assert.Equal(t, bundles, []int{*kit})
+	st.DeleteProduct(ctx, tx, *kit)
+	bundles, _ = st.GetBundlesContaining(ctx, tx, *lens)
+	assert.Empty(t, bundles)
+}
+
+func TestStore_ProductRelations(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	camera, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "camera", Price: 500})
+	lens, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "lens", Price: 200})
+	camera2, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "camera2", Price: 600})
+	err := st.AddProductRelations(ctx, tx, *camera, []model.ProductRelation{
+		{Type: model.RelationAccessoryOf, Product: *lens},
+		{Type: model.RelationReplacedBy, Product: *camera2},
+		{Type: model.RelationReplacedBy, Product: *camera2},
+	})
+	assert.NoError(t, err)
+	r, err := st.GetProductRelations(ctx, tx, *camera, "")
+	assert.NoError(t, err)
+	assert.Len(t, r, 2)
+	r, _ = st.GetProductRelations(ctx, tx, *camera, model.RelationReplacedBy)
+	assert.Equal(t, r, []model.ProductRelation{{Type: model.RelationReplacedBy, Product: *camera2}})
+	// the other direction isn't linked
+	r, _ = st.GetProductRelations(ctx, tx, *camera2, "")
+	assert.Empty(t, r)
+	assert.NoError(t, st.RemoveProductRelations(ctx, tx, *camera, []model.ProductRelation{{Type: model.RelationReplacedBy, Product: *camera2}}))
+	r, _ = st.GetProductRelations(ctx, tx, *camera, "")
+	assert.Equal(t, r, []model.ProductRelation{{Type: model.RelationAccessoryOf, Product: *lens}})
+	// deleting the related product cleans up the link
+	st.DeleteProduct(ctx, tx, *lens)
+	r, _ = st.GetProductRelations(ctx, tx, *camera, "")
+	assert.Empty(t, r)
+	st.AddProductRelations(ctx, tx, *camera, []model.ProductRelation{{Type: model.RelationUpSell, Product: *camera2}})
+	assert.NoError(t, st.ClearProductRelations(ctx, tx, *camera))
+	r, _ = st.GetProductRelations(ctx, tx, *camera, "")
+	assert.Empty(t, r)
+}
+
+func TestStore_GetProductsByIds(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id1, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "one", Price: 1})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "two", Price: 1})
+	id3, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "three", Price: 1})
+	ps, err := st.GetProducts(ctx, tx, &model.ProductFilter{Ids: []int{*id1, *id3}})
+	assert.NoError(t, err)
+	assert.Len(t, ps, 2)
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Ids: []int{}})
+	assert.Empty(t, ps)
+}
+
+func TestStore_Reviews(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	product, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "reviewed", Price: 1})
+	now := time.Now()
+	var ids []int
+	for _, rating := range []int{5, 4, 1} {
+		id, err := st.CreateReview(ctx, tx, &model.Review{Product: *product, Rating: rating, Title: "title", AuthorName: "author",
+			Status: model.ReviewStatusPending, CreatedAt: now})
+		assert.NoError(t, err)
+		ids = append(ids, *id)
+	}
+	p, _ := st.GetProduct(ctx, tx, *product)
+	assert.Nil(t, p.AverageRating)
+	assert.Equal(t, 0, p.ReviewCount)
+	assert.NoError(t, st.SetReviewStatus(ctx, tx, ids[0], model.ReviewStatusApproved))
+	assert.NoError(t, st.SetReviewStatus(ctx, tx, ids[1], model.ReviewStatusApproved))
+	assert.NoError(t, st.SetReviewStatus(ctx, tx, ids[2], model.ReviewStatusRejected))
+	assert.Equal(t, sql.ErrNoRows, st.SetReviewStatus(ctx, tx, -1, model.ReviewStatusApproved))
+	p, _ = st.GetProduct(ctx, tx, *product)
+	assert.Equal(t, 4.5, *p.AverageRating)
+	assert.Equal(t, 2, p.ReviewCount)
+	reviews, err := st.GetReviews(ctx, tx, *product, model.ReviewStatusApproved)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	reviews, _ = st.GetReviews(ctx, tx, *product, "")
+	assert.Len(t, reviews, 3)
+	assert.WithinDuration(t, now, reviews[0].CreatedAt, time.Second)
+	// reviews go away with the product
+	st.DeleteProduct(ctx, tx, *product)
+	reviews, _ = st.GetReviews(ctx, tx, *product, "")
+	assert.Empty(t, reviews)
+}
+
+func TestStore_GetProductsSorted(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "sorted"})
+	good, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "good", Category: *category, Price: 3})
+	bad, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "bad", Category: *category, Price: 1})
+	unrated, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "unrated", Category: *category, Price: 2})
+	for product, rating := range map[int]int{*good: 5, *bad: 2} {
+		id, _ := st.CreateReview(ctx, tx, &model.Review{Product: product, Rating: rating, Title: "t", AuthorName: "a",
+			Status: model.ReviewStatusPending, CreatedAt: time.Now()})
+		st.SetReviewStatus(ctx, tx, *id, model.ReviewStatusApproved)
+	}
+	ids := func(ps []*model.Product) []int {
+		var res []int
+		for _, p := range ps {
+			res = append(res, p.Id)
+		}
+		return res
+	}
+	ps, err := st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Sort: "-average_rating"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{*good, *bad, *unrated}, ids(ps))
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Sort: "average_rating"})
+	assert.Equal(t, []int{*bad, *good, *unrated}, ids(ps))
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Sort: "price"})
+	assert.Equal(t, []int{*bad, *unrated, *good}, ids(ps))
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Sort: "-review_count"})
+	assert.Equal(t, *unrated, ps[2].Id)
+}
+
+func TestStore_Brands(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreateBrand(ctx, tx, &model.Brand{Name: "Acme"})
+	assert.NoError(t, err)
+	brand, _ := st.GetBrand(ctx, tx, *id)
+	assert.Equal(t, "Acme", brand.Name)
+	assert.NoError(t, st.UpdateBrand(ctx, tx, &model.Brand{Id: *id, Name: "Acme Corp"}))
+	assert.Equal(t, sql.ErrNoRows, st.UpdateBrand(ctx, tx, &model.Brand{Id: -1, Name: "none"}))
+	brand, _ = st.GetBrand(ctx, tx, *id)
+	assert.Equal(t, "Acme Corp", brand.Name)
+	brands, _ := st.GetBrands(ctx, tx)
+	assert.NotEmpty(t, brands)
+	// names are unique regardless of case
+	_, err = st.CreateBrand(ctx, tx, &model.Brand{Name: "ACME CORP"})
+	assert.Equal(t, &db.ConflictError{Entity: "brand", Field: "name", Id: *id}, err)
+	other, _ := st.CreateBrand(ctx, tx, &model.Brand{Name: "Globex"})
+	err = st.UpdateBrand(ctx, tx, &model.Brand{Id: *other, Name: "acme corp"})
+	assert.Equal(t, &db.ConflictError{Entity: "brand", Field: "name", Id: *id}, err)
+	// products keep existing without their brand
+	product, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "branded", Price: 1, Brand: id})
+	p, _ := st.GetProduct(ctx, tx, *product)
+	assert.Equal(t, *id, *p.Brand)
+	assert.NoError(t, st.DeleteBrand(ctx, tx, *id))
+	assert.Equal(t, sql.ErrNoRows, st.DeleteBrand(ctx, tx, *id))
+	brand, err = st.GetBrand(ctx, tx, *id)
+	assert.NoError(t, err)
+	assert.Nil(t, brand)
+	p, _ = st.GetProduct(ctx, tx, *product)
+	assert.Nil(t, p.Brand)
+}
+
+func TestStore_GetProductsByBrand(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "branded"})
+	acme, _ := st.CreateBrand(ctx, tx, &model.Brand{Name: "Acme"})
+	zenith, _ := st.CreateBrand(ctx, tx, &model.Brand{Name: "Zenith"})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "anvil", Category: *category, Price: 1, Brand: acme})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "rocket", Category: *category, Price: 1, Brand: acme})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "radio", Category: *category, Price: 1, Brand: zenith})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "generic", Category: *category, Price: 1})
+	ps, err := st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, Brand: acme})
+	assert.NoError(t, err)
+	assert.Len(t, ps, 2)
+	facets, err := st.GetBrandFacets(ctx, tx, &model.ProductFilter{Category: category, Brand: acme})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.BrandFacet{{Brand: *acme, Name: "Acme", Count: 2}, {Brand: *zenith, Name: "Zenith", Count: 1}}, facets)
+}
+
+func TestStore_SlugHistory(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "Smart TV", Slug: "slug-test-tv", Price: 1})
+	owner, err := st.ResolveSlug(ctx, tx, model.SlugEntityProduct, "slug-test-tv")
+	assert.NoError(t, err)
+	assert.Equal(t, *id, *owner)
+	// renaming keeps the former slug
+	assert.NoError(t, st.UpdateProduct(ctx, tx, &model.Product{Id: *id, Name: "Smart TV", Slug: "slug-test-smart-tv", Price: 1}))
+	p, _ := st.GetProduct(ctx, tx, *id)
+	assert.Equal(t, "slug-test-smart-tv", p.Slug)
+	owner, _ = st.ResolveSlug(ctx, tx, model.SlugEntityProduct, "slug-test-tv")
+	assert.Equal(t, *id, *owner)
+	// an empty slug keeps the current one
+	assert.NoError(t, st.UpdateProduct(ctx, tx, &model.Product{Id: *id, Name: "Smart TV", Price: 1}))
+	p, _ = st.GetProduct(ctx, tx, *id)
+	assert.Equal(t, "slug-test-smart-tv", p.Slug)
+	// slugs are per entity
+	owner, _ = st.ResolveSlug(ctx, tx, model.SlugEntityCategory, "slug-test-tv")
+	assert.Nil(t, owner)
+	_, err = st.ResolveSlug(ctx, tx, "brand", "slug-test-tv")
+	assert.Error(t, err)
+	// current slugs are unique
+	_, err = st.CreateProduct(ctx, tx, &model.Product{Name: "Other TV", Slug: "slug-test-smart-tv", Price: 1})
+	assert.Error(t, err)
+	st.DeleteProduct(ctx, tx, *id)
+	owner, _ = st.ResolveSlug(ctx, tx, model.SlugEntityProduct, "slug-test-tv")
+	assert.Nil(t, owner)
+}
+
+func TestStore_CategorySlug(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "TVs", Slug: "slug-test-tvs"})
+	c, _ := st.GetCategory(ctx, tx, *id)
+	assert.Equal(t, "slug-test-tvs", c.Slug)
+	assert.NoError(t, st.UpdateCategory(ctx, tx, &model.Category{Id: *id, Name: "Televisions", Slug: "slug-test-televisions"}))
+	owner, _ := st.ResolveSlug(ctx, tx, model.SlugEntityCategory, "slug-test-tvs")
+	assert.Equal(t, *id, *owner)
+	// taking a former slug back
+	assert.NoError(t, st.UpdateCategory(ctx, tx, &model.Category{Id: *id, Name: "TVs", Slug: "slug-test-tvs"}))
+	c, _ = st.GetCategory(ctx, tx, *id)
+	assert.Equal(t, "slug-test-tvs", c.Slug)
+	owner, _ = st.ResolveSlug(ctx, tx, model.SlugEntityCategory, "slug-test-televisions")
+	assert.Equal(t, *id, *owner)
+}
+
+func TestStore_Barcode(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	// UPC-A 036000291452 is EAN-13 0036000291452
+	id, err := st.CreateProduct(ctx, tx, &model.Product{Name: "scanned", Price: 1, Barcode: "036000291452"})
+	assert.NoError(t, err)
+	p, err := st.GetProductByBarcode(ctx, tx, "0036000291452")
+	assert.NoError(t, err)
+	assert.Equal(t, *id, p.Id)
+	assert.Equal(t, "036000291452", p.Barcode)
+	p, _ = st.GetProductByBarcode(ctx, tx, "96385074")
 	assert.Nil(t, p)
+	_, err = st.CreateProduct(ctx, tx, &model.Product{Name: "duplicate", Price: 1, Barcode: "0036000291452"})
+	assert.Equal(t, &db.ConflictError{Entity: "product", Field: "barcode", Id: *id}, err)
+	// products without barcode don't conflict
+	other, err := st.CreateProduct(ctx, tx, &model.Product{Name: "plain", Price: 1})
+	assert.NoError(t, err)
+	_, err = st.CreateProduct(ctx, tx, &model.Product{Name: "plain2", Price: 1})
+	assert.NoError(t, err)
+	err = st.UpdateProduct(ctx, tx, &model.Product{Id: *other, Name: "plain", Price: 1, Barcode: "036000291452"})
+	assert.Equal(t, &db.ConflictError{Entity: "product", Field: "barcode", Id: *id}, err)
+}
+
+func TestStore_GetProductsByWeight(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "parcels"})
+	light, heavy, short := 0.5, 20.0, 0.3
+	id, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "light", Category: *category, Price: 1, Weight: &light, Height: &short})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "heavy", Category: *category, Price: 1, Weight: &heavy})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "unknown", Category: *category, Price: 1})
+	p, _ := st.GetProduct(ctx, tx, *id)
+	assert.Equal(t, light, *p.Weight)
+	assert.Equal(t, short, *p.Height)
+	assert.Nil(t, p.Length)
+	max := 1.0
+	ps, err := st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, WeightMax: &max})
+	assert.NoError(t, err)
+	assert.Len(t, ps, 1)
+	assert.Equal(t, *id, ps[0].Id)
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, WeightMax: &max, HeightMax: &light})
+	assert.Len(t, ps, 1)
+	ps, _ = st.GetProducts(ctx, tx, &model.ProductFilter{Category: category, HeightMax: &max, LengthMax: &max})
+	assert.Empty(t, ps)
+}
+
+func TestStore_Suppliers(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	acme, err := st.CreateSupplier(ctx, tx, &model.Supplier{Name: "Acme", Email: "sales@acme.test"})
+	assert.NoError(t, err)
+	globex, _ := st.CreateSupplier(ctx, tx, &model.Supplier{Name: "Globex"})
+	assert.NoError(t, st.UpdateSupplier(ctx, tx, &model.Supplier{Id: *globex, Name: "Globex Corp"}))
+	assert.Equal(t, sql.ErrNoRows, st.UpdateSupplier(ctx, tx, &model.Supplier{Id: -1, Name: "none"}))
+	s, _ := st.GetSupplier(ctx, tx, *globex)
+	assert.Equal(t, "Globex Corp", s.Name)
+	product, _ := st.CreateProduct(ctx, tx, &model.Product{Name: "supplied", Price: 10})
+	p, _ := st.GetProduct(ctx, tx, *product)
+	assert.Nil(t, p.CostPrice)
+	// the cheapest supplier gives the cost unless one is preferred
+	links := []model.ProductSupplier{{Supplier: *acme, SKU: "A-1", CostPrice: 6}, {Supplier: *globex, SKU: "G-1", CostPrice: 5}}
+	assert.NoError(t, st.SetProductSuppliers(ctx, tx, *product, links))
+	p, _ = st.GetProduct(ctx, tx, *product)
+	assert.Equal(t, 5.0, *p.CostPrice)
+	links[0].Preferred = true
+	assert.NoError(t, st.SetProductSuppliers(ctx, tx, *product, links))
+	p, _ = st.GetProduct(ctx, tx, *product)
+	assert.Equal(t, 6.0, *p.CostPrice)
+	res, err := st.GetProductSuppliers(ctx, tx, *product)
+	assert.NoError(t, err)
+	assert.Equal(t, links, res)
+	// links go away with the supplier
+	assert.NoError(t, st.DeleteSupplier(ctx, tx, *acme))
+	res, _ = st.GetProductSuppliers(ctx, tx, *product)
+	assert.Equal(t, []model.ProductSupplier{links[1]}, res)
+	st.DeleteProduct(ctx, tx, *product)
+	res, _ = st.GetProductSuppliers(ctx, tx, *product)
+	assert.Empty(t, res)
+}
+
+func TestStore_UniqueCategoryName(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreateCategory(ctx, tx, &model.Category{Name: "Phones"})
+	assert.NoError(t, err)
+	_, err = st.CreateCategory(ctx, tx, &model.Category{Name: "phones"})
+	assert.Equal(t, &db.ConflictError{Entity: "category", Field: "name", Id: *id}, err)
+	other, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "Tablets"})
+	err = st.UpdateCategory(ctx, tx, &model.Category{Id: *other, Name: "PHONES"})
+	assert.Equal(t, &db.ConflictError{Entity: "category", Field: "name", Id: *id}, err)
+	// renaming a category to itself is fine
+	assert.NoError(t, st.UpdateCategory(ctx, tx, &model.Category{Id: *id, Name: "phones"}))
+}
+
+func TestStore_DuplicateCategoryNames(t *testing.T) {
+	// A database of before the names were unique
+	conf := &config.Config{}
+	conf.Store.Dbpath = filepath.Join(t.TempDir(), "store.db")
+	old, err := sql.Open("sqlite3", conf.Store.Dbpath)
+	assert.NoError(t, err)
+	_, err = old.Exec(`CREATE TABLE "category" ("id" INTEGER NOT NULL, "name" TEXT NOT NULL, PRIMARY KEY("id" AUTOINCREMENT));
+		INSERT INTO category(name) VALUES ('Phones'), ('TV'), ('phones'), ('Audio'), ('Tv');`)
+	assert.NoError(t, err)
+	_, err = db.NewStore(conf)
+	assert.EqualError(t, err, `categories share names, rename them before the names are made unique: "Phones" (ids 1, 3); "TV" (ids 2, 5)`)
+	// The names are left to the editors
+	var name string
+	assert.NoError(t, old.QueryRow("SELECT name FROM category WHERE id = 3;").Scan(&name))
+	assert.Equal(t, "phones", name)
+	_, err = old.Exec("UPDATE category SET name = 'Smartphones' WHERE id = 3; DELETE FROM category WHERE id = 5;")
+	assert.NoError(t, err)
+	old.Close()
+	renamed, err := db.NewStore(conf)
+	assert.NoError(t, err)
+	defer renamed.Close()
+	_, err = renamed.CreateCategory(ctx, nil, &model.Category{Name: "audio"})
+	assert.IsType(t, &db.ConflictError{}, err)
+}
+
+func TestStore_DuplicateBrandNames(t *testing.T) {
+	conf := &config.Config{}
+	conf.Store.Dbpath = filepath.Join(t.TempDir(), "store.db")
+	old, err := sql.Open("sqlite3", conf.Store.Dbpath)
+	assert.NoError(t, err)
+	defer old.Close()
+	_, err = old.Exec(`CREATE TABLE "brand" ("id" INTEGER NOT NULL, "name" TEXT NOT NULL, PRIMARY KEY("id" AUTOINCREMENT));
+		INSERT INTO brand(name) VALUES ('Acme'), ('ACME');`)
+	assert.NoError(t, err)
+	_, err = db.NewStore(conf)
+	assert.EqualError(t, err, `brands share names, rename them before the names are made unique: "ACME" (ids 1, 2)`)
+}
+
+func TestStore_UniqueSKU(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	id, err := st.CreateProduct(ctx, tx, &model.Product{Name: "stocked", Price: 1, SKU: "SKU-TEST-1"})
+	assert.NoError(t, err)
+	p, _ := st.GetProductBySKU(ctx, tx, "SKU-TEST-1")
+	assert.Equal(t, *id, p.Id)
+	assert.Equal(t, "SKU-TEST-1", p.SKU)
+	p, _ = st.GetProductBySKU(ctx, tx, "SKU-TEST-2")
+	assert.Nil(t, p)
+	_, err = st.CreateProduct(ctx, tx, &model.Product{Name: "duplicate", Price: 1, SKU: "SKU-TEST-1"})
+	assert.Equal(t, &db.ConflictError{Entity: "product", Field: "sku", Id: *id}, err)
+	// products without SKU don't conflict
+	other, err := st.CreateProduct(ctx, tx, &model.Product{Name: "plain", Price: 1})
+	assert.NoError(t, err)
+	_, err = st.CreateProduct(ctx, tx, &model.Product{Name: "plain2", Price: 1})
+	assert.NoError(t, err)
+	err = st.UpdateProduct(ctx, tx, &model.Product{Id: *other, Name: "plain", Price: 1, SKU: "SKU-TEST-1"})
+	assert.Equal(t, &db.ConflictError{Entity: "product", Field: "sku", Id: *id}, err)
+}
+
+func TestStore_Savepoint(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	assert.NoError(t, st.Savepoint(ctx, tx, "test"))
+	undone, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "undone"})
+	assert.NoError(t, st.RollbackTo(ctx, tx, "test"))
+	c, _ := st.GetCategory(ctx, tx, *undone)
+	assert.Nil(t, c)
+	assert.NoError(t, st.Savepoint(ctx, tx, "test"))
+	kept, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "kept"})
+	assert.NoError(t, st.Release(ctx, tx, "test"))
+	c, _ = st.GetCategory(ctx, tx, *kept)
+	assert.NotNil(t, c)
+	assert.Error(t, st.Savepoint(ctx, nil, "test"))
+}
+
+func TestStore_ExportProducts(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	category, _ := st.CreateCategory(ctx, tx, &model.Category{Name: "exported"})
+	weight := 1.5
+	st.CreateProduct(ctx, tx, &model.Product{Name: "b", Category: *category, Price: 2, SKU: "EXP-B", Tags: []string{"x", "y"}, Weight: &weight, Status: model.ProductStatusPublished})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "a", Category: *category, Price: 1, Status: model.ProductStatusPublished})
+	st.CreateProduct(ctx, tx, &model.Product{Name: "draft", Category: *category, Price: 1, Status: model.ProductStatusDraft})
+	var exported []*model.ExportedProduct
+	filter := &model.ProductFilter{Category: category, Statuses: []string{model.ProductStatusPublished}, Sort: "-price"}
+	err := st.ExportProducts(ctx, tx, filter, func(p *model.ExportedProduct) error {
+		exported = append(exported, p)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, exported, 2)
+	assert.Equal(t, "EXP-B", exported[0].SKU)
+	assert.Equal(t, "exported", exported[0].CategoryName)
+	assert.Equal(t, []string{"x", "y"}, exported[0].Tags)
+	assert.Equal(t, &weight, exported[0].Weight)
+	assert.Equal(t, []string{}, exported[1].Tags)
+	// errors of the callback stop the export
+	stop := errors.New("stop")
+	calls := 0
+	err = st.ExportProducts(ctx, tx, filter, func(p *model.ExportedProduct) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStore_ApiKeys(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := &model.ApiKey{Name: "shop", Prefix: "pk_test", Scopes: []string{model.ScopeRead, model.ScopeWrite},
+		ExpiresAt: &expires, CreatedAt: time.Now(), Hash: "store-test-hash"}
+	id, err := st.CreateApiKey(ctx, tx, key)
+	assert.NoError(t, err)
+	count, err := st.CountApiKeys(ctx, tx)
+	assert.NoError(t, err)
+	assert.NotZero(t, count)
+	k, err := st.GetApiKeyByHash(ctx, tx, "store-test-hash")
+	assert.NoError(t, err)
+	assert.Equal(t, *id, k.Id)
+	assert.Equal(t, []string{model.ScopeRead, model.ScopeWrite}, k.Scopes)
+	assert.True(t, expires.Equal(*k.ExpiresAt))
+	assert.Nil(t, k.LastUsedAt)
+	assert.Nil(t, k.RevokedAt)
+	// last use, rotation and revocation
+	used := time.Now().Add(-time.Minute)
+	assert.NoError(t, st.TouchApiKey(ctx, tx, *id, used))
+	assert.NoError(t, st.SetApiKeyHash(ctx, tx, *id, "pk_other", "store-test-rotated"))
+	assert.NoError(t, st.RevokeApiKey(ctx, tx, *id, time.Now()))
+	k, _ = st.GetApiKeyByHash(ctx, tx, "store-test-hash")
+	assert.Nil(t, k)
+	k, _ = st.GetApiKey(ctx, tx, *id)
+	assert.Equal(t, "pk_other", k.Prefix)
+	assert.True(t, used.Equal(*k.LastUsedAt))
+	assert.NotNil(t, k.RevokedAt)
+	keys, err := st.GetApiKeys(ctx, tx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, keys)
+	assert.Equal(t, sql.ErrNoRows, st.RevokeApiKey(ctx, tx, -1, time.Now()))
+}
+
+func TestStore_IdempotencyKeys(t *testing.T) {
+	tx, _ := st.Begin(ctx)
+	defer st.Rollback(ctx, tx)
+	now := time.Now()
+	key := &model.IdempotencyKey{Key: "store-test " + now.String(), Fingerprint: "f", CreatedAt: now}
+	created, err := st.CreateIdempotencyKey(ctx, tx, key)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	created, err = st.CreateIdempotencyKey(ctx, tx, &model.IdempotencyKey{Key: key.Key, Fingerprint: "other", CreatedAt: now})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	k, err := st.GetIdempotencyKey(ctx, tx, key.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "f", k.Fingerprint)
+	assert.Nil(t, k.Response)
+	// locked until the request is taken for dead
+	relocked, err := st.RelockIdempotencyKey(ctx, tx, key.Key, now.Add(-time.Second), now)
+	assert.NoError(t, err)
+	assert.False(t, relocked)
+	relocked, err = st.RelockIdempotencyKey(ctx, tx, key.Key, now.Add(time.Second), now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, relocked)
+	relocked, _ = st.RelockIdempotencyKey(ctx, tx, key.Key, now.Add(time.Second), now.Add(time.Second))
+	assert.False(t, relocked)
+	response := &model.IdempotentResponse{Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	assert.NoError(t, st.SetIdempotentResponse(ctx, tx, key.Key, response))
+	k, _ = st.GetIdempotencyKey(ctx, tx, key.Key)
+	assert.Equal(t, response, k.Response)
+	// answered keys stay
+	relocked, _ = st.RelockIdempotencyKey(ctx, tx, key.Key, now.Add(time.Hour), now.Add(time.Hour))
+	assert.False(t, relocked)
+	// expiry
+	deleted, err := st.DeleteIdempotencyKeys(ctx, tx, now.Add(-time.Minute))
+	assert.NoError(t, err)
+	k, _ = st.GetIdempotencyKey(ctx, tx, key.Key)
+	assert.NotNil(t, k)
+	deleted, err = st.DeleteIdempotencyKeys(ctx, tx, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.NotZero(t, deleted)
+	k, _ = st.GetIdempotencyKey(ctx, tx, key.Key)
+	assert.Nil(t, k)
+}
+
+func TestStore_Logging(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&log.JSONFormatter{})
+	logger.SetLevel(log.DebugLevel)
+	ctx := logging.WithEntry(ctx, logger.WithField("request_id", "store-test"))
+	tx, _ := st.Begin(ctx)
+	_, err := st.GetCategory(ctx, tx, -1)
+	assert.NoError(t, err)
+	assert.NoError(t, st.Rollback(ctx, tx))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "store-test", line["request_id"])
+	assert.Equal(t, "SQL statement", line["msg"])
+	assert.Equal(t, "SELECT id, name, COALESCE(slug, ''), tax_class FROM category WHERE id= $1;", line["query"])
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, "Transaction rolled back", line["msg"])
+	// Nothing below the level of the logger
+	out.Reset()
+	logger.SetLevel(log.InfoLevel)
+	_, err = st.GetCategories(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, out.String())
 }
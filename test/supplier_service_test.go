@@ -0,0 +1,97 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupplierService_CreateSupplier(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	ss := service.NewSupplierService(mockStore)
+	r, e := ss.CreateSupplier(ctx, &model.Supplier{Name: "Acme"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	id := 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateSupplier(gomock.Any(), tx, &model.Supplier{Name: "Acme"}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ss = service.NewSupplierService(mockStore)
+	r, e = ss.CreateSupplier(ctx, &model.Supplier{Name: "Acme"})
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+}
+
+func TestSupplierService_GetProductSuppliers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 1).Return(nil, nil).Times(1)
+	ss := service.NewSupplierService(mockStore)
+	r, e := ss.GetProductSuppliers(ctx, 1)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, e)
+	assert.Nil(t, r)
+
+	links := []model.ProductSupplier{{Supplier: 1, CostPrice: 5}}
+	mockStore.EXPECT().GetProduct(gomock.Any(), nil, 2).Return(&model.Product{Id: 2}, nil).Times(1)
+	mockStore.EXPECT().GetProductSuppliers(gomock.Any(), nil, 2).Return(links, nil).Times(1)
+	r, e = ss.GetProductSuppliers(ctx, 2)
+	assert.Nil(t, e)
+	assert.Equal(t, links, r)
+}
+
+func TestSupplierService_SetProductSuppliers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// several preferred
+	mockStore := mock.NewMockStore(mockCtrl)
+	ss := service.NewSupplierService(mockStore)
+	e := ss.SetProductSuppliers(ctx, 1, []model.ProductSupplier{{Supplier: 1, Preferred: true}, {Supplier: 2, Preferred: true}})
+	assert.Equal(t, service.ErrPreferredSupplier, e)
+
+	// unknown product
+	links := []model.ProductSupplier{{Supplier: 1, Preferred: true}, {Supplier: 2}}
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ss = service.NewSupplierService(mockStore)
+	assert.Equal(t, &service.NotFoundError{Entity: "product", Field: "id", Value: 1}, ss.SetProductSuppliers(ctx, 1, links))
+
+	// unknown supplier
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().GetSupplier(gomock.Any(), tx, 1).Return(&model.Supplier{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().GetSupplier(gomock.Any(), tx, 2).Return(nil, nil).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	ss = service.NewSupplierService(mockStore)
+	assert.Equal(t, service.ErrUnknownSupplier, ss.SetProductSuppliers(ctx, 1, links))
+
+	// ok
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().GetProduct(gomock.Any(), tx, 1).Return(&model.Product{Id: 1}, nil).Times(1)
+	mockStore.EXPECT().GetSupplier(gomock.Any(), tx, gomock.Any()).Return(&model.Supplier{}, nil).Times(2)
+	mockStore.EXPECT().SetProductSuppliers(gomock.Any(), tx, 1, links).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	ss = service.NewSupplierService(mockStore)
+	assert.Nil(t, ss.SetProductSuppliers(ctx, 1, links))
+}
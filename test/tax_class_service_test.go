@@ -0,0 +1,159 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/test/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxClassService_GetTaxClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), nil, 1).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), nil, 2).Return(&model.TaxClass{Id: 2, Name: "test"}, nil).Times(1)
+	mockStore.EXPECT().GetTaxClass(gomock.Any(), nil, 3).Return(nil, nil).Times(1)
+	tcs := service.NewTaxClassService(mockStore)
+	r, e := tcs.GetTaxClass(ctx, 1)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	r, e = tcs.GetTaxClass(ctx, 2)
+	assert.Nil(t, e)
+	assert.Equal(t, &model.TaxClass{Id: 2, Name: "test"}, r)
+	r, e = tcs.GetTaxClass(ctx, 3)
+	assert.Equal(t, &service.NotFoundError{Entity: "tax class", Field: "id", Value: 3}, e)
+	assert.Nil(t, r)
+}
+
+func TestTaxClassService_GetTaxClasses(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().GetTaxClasses(gomock.Any(), nil).Return(nil, errors.New("test")).Times(1)
+	tcs := service.NewTaxClassService(mockStore)
+	r, e := tcs.GetTaxClasses(ctx)
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+	mockStore.EXPECT().GetTaxClasses(gomock.Any(), nil).Return([]*model.TaxClass{}, nil).Times(1)
+	r, e = tcs.GetTaxClasses(ctx)
+	assert.Nil(t, e)
+	assert.NotNil(t, r)
+}
+
+func TestTaxClassService_CreateTaxClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	tcs := service.NewTaxClassService(mockStore)
+	r, e := tcs.CreateTaxClass(ctx, &model.TaxClass{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateTaxClass(gomock.Any(), tx, &model.TaxClass{Name: "Test"}).Return(nil, errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	r, e = tcs.CreateTaxClass(ctx, &model.TaxClass{Name: "Test"})
+	assert.NotNil(t, e)
+	assert.Nil(t, r)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	var id = 1
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateTaxClass(gomock.Any(), tx, &model.TaxClass{Name: "Test"}).Return(&id, nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	r, e = tcs.CreateTaxClass(ctx, &model.TaxClass{Name: "Test"})
+	assert.Nil(t, e)
+	assert.Equal(t, &id, r)
+
+	// names are unique
+	mockStore = mock.NewMockStore(mockCtrl)
+	conflict := &service.ConflictError{Entity: "tax_class", Field: "name", Id: 1}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().CreateTaxClass(gomock.Any(), tx, &model.TaxClass{Name: "test"}).Return(nil, conflict).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	r, e = tcs.CreateTaxClass(ctx, &model.TaxClass{Name: "test"})
+	assert.Equal(t, conflict, e)
+	assert.Nil(t, r)
+}
+
+func TestTaxClassService_UpdateTaxClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	tcs := service.NewTaxClassService(mockStore)
+	e := tcs.UpdateTaxClass(ctx, nil)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	taxClass := &model.TaxClass{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateTaxClass(gomock.Any(), tx, taxClass).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	e = tcs.UpdateTaxClass(ctx, taxClass)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	taxClass = &model.TaxClass{Id: 1, Name: "test"}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateTaxClass(gomock.Any(), tx, taxClass).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	e = tcs.UpdateTaxClass(ctx, taxClass)
+	assert.Nil(t, e)
+	mockStore = mock.NewMockStore(mockCtrl)
+	conflict := &service.ConflictError{Entity: "tax_class", Field: "name", Id: 2}
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().UpdateTaxClass(gomock.Any(), tx, taxClass).Return(conflict).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	assert.Equal(t, conflict, tcs.UpdateTaxClass(ctx, taxClass))
+}
+
+func TestTaxClassService_DeleteTaxClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mock.NewMockStore(mockCtrl)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+	tcs := service.NewTaxClassService(mockStore)
+	e := tcs.DeleteTaxClass(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx := new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteTaxClass(gomock.Any(), tx, 1).Return(errors.New("test")).Times(1)
+	mockStore.EXPECT().Rollback(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	e = tcs.DeleteTaxClass(ctx, 1)
+	assert.NotNil(t, e)
+
+	mockStore = mock.NewMockStore(mockCtrl)
+	tx = new(sql.Tx)
+	mockStore.EXPECT().Begin(gomock.Any()).Return(tx, nil).Times(1)
+	mockStore.EXPECT().DeleteTaxClass(gomock.Any(), tx, 1).Return(nil).Times(1)
+	mockStore.EXPECT().Commit(gomock.Any(), tx).Return(nil).Times(1)
+	tcs = service.NewTaxClassService(mockStore)
+	e = tcs.DeleteTaxClass(ctx, 1)
+	assert.Nil(t, e)
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func TestTokenService_Verify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherRsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "RSA", "kid": "rsa-2", "n": b64(otherRsaKey.N), "e": b64(big.NewInt(int64(otherRsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+		{"kty": "oct", "kid": "skipped", "k": "c2VjcmV0"},
+	}})
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(jwksFile, jwks, 0600))
+
+	conf := &config.Config{}
+	_, err := service.NewTokenService(conf)
+	assert.Error(t, err)
+	conf.Jwt.Secrets = []string{"current-secret", "former-secret"}
+	conf.Jwt.JwksFile = jwksFile
+	conf.Jwt.Issuer = "https://sso.example.com"
+	conf.Jwt.Audience = "products-api"
+	conf.Jwt.RolesClaim = "roles"
+	ts, err := service.NewTokenService(conf)
+	assert.NoError(t, err)
+
+	valid := func(roles interface{}) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "jane", "iss": "https://sso.example.com", "aud": []string{"products-api"},
+			"exp": time.Now().Add(time.Hour).Unix(), "roles": roles}
+	}
+	// HMAC, with any of the secrets
+	p, err := ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", valid([]string{"viewer"})))
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Principal{Subject: "jane", Role: model.RoleViewer}, p)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodHS512, []byte("former-secret"), "", valid("viewer editor")))
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleEditor, p.Role)
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("unknown-secret"), "", valid("viewer")))
+	assert.IsType(t, &service.AuthenticationError{}, err)
+	// RSA and ECDSA, by kid or by trying the keys
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", valid([]string{"admin", "viewer"})))
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, p.Role)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodPS256, otherRsaKey, "", valid("editor")))
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleEditor, p.Role)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodES256, ecKey, "ec-1", valid("unknown-role")))
+	assert.NoError(t, err)
+	assert.Equal(t, "", p.Role)
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodRS256, otherRsaKey, "rsa-1", valid("viewer")))
+	assert.IsType(t, &service.AuthenticationError{}, err)
+	// Unsigned tokens and public keys used as secrets
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid("admin")))
+	assert.IsType(t, &service.AuthenticationError{}, err)
+	rsaPublic, _ := json.Marshal(rsaKey.PublicKey)
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, rsaPublic, "rsa-1", valid("admin")))
+	assert.IsType(t, &service.AuthenticationError{}, err)
+	// Claims
+	claims := valid("viewer")
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", claims))
+	assert.IsType(t, &service.AuthenticationError{}, err)
+	delete(claims, "exp")
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", claims))
+	assert.Equal(t, &service.AuthenticationError{Reason: "token without expiry"}, err)
+	claims = valid("viewer")
+	claims["iss"] = "https://elsewhere.example.com"
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", claims))
+	assert.Equal(t, &service.AuthenticationError{Reason: "token of another issuer"}, err)
+	claims = valid("viewer")
+	claims["aud"] = "another-api"
+	_, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", claims))
+	assert.Equal(t, &service.AuthenticationError{Reason: "token for another audience"}, err)
+	_, err = ts.Verify("not.a.token")
+	assert.Equal(t, &service.AuthenticationError{Reason: "malformed token"}, err)
+	// Mapped roles, the values without mapping grant nothing
+	conf.Jwt.Roles = map[string]string{"catalog-editors": model.RoleEditor, "catalog-viewers": model.RoleViewer}
+	ts, err = service.NewTokenService(conf)
+	assert.NoError(t, err)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", valid("catalog-viewers catalog-editors")))
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleEditor, p.Role)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", valid([]string{"admin", "catalog-viewers"})))
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleViewer, p.Role)
+	p, err = ts.Verify(signToken(t, jwt.SigningMethodHS256, []byte("current-secret"), "", valid("admin")))
+	assert.NoError(t, err)
+	assert.Equal(t, "", p.Role)
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrlightwood/golang-products-api/api"
+	"github.com/mrlightwood/golang-products-api/config"
+	"github.com/mrlightwood/golang-products-api/service"
+	"github.com/mrlightwood/golang-products-api/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collector "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	recorder     *tracetest.InMemoryExporter
+	recorderOnce sync.Once
+)
+
+// recordSpans records the spans of the tracers, the global provider is only
+// set once as the tracers keep the first one
+func recordSpans() *tracetest.InMemoryExporter {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder)))
+	})
+	recorder.Reset()
+	return recorder
+}
+
+// attributeOf returns the value of the attribute `key` of a span
+func attributeOf(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestApi_Tracing(t *testing.T) {
+	spans := recordSpans()
+	conf := &config.Config{LogLevel: 5}
+	api := api.NewApi(conf, api.Services{Categories: service.TraceCategoryService(service.NewCategoryService(st))})
+	assert.Contains(t, api.GetApiInfo().MW, "Tracing")
+	req := httptest.NewRequest(echo.GET, "/api/categories/-1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	// Ended from the innermost
+	ended := spans.GetSpans()
+	assert.Len(t, ended, 3)
+	statement, call, request := ended[0], ended[1], ended[2]
+	assert.Equal(t, "GET /api/categories/:id", request.Name)
+	assert.Equal(t, trace.SpanKindServer, request.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", request.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", request.Parent.SpanID().String())
+	assert.True(t, request.Parent.IsRemote())
+	assert.Equal(t, int64(http.StatusNotFound), attributeOf(request, "http.status_code").AsInt64())
+	assert.Equal(t, "/api/categories/:id", attributeOf(request, "http.route").AsString())
+	// Client errors aren't errors of the server
+	assert.Equal(t, codes.Unset, request.Status.Code)
+	assert.Equal(t, "CategoryService.GetCategory", call.Name)
+	assert.Equal(t, request.SpanContext.SpanID(), call.Parent.SpanID())
+	assert.Equal(t, int64(-1), attributeOf(call, "id").AsInt64())
+	assert.Equal(t, codes.Error, call.Status.Code)
+	assert.Equal(t, "SELECT", statement.Name)
+	assert.Equal(t, call.SpanContext.SpanID(), statement.Parent.SpanID())
+	assert.Equal(t, request.SpanContext.TraceID(), statement.SpanContext.TraceID())
+	assert.Equal(t, "sqlite", attributeOf(statement, "db.system").AsString())
+	assert.Equal(t, "SELECT id, name, COALESCE(slug, ''), tax_class FROM category WHERE id= $1;", attributeOf(statement, "db.statement").AsString())
+
+	// A new trace without traceparent
+	spans.Reset()
+	rec = httptest.NewRecorder()
+	api.Http.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/api/unknown", nil))
+	ended = spans.GetSpans()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, "HTTP GET", ended[0].Name)
+	assert.False(t, ended[0].Parent.IsValid())
+	assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", ended[0].SpanContext.TraceID().String())
+}
+
+func TestTracing_NewProvider(t *testing.T) {
+	conf := &config.Config{}
+	tp, err := tracing.NewProvider(conf)
+	assert.NoError(t, err)
+	assert.Nil(t, tp)
+	conf.Tracing.Exporter = "zipkin"
+	_, err = tracing.NewProvider(conf)
+	assert.Error(t, err)
+	conf.Tracing.Exporter = tracing.ExporterStdout
+	tp, err = tracing.NewProvider(conf)
+	assert.NoError(t, err)
+	assert.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestTracing_OTLP(t *testing.T) {
+	// Stands in for a collector receiving OTLP over HTTP
+	received := make(chan *collector.ExportTraceServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		export := &collector.ExportTraceServiceRequest{}
+		assert.NoError(t, proto.Unmarshal(body, export))
+		received <- export
+		response, _ := proto.Marshal(&collector.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(response)
+	}))
+	defer server.Close()
+	conf := &config.Config{}
+	conf.Tracing.Exporter = tracing.ExporterOTLP
+	conf.Tracing.Endpoint = server.Listener.Addr().String()
+	conf.Tracing.Insecure = true
+	conf.Tracing.ServiceName = "products-api-test"
+	conf.Tracing.SampleRatio = 1
+	tp, err := tracing.NewProvider(conf)
+	assert.NoError(t, err)
+	_, span := tp.Tracer("test").Start(context.Background(), "test span")
+	span.End()
+	// Flushes the batch
+	assert.NoError(t, tp.Shutdown(context.Background()))
+	export := <-received
+	assert.Len(t, export.ResourceSpans, 1)
+	var service string
+	for _, kv := range export.ResourceSpans[0].Resource.Attributes {
+		if kv.Key == "service.name" {
+			service = kv.Value.GetStringValue()
+		}
+	}
+	assert.Equal(t, "products-api-test", service)
+	assert.Equal(t, "test span", export.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+}
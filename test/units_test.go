@@ -0,0 +1,24 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mrlightwood/golang-products-api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProduct_Units(t *testing.T) {
+	weight, length := 2.0, 10.0
+	product := &model.Product{Weight: &weight, Length: &length}
+	product.FromUnits(model.UnitsImperial)
+	assert.InDelta(t, 0.90718474, *product.Weight, 1e-9)
+	assert.InDelta(t, 0.254, *product.Length, 1e-9)
+	assert.Nil(t, product.Width)
+	product.ToUnits(model.UnitsImperial)
+	assert.InDelta(t, 2, *product.Weight, 1e-9)
+	assert.InDelta(t, 10, *product.Length, 1e-9)
+	product.ToUnits(model.UnitsMetric)
+	assert.InDelta(t, 2, *product.Weight, 1e-9)
+	assert.InDelta(t, 1, model.WeightFromUnits(1, model.UnitsMetric), 1e-9)
+	assert.InDelta(t, 0.0254, model.LengthFromUnits(1, model.UnitsImperial), 1e-9)
+}